@@ -27,6 +27,12 @@ type Config struct {
 	// API Configuration
 	API APIConfig
 
+	// Task Executor Configuration
+	Executor ExecutorConfig
+
+	// Grid Snapshot Sync Configuration
+	Sync SyncConfig
+
 	// Logging Configuration
 	Logging LoggingConfig
 
@@ -59,6 +65,23 @@ type APIConfig struct {
 	Timeout   time.Duration
 }
 
+// ExecutorConfig points the API at a remote executor (anubis-executer serve).
+// When URL is empty, tasks run in-process.
+type ExecutorConfig struct {
+	URL         string
+	Secret      string // Shared secret sent as a bearer token
+	TLSCertFile string // Client certificate and key for mTLS
+	TLSKeyFile  string
+	TLSCAFile   string // CA that signed the executor's certificate
+}
+
+// SyncConfig controls the background job that copies farms, nodes and stats
+// from the executor into local tables. A zero Interval disables it; it
+// defaults to 15 minutes with a remote executor and to disabled without one.
+type SyncConfig struct {
+	Interval time.Duration
+}
+
 type LoggingConfig struct {
 	Level  string
 	Format string
@@ -77,6 +100,13 @@ func LoadConfig() *Config {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// The in-process executor cannot list nodes or grid stats, so the grid
+	// snapshot sync only runs by default against a remote executor
+	syncInterval := "0"
+	if getEnv("EXECUTOR_URL", "") != "" {
+		syncInterval = "15m"
+	}
+
 	config := &Config{
 		Env:  getEnv("ENV", "development"),
 		Port: getEnv("PORT", "8080"),
@@ -106,6 +136,18 @@ func LoadConfig() *Config {
 			Timeout:   getEnvAsDuration("API_TIMEOUT", "30s"),
 		},
 
+		Executor: ExecutorConfig{
+			URL:         getEnv("EXECUTOR_URL", ""),
+			Secret:      getEnv("EXECUTOR_SECRET", ""),
+			TLSCertFile: getEnv("EXECUTOR_TLS_CERT", ""),
+			TLSKeyFile:  getEnv("EXECUTOR_TLS_KEY", ""),
+			TLSCAFile:   getEnv("EXECUTOR_TLS_CA", ""),
+		},
+
+		Sync: SyncConfig{
+			Interval: getEnvAsDuration("GRID_SYNC_INTERVAL", syncInterval),
+		},
+
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
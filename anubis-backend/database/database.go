@@ -93,6 +93,11 @@ func RunMigrations() error {
 		&models.UserMemory{},
 		&models.TaskExecution{},
 		&models.PasswordReset{},
+		&models.GridFarm{},
+		&models.GridNode{},
+		&models.GridChange{},
+		&models.GridStatsSnapshot{},
+		&models.GridSyncRun{},
 	)
 
 	if err != nil {
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd
+
+package handlers
+
+import "net"
+
+// connClosed cannot check the connection on this platform, so requests are
+// only cancelled when the server shuts down
+func connClosed(conn net.Conn) (closed, ok bool) {
+	return false, false
+}
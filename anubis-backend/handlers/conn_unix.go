@@ -0,0 +1,42 @@
+//go:build linux || darwin || freebsd
+
+package handlers
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// connClosed reports whether the peer of conn has closed the connection,
+// by peeking at the socket without consuming any pipelined request data.
+// ok is false when conn is not backed by a socket that can be checked.
+func connClosed(conn net.Conn) (closed, ok bool) {
+	sc, isSyscallConn := conn.(syscall.Conn)
+	if !isSyscallConn {
+		return false, false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false, false
+	}
+
+	buf := make([]byte, 1)
+	err = raw.Read(func(fd uintptr) bool {
+		n, _, err := syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		switch {
+		case n == 0 && err == nil:
+			closed = true
+		case errors.Is(err, syscall.ECONNRESET):
+			closed = true
+		}
+		// Never wait for the socket to become readable
+		return true
+	})
+	if err != nil {
+		// The connection is already closed on our side
+		return true, true
+	}
+
+	return closed, true
+}
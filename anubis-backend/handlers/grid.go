@@ -0,0 +1,82 @@
+// Package handlers provides HTTP request handlers for the local grid snapshot.
+// This file contains handlers for the sync job's status and for node history
+// recorded between syncs, e.g. how long a node has been down.
+package handlers
+
+import (
+	"anubis-backend/database"
+	"anubis-backend/models"
+	"anubis-backend/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GridSyncStatusResponse lists the latest runs of the grid snapshot sync
+type GridSyncStatusResponse struct {
+	Runs      []models.GridSyncRun `json:"runs"`                                         // Latest runs, newest first
+	RequestID string               `json:"request_id,omitempty" example:"req_123456789"` // Request identifier
+}
+
+// GridSyncStatus godoc
+// @Summary Get grid sync status
+// @Description Returns the latest runs of the background job that copies farms, nodes and stats into the local grid snapshot
+// @Tags grid
+// @Produce json
+// @Param limit query int false "Number of runs to return (1-100)" default(10)
+// @Success 200 {object} GridSyncStatusResponse "Latest sync runs"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /grid/sync [get]
+func GridSyncStatus(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 10)
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	runs, err := services.GetSyncRuns(database.GetDB(), limit)
+	if err != nil {
+		return NewErrorResponse(c, fiber.StatusInternalServerError,
+			"Failed to fetch sync runs",
+			err.Error())
+	}
+
+	return c.JSON(GridSyncStatusResponse{
+		Runs:      runs,
+		RequestID: c.Get("X-Request-ID", ""),
+	})
+}
+
+// NodeHistory godoc
+// @Summary Get node history
+// @Description Returns a node's state in the local grid snapshot, how long it has had its current status, and the changes recorded between syncs
+// @Description Status durations are lower bounds: a status seen on the first sync counts from that sync
+// @Tags grid
+// @Produce json
+// @Param id path int true "Node ID"
+// @Param limit query int false "Number of changes to return (1-500)" default(50)
+// @Success 200 {object} services.NodeHistory "Node history"
+// @Failure 400 {object} ErrorResponse "Invalid node ID"
+// @Failure 404 {object} ErrorResponse "The node is not in the snapshot"
+// @Failure 502 {object} ErrorResponse "No snapshot has been synced yet"
+// @Router /grid/nodes/{id}/history [get]
+func NodeHistory(c *fiber.Ctx) error {
+	nodeID, err := c.ParamsInt("id")
+	if err != nil || nodeID < 1 {
+		return NewErrorResponse(c, fiber.StatusBadRequest,
+			"Invalid node ID",
+			"Node ID must be a positive integer")
+	}
+
+	limit := c.QueryInt("limit", 0)
+	if limit < 0 || limit > 500 {
+		limit = 0
+	}
+
+	history, err := services.GetNodeHistory(database.GetDB(), nodeID, limit)
+	if err != nil {
+		return NewErrorResponse(c, taskErrorStatus(services.ErrorCode(err)),
+			"Failed to fetch node history",
+			err.Error())
+	}
+
+	return c.JSON(history)
+}
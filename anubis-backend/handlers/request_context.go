@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// disconnectPollInterval is how often a running request checks whether its
+// client has gone away
+const disconnectPollInterval = 100 * time.Millisecond
+
+// requestContext returns a context for work done on behalf of the request.
+// Fiber's user context is never cancelled, so the returned one is cancelled
+// when the client closes its connection or the server shuts down; the caller
+// must call the cancel function once the request is handled.
+func requestContext(c *fiber.Ctx) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(c.UserContext())
+
+	// The fasthttp request context is recycled after the handler returns,
+	// so everything the watcher needs is taken from it up front
+	shutdown := c.Context().Done()
+	conn := c.Context().Conn()
+
+	go func() {
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-shutdown:
+				cancel()
+				return
+			case <-ticker.C:
+				if closed, ok := connClosed(conn); !ok {
+					return
+				} else if closed {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, cancel
+}
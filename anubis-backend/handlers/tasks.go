@@ -4,7 +4,9 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -20,21 +22,21 @@ import (
 // This structure provides detailed documentation for each available task including
 // parameter specifications and usage examples for API consumers.
 type TaskInfo struct {
-	Name        string                 `json:"name" example:"list_farms"`                    // Task identifier
-	Description string                 `json:"description" example:"List ThreeFold farms"`   // Human-readable description
-	Parameters  map[string]interface{} `json:"parameters"`                                   // Parameter specifications
-	Example     map[string]interface{} `json:"example"`                                      // Usage example
-	Category    string                 `json:"category" example:"farms"`                     // Task category for organization
-	Version     string                 `json:"version" example:"1.0"`                        // Task version for compatibility
+	Name        string                 `json:"name" example:"list_farms"`                  // Task identifier
+	Description string                 `json:"description" example:"List ThreeFold farms"` // Human-readable description
+	Parameters  *services.ParamSchema  `json:"parameters"`                                 // JSON-Schema style parameter specification
+	Example     map[string]interface{} `json:"example"`                                    // Usage example
+	Category    string                 `json:"category" example:"farms"`                   // Task category for organization
+	Version     string                 `json:"version" example:"1.0"`                      // Task version for compatibility
 }
 
 // AvailableTasksResponse represents the response for available tasks endpoint.
 // This provides a comprehensive list of all supported ThreeFold Grid operations.
 type AvailableTasksResponse struct {
-	Tasks     []TaskInfo `json:"tasks"`                                           // Available tasks with full documentation
-	Count     int        `json:"count" example:"2"`                               // Total number of available tasks
-	Timestamp time.Time  `json:"timestamp" example:"2024-01-01T12:00:00Z"`       // Response timestamp
-	RequestID string     `json:"request_id,omitempty" example:"req_123456789"`   // Request identifier
+	Tasks     []TaskInfo `json:"tasks"`                                        // Available tasks with full documentation
+	Count     int        `json:"count" example:"2"`                            // Total number of available tasks
+	Timestamp time.Time  `json:"timestamp" example:"2024-01-01T12:00:00Z"`     // Response timestamp
+	RequestID string     `json:"request_id,omitempty" example:"req_123456789"` // Request identifier
 }
 
 // AvailableTasks godoc
@@ -46,43 +48,18 @@ type AvailableTasksResponse struct {
 // @Success 200 {object} AvailableTasksResponse "List of available tasks retrieved successfully"
 // @Router /available-tasks [get]
 func AvailableTasks(c *fiber.Ctx) error {
-	// Define all supported tasks with comprehensive documentation
-	tasks := []TaskInfo{
-		{
-			Name:        "list_farms",
-			Description: "List ThreeFold farms with optional filtering and pagination support",
-			Category:    "farms",
-			Version:     "1.0",
-			Parameters: map[string]interface{}{
-				"page":     "integer (optional) - Page number for pagination (default: 1, max: 1000)",
-				"location": "string (optional) - Filter by country code (e.g., 'BE', 'US') or location name",
-				"name":     "string (optional) - Filter by farm name using case-insensitive contains search",
-				"farm_id":  "integer (optional) - Filter by specific farm ID for exact match",
-			},
-			Example: map[string]interface{}{
-				"task_name": "list_farms",
-				"params": map[string]interface{}{
-					"page":     1,
-					"location": "BE",
-					"name":     "freefarm",
-				},
-			},
-		},
-		{
-			Name:        "get_farm",
-			Description: "Get comprehensive information about a specific ThreeFold farm including resources and public IPs",
-			Category:    "farms",
-			Version:     "1.0",
-			Parameters: map[string]interface{}{
-				"farm_id": "integer (required) - The unique ID of the farm to retrieve (must be > 0)",
-			},
-			Example: map[string]interface{}{
-				"task_name": "get_farm",
-				"params": map[string]interface{}{
-					"farm_id": 1,
-				},
-			},
-		},
+	// Task documentation comes from the executor's task registry
+	definitions := services.GetTaskDefinitions()
+	tasks := make([]TaskInfo, 0, len(definitions))
+	for _, def := range definitions {
+		tasks = append(tasks, TaskInfo{
+			Name:        def.Name,
+			Description: def.Description,
+			Category:    def.Category,
+			Version:     def.Version,
+			Parameters:  def.Parameters,
+			Example:     def.Example,
+		})
 	}
 
 	response := AvailableTasksResponse{
@@ -98,33 +75,47 @@ func AvailableTasks(c *fiber.Ctx) error {
 // ExecuteTaskRequest represents the request for task execution with comprehensive validation.
 // This structure defines the expected format for task execution requests.
 type ExecuteTaskRequest struct {
-	TaskName string                 `json:"task_name" validate:"required" example:"list_farms"`  // Task identifier (required)
-	Params   map[string]interface{} `json:"params" example:"{\"page\": 1}"`                      // Task parameters (optional)
+	TaskName string                 `json:"task_name" validate:"required" example:"list_farms"` // Task identifier (required)
+	Params   map[string]interface{} `json:"params" example:"{\"page\": 1}"`                     // Task parameters (optional)
+	Timeout  string                 `json:"timeout,omitempty" example:"10s"`                    // Execution timeout (optional, capped by API_TIMEOUT)
+	Mode     string                 `json:"mode,omitempty" example:"stale_ok"`                  // live (default), stale_ok or offline; see the grid snapshot
 }
 
 // ExecuteTaskResponse represents the response for task execution with comprehensive result information.
 // This structure provides detailed execution results including performance metrics and error details.
 type ExecuteTaskResponse struct {
-	TaskID    uuid.UUID   `json:"task_id" example:"123e4567-e89b-12d3-a456-426614174000"`     // Unique task execution ID
-	Status    string      `json:"status" example:"success"`                                    // Execution status (success/failed)
-	Data      interface{} `json:"data,omitempty"`                                             // Task result data (on success)
-	Error     string      `json:"error,omitempty" example:"farm_id parameter is required"`   // Error message (on failure)
-	Duration  int64       `json:"duration_ms" example:"150"`                                  // Execution time in milliseconds
-	Timestamp time.Time   `json:"timestamp" example:"2024-01-01T12:00:00Z"`                  // Execution timestamp
-	RequestID string      `json:"request_id,omitempty" example:"req_123456789"`              // Request identifier for tracing
+	TaskID    uuid.UUID                `json:"task_id" example:"123e4567-e89b-12d3-a456-426614174000"`  // Unique task execution ID
+	Status    string                   `json:"status" example:"success"`                                // Execution status (success/failed)
+	Data      interface{}              `json:"data,omitempty"`                                          // Task result data (on success)
+	Error     string                   `json:"error,omitempty" example:"farm_id parameter is required"` // Error message (on failure)
+	Code      string                   `json:"code,omitempty" example:"not_found"`                      // Machine-readable error code (on failure)
+	Retryable bool                     `json:"retryable,omitempty" example:"false"`                     // Whether retrying later may succeed (on failure)
+	Field     string                   `json:"field,omitempty" example:"farm_id"`                       // Offending parameter, for invalid_params
+	Errors    []services.FieldError    `json:"errors,omitempty"`                                        // Every invalid parameter, for invalid_params
+	Upstream  *services.UpstreamStatus `json:"upstream,omitempty"`                                      // Failed GridProxy call, for upstream_unavailable
+	Source    string                   `json:"source,omitempty" example:"snapshot"`                     // live or snapshot: where the data came from
+	SyncedAt  *time.Time               `json:"synced_at,omitempty" example:"2024-01-01T11:45:00Z"`      // When the snapshot was taken, for snapshot data
+	Duration  int64                    `json:"duration_ms" example:"150"`                               // Execution time in milliseconds
+	Timestamp time.Time                `json:"timestamp" example:"2024-01-01T12:00:00Z"`                // Execution timestamp
+	RequestID string                   `json:"request_id,omitempty" example:"req_123456789"`            // Request identifier for tracing
 }
 
 // ExecuteTask godoc
 // @Summary Execute a task
 // @Description Execute a ThreeFold Grid task with comprehensive validation and error handling
 // @Description This endpoint processes task execution requests, validates parameters, logs execution history, and returns detailed results
+// @Description With mode stale_ok, tasks the grid snapshot supports fall back to it when GridProxy or the executor is unavailable; mode offline answers from the snapshot only
 // @Tags tasks
 // @Accept json
 // @Produce json
 // @Param request body ExecuteTaskRequest true "Task execution request with task name and parameters"
 // @Success 200 {object} ExecuteTaskResponse "Task executed successfully"
-// @Failure 400 {object} ErrorResponse "Invalid request or parameters"
+// @Failure 400 {object} ErrorResponse "Invalid request; invalid parameters are reported as an ExecuteTaskResponse with code invalid_params"
+// @Failure 404 {object} ExecuteTaskResponse "The requested grid object does not exist"
 // @Failure 500 {object} ErrorResponse "Internal server error during task execution"
+// @Failure 502 {object} ExecuteTaskResponse "GridProxy is unavailable"
+// @Failure 503 {object} ExecuteTaskResponse "The executor is busy"
+// @Failure 504 {object} ExecuteTaskResponse "Task did not complete within its timeout"
 // @Router /execute-task [post]
 func ExecuteTask(c *fiber.Ctx) error {
 	var req ExecuteTaskRequest
@@ -136,27 +127,51 @@ func ExecuteTask(c *fiber.Ctx) error {
 			"Failed to parse JSON request body: "+err.Error())
 	}
 
-	// Validate task name against supported tasks
-	supportedTasks := []string{"list_farms", "get_farm"}
-	isSupported := false
-	for _, task := range supportedTasks {
-		if task == req.TaskName {
-			isSupported = true
-			break
-		}
-	}
-
-	if !isSupported {
+	// Validate task name against the executor's supported tasks
+	if !services.IsTaskSupported(req.TaskName) {
 		return NewErrorResponse(c, fiber.StatusBadRequest,
 			"Unsupported task",
-			fmt.Sprintf("Task '%s' is not supported. Available tasks: %v", req.TaskName, supportedTasks))
+			fmt.Sprintf("Task '%s' is not supported. Available tasks: %v", req.TaskName, services.GetSupportedTasks()))
 	}
 
-	// Validate required parameters based on task type
-	if err := validateTaskParameters(req.TaskName, req.Params); err != nil {
+	// Snapshot modes only apply to tasks the local grid snapshot can answer
+	switch req.Mode {
+	case "", services.TaskModeLive:
+	case services.TaskModeStaleOK, services.TaskModeOffline:
+		if !services.SnapshotSupportsTask(req.TaskName) {
+			return NewErrorResponse(c, fiber.StatusBadRequest,
+				"Invalid mode",
+				fmt.Sprintf("Task '%s' cannot be answered from the grid snapshot, so mode %s is not available", req.TaskName, req.Mode))
+		}
+	default:
 		return NewErrorResponse(c, fiber.StatusBadRequest,
-			"Invalid parameters",
-			err.Error())
+			"Invalid mode",
+			fmt.Sprintf("mode must be one of live, stale_ok or offline, got: %q", req.Mode))
+	}
+
+	// Validate and coerce parameters against the executor's schema for this task;
+	// invalid parameters are recorded and reported like any other task failure
+	params, err := services.ValidateTaskParams(req.TaskName, req.Params)
+
+	// Bound execution by the request's timeout, if any; API_TIMEOUT applies on top.
+	// The task is cancelled if the client disconnects, and the request ID is
+	// forwarded to a remote executor for log correlation.
+	ctx, cancel := requestContext(c)
+	defer cancel()
+	if requestID, ok := c.Locals("requestid").(string); ok {
+		ctx = services.WithRequestID(ctx, requestID)
+	}
+	if req.Timeout != "" {
+		timeout, err := time.ParseDuration(req.Timeout)
+		if err != nil || timeout <= 0 {
+			return NewErrorResponse(c, fiber.StatusBadRequest,
+				"Invalid timeout",
+				fmt.Sprintf("timeout must be a positive duration such as \"10s\", got: %q", req.Timeout))
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
 	// Get user ID from context (if authenticated)
@@ -167,6 +182,13 @@ func ExecuteTask(c *fiber.Ctx) error {
 		}
 	}
 
+	// The authenticated user is passed to the executor as the caller for
+	// "my twin" and "my contracts" style tasks
+	var userProfile *services.UserProfile
+	if profile, ok := c.Locals("user").(*services.UserProfile); ok {
+		userProfile = profile
+	}
+
 	// Create task execution record for audit and monitoring
 	taskExecution := &models.TaskExecution{
 		TaskName:   req.TaskName,
@@ -186,9 +208,22 @@ func ExecuteTask(c *fiber.Ctx) error {
 			"Database error: "+err.Error())
 	}
 
-	// Execute the task with performance monitoring
+	// Execute the task with performance monitoring; the snapshot answers
+	// offline requests, and stale_ok ones the executor could not answer
 	startTime := time.Now()
-	result, err := services.ExecuteTask(req.TaskName, req.Params)
+	var result interface{}
+	var syncedAt time.Time
+	source := "live"
+	if err == nil && req.Mode != services.TaskModeOffline {
+		result, err = services.ExecuteTask(ctx, req.TaskName, params, services.CallerFromUser(userProfile))
+	}
+	if (err == nil && req.Mode == services.TaskModeOffline) || (req.Mode == services.TaskModeStaleOK && err != nil && services.CanServeStale(err)) {
+		snapshot, snapshotAt, snapshotErr := services.ExecuteSnapshotTask(db, req.TaskName, params)
+		if snapshotErr == nil || req.Mode == services.TaskModeOffline {
+			result, syncedAt, err = snapshot, snapshotAt, snapshotErr
+			source = "snapshot"
+		}
+	}
 	duration := time.Since(startTime).Milliseconds()
 
 	// Update task execution record with results
@@ -219,71 +254,51 @@ func ExecuteTask(c *fiber.Ctx) error {
 	if err != nil {
 		response.Status = "failed"
 		response.Error = err.Error()
-		return c.Status(fiber.StatusInternalServerError).JSON(response)
+		response.Code = services.ErrorCode(err)
+		var taskErr *services.TaskError
+		var validationErr *services.ValidationError
+		switch {
+		case errors.As(err, &taskErr):
+			response.Retryable = taskErr.Retryable
+			response.Field = taskErr.Field
+			response.Upstream = taskErr.Upstream
+		case errors.As(err, &validationErr):
+			response.Errors = validationErr.Errors
+			if len(validationErr.Errors) > 0 {
+				response.Field = validationErr.Errors[0].Field
+			}
+		}
+		if response.Code == services.ErrorCodeTimeout {
+			response.Retryable = true
+		}
+		return c.Status(taskErrorStatus(response.Code)).JSON(response)
 	}
 
 	response.Status = "success"
 	response.Data = result
+	response.Source = source
+	if source == "snapshot" {
+		response.SyncedAt = &syncedAt
+	}
 	return c.JSON(response)
 }
 
-// validateTaskParameters validates parameters for specific tasks with comprehensive checks.
-// This function ensures that all required parameters are present and have valid types.
-func validateTaskParameters(taskName string, params map[string]interface{}) error {
-	switch taskName {
-	case "get_farm":
-		// Validate required farm_id parameter
-		farmIDParam, exists := params["farm_id"]
-		if !exists {
-			return fmt.Errorf("farm_id parameter is required for get_farm task")
-		}
-
-		// Validate farm_id type and value
-		switch v := farmIDParam.(type) {
-		case float64:
-			if v <= 0 {
-				return fmt.Errorf("farm_id must be a positive integer, got: %v", v)
-			}
-		case int:
-			if v <= 0 {
-				return fmt.Errorf("farm_id must be a positive integer, got: %v", v)
-			}
-		default:
-			return fmt.Errorf("farm_id must be an integer, got: %T", farmIDParam)
-		}
-
-	case "list_farms":
-		// Validate optional parameters for list_farms
-		if pageParam, exists := params["page"]; exists {
-			switch v := pageParam.(type) {
-			case float64:
-				if v <= 0 || v > 1000 {
-					return fmt.Errorf("page must be between 1 and 1000, got: %v", v)
-				}
-			case int:
-				if v <= 0 || v > 1000 {
-					return fmt.Errorf("page must be between 1 and 1000, got: %v", v)
-				}
-			default:
-				return fmt.Errorf("page must be an integer, got: %T", pageParam)
-			}
-		}
-
-		// Validate location parameter if provided
-		if locationParam, exists := params["location"]; exists {
-			if _, ok := locationParam.(string); !ok {
-				return fmt.Errorf("location must be a string, got: %T", locationParam)
-			}
-		}
-
-		// Validate name parameter if provided
-		if nameParam, exists := params["name"]; exists {
-			if _, ok := nameParam.(string); !ok {
-				return fmt.Errorf("name must be a string, got: %T", nameParam)
-			}
-		}
+// taskErrorStatus maps a task error code to the HTTP status of the response
+func taskErrorStatus(code string) int {
+	switch code {
+	case services.ErrorCodeInvalidRequest, services.ErrorCodeInvalidParams, services.ErrorCodeUnknownTask:
+		return fiber.StatusBadRequest
+	case services.ErrorCodeNotFound:
+		return fiber.StatusNotFound
+	case services.ErrorCodeUpstreamUnavailable:
+		return fiber.StatusBadGateway
+	case services.ErrorCodeBusy:
+		return fiber.StatusServiceUnavailable
+	case services.ErrorCodeTimeout:
+		return fiber.StatusGatewayTimeout
+	default:
+		return fiber.StatusInternalServerError
 	}
-	return nil
 }
 
 // mustMarshalJSON marshals data to JSON with safe error handling.
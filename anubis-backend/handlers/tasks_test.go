@@ -5,11 +5,15 @@ import (
 	"anubis-backend/database"
 	"anubis-backend/services"
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
@@ -204,14 +208,12 @@ func TestExecuteTask_ValidationErrors(t *testing.T) {
 			expectedMsg: "is not supported",
 		},
 		{
-			name: "Invalid parameter type",
+			name: "Invalid timeout",
 			request: ExecuteTaskRequest{
 				TaskName: "list_farms",
-				Params: map[string]interface{}{
-					"page": "invalid", // Should be number
-				},
+				Timeout:  "soon",
 			},
-			expectedMsg: "page must be an integer",
+			expectedMsg: "timeout must be a positive duration",
 		},
 	}
 
@@ -236,6 +238,35 @@ func TestExecuteTask_ValidationErrors(t *testing.T) {
 	}
 }
 
+func TestExecuteTask_InvalidParams(t *testing.T) {
+	app := setupTaskTestApp()
+
+	body, err := json.Marshal(ExecuteTaskRequest{
+		TaskName: "list_farms",
+		Params:   map[string]interface{}{"page": "invalid", "farm_id": 0},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/execute-task", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// Reported like invalid parameters the executor rejects
+	var response ExecuteTaskResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, "failed", response.Status)
+	assert.Equal(t, services.ErrorCodeInvalidParams, response.Code)
+	assert.Equal(t, "farm_id", response.Field)
+	assert.False(t, response.Retryable)
+	assert.Len(t, response.Errors, 2)
+	assert.Contains(t, response.Error, "page must be an integer")
+}
+
 func TestExecuteTask_InvalidJSON(t *testing.T) {
 	app := setupTaskTestApp()
 
@@ -303,6 +334,150 @@ func TestExecuteTask_AllTaskTypes(t *testing.T) {
 	}
 }
 
+func TestExecuteTask_NotFound(t *testing.T) {
+	app := setupTaskTestApp()
+
+	body, err := json.Marshal(ExecuteTaskRequest{
+		TaskName: "get_farm",
+		Params:   map[string]interface{}{"farm_id": 9},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/execute-task", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var response ExecuteTaskResponse
+	err = json.NewDecoder(resp.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, "failed", response.Status)
+	assert.Equal(t, services.ErrorCodeNotFound, response.Code)
+	assert.False(t, response.Retryable)
+	assert.Contains(t, response.Error, "farm with ID 9 not found")
+}
+
+func TestExecuteTask_SnapshotModes(t *testing.T) {
+	app := setupTaskTestApp()
+	sqlDB, err := database.GetDB().DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every SQLite connection would open its own database
+
+	execute := func(request ExecuteTaskRequest) (int, ExecuteTaskResponse) {
+		body, err := json.Marshal(request)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/execute-task", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		var response ExecuteTaskResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		return resp.StatusCode, response
+	}
+
+	// Nothing has been synced yet
+	status, response := execute(ExecuteTaskRequest{TaskName: "list_farms", Mode: services.TaskModeOffline})
+	assert.Equal(t, http.StatusBadGateway, status)
+	assert.Equal(t, services.ErrorCodeUpstreamUnavailable, response.Code)
+
+	// The in-process executor only lists farms, so the sync is partial
+	run, err := services.NewGridSyncer(database.GetDB(), time.Minute).SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, services.SyncStatusPartial, run.Status)
+
+	status, response = execute(ExecuteTaskRequest{TaskName: "list_farms", Mode: services.TaskModeOffline})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "snapshot", response.Source)
+	assert.NotNil(t, response.SyncedAt)
+	items := response.Data.(map[string]interface{})["items"].([]interface{})
+	require.Len(t, items, 2)
+	assert.Contains(t, items[0], "farmId") // The live task's field names
+
+	status, response = execute(ExecuteTaskRequest{TaskName: "get_farm", Params: map[string]interface{}{"farm_id": 1}, Mode: services.TaskModeStaleOK})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "live", response.Source)
+	assert.Nil(t, response.SyncedAt)
+
+	status, _ = execute(ExecuteTaskRequest{TaskName: "list_farms", Mode: "cached"})
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestExecuteTask_ClientDisconnectCancelsTask(t *testing.T) {
+	app := setupTaskTestApp()
+
+	// A remote executor that holds every execution until it is cancelled
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	executor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/execute" {
+			http.NotFound(w, r) // The builtin catalog is used instead
+			return
+		}
+		// The server only notices the disconnect once the body has been read
+		_, _ = io.Copy(io.Discard, r.Body)
+		close(started)
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer executor.Close()
+
+	require.NoError(t, services.InitTaskService(&config.Config{Executor: config.ExecutorConfig{URL: executor.URL}}))
+	defer func() {
+		require.NoError(t, services.InitTaskService(&config.Config{}))
+	}()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(listener) }()
+	defer func() { _ = app.Shutdown() }()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+
+	body := `{"task_name":"list_farms"}`
+	_, err = fmt.Fprintf(conn, "POST /execute-task HTTP/1.1\r\nHost: localhost\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+	require.NoError(t, err)
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task never reached the executor")
+	}
+	require.NoError(t, conn.Close())
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not cancelled after the client disconnected")
+	}
+}
+
+func TestTaskErrorStatus(t *testing.T) {
+	tests := map[string]int{
+		services.ErrorCodeInvalidRequest:      http.StatusBadRequest,
+		services.ErrorCodeInvalidParams:       http.StatusBadRequest,
+		services.ErrorCodeUnknownTask:         http.StatusBadRequest,
+		services.ErrorCodeNotFound:            http.StatusNotFound,
+		services.ErrorCodeUpstreamUnavailable: http.StatusBadGateway,
+		services.ErrorCodeTimeout:             http.StatusGatewayTimeout,
+		services.ErrorCodeBusy:                http.StatusServiceUnavailable,
+		services.ErrorCodeCancelled:           http.StatusInternalServerError,
+		services.ErrorCodeInternal:            http.StatusInternalServerError,
+	}
+
+	for code, expected := range tests {
+		assert.Equal(t, expected, taskErrorStatus(code), code)
+	}
+}
+
 func TestExecuteTask_PerformanceMetrics(t *testing.T) {
 	app := setupTaskTestApp()
 
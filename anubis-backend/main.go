@@ -20,6 +20,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -85,11 +86,18 @@ func main() {
 		log.Fatalf("Failed to initialize task service: %v", err)
 	}
 
+	// Keep a local grid snapshot for stale_ok/offline tasks and node history
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	if cfg.Sync.Interval > 0 && !fiber.IsChild() {
+		go services.NewGridSyncer(database.GetDB(), cfg.Sync.Interval).Run(syncCtx)
+		log.Printf("Grid snapshot sync running every %s", cfg.Sync.Interval)
+	}
+
 	// Setup routes with middleware stack
 	routes.SetupRoutes(app, cfg)
 
 	// Setup graceful shutdown handling
-	setupGracefulShutdown(app)
+	setupGracefulShutdown(app, stopSync)
 
 	// Start server with comprehensive logging
 	log.Printf("🚀 Starting Anubis AI Core-Backend API server on port %s", cfg.Port)
@@ -106,7 +114,7 @@ func main() {
 
 // setupGracefulShutdown configures graceful shutdown handling for the Fiber application.
 // This ensures proper cleanup of resources and connections when the server is terminated.
-func setupGracefulShutdown(app *fiber.App) {
+func setupGracefulShutdown(app *fiber.App, stopSync context.CancelFunc) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
@@ -114,6 +122,9 @@ func setupGracefulShutdown(app *fiber.App) {
 		<-c
 		log.Println("🛑 Received shutdown signal, gracefully shutting down...")
 
+		// Stop the grid snapshot sync before its database goes away
+		stopSync()
+
 		// Shutdown the Fiber server gracefully
 		if err := app.Shutdown(); err != nil {
 			log.Printf("❌ Error during server shutdown: %v", err)
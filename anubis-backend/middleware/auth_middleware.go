@@ -77,6 +77,19 @@ func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 	}
 }
 
+// OptionalAuthMiddleware authenticates the request when a Bearer token is present
+// and lets anonymous requests through. Invalid tokens are still rejected so
+// callers notice expired sessions instead of silently losing their identity.
+func OptionalAuthMiddleware(authService *services.AuthService) fiber.Handler {
+	required := AuthMiddleware(authService)
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+		return required(c)
+	}
+}
+
 // AdminMiddleware ensures only admin users can access protected routes
 func AdminMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GridFarm is the last synced state of a ThreeFold farm
+type GridFarm struct {
+	FarmID            int        `json:"farm_id" gorm:"primaryKey;autoIncrement:false"`
+	Name              string     `json:"name" gorm:"index"`
+	TwinID            int        `json:"twin_id"`
+	PricingPolicyID   int        `json:"pricing_policy_id"`
+	CertificationType string     `json:"certification_type"`
+	Dedicated         bool       `json:"dedicated"`
+	PublicIPs         int        `json:"public_ips"`
+	FreeIPs           int        `json:"free_ips"`
+	FirstSeenAt       time.Time  `json:"first_seen_at"`
+	SyncedAt          time.Time  `json:"synced_at"`                         // Last sync that saw the farm
+	MissedSyncs       int        `json:"missed_syncs"`                      // Complete syncs in a row that did not see the farm
+	RemovedAt         *time.Time `json:"removed_at,omitempty" gorm:"index"` // Complete sync that missed the farm for the second time in a row
+}
+
+// GridNode is the last synced state of a ThreeFold node. Capacity is in bytes,
+// as GridProxy reports it, except for cores.
+type GridNode struct {
+	NodeID            int        `json:"node_id" gorm:"primaryKey;autoIncrement:false"`
+	FarmID            int        `json:"farm_id" gorm:"index"`
+	TwinID            int        `json:"twin_id"`
+	Country           string     `json:"country" gorm:"index"`
+	City              string     `json:"city"`
+	Status            string     `json:"status" gorm:"index"`
+	StatusSince       time.Time  `json:"status_since"` // When the status was first seen, at most the first sync
+	CertificationType string     `json:"certification_type"`
+	Dedicated         bool       `json:"dedicated"`
+	Rented            bool       `json:"rented"`
+	RentedByTwinID    int        `json:"rented_by_twin_id,omitempty"`
+	NumGPU            int        `json:"num_gpu"`
+	Uptime            int64      `json:"uptime"` // Seconds
+	TotalCRU          uint64     `json:"total_cru"`
+	TotalMRU          uint64     `json:"total_mru"`
+	TotalSRU          uint64     `json:"total_sru"`
+	TotalHRU          uint64     `json:"total_hru"`
+	UsedCRU           uint64     `json:"used_cru"`
+	UsedMRU           uint64     `json:"used_mru"`
+	UsedSRU           uint64     `json:"used_sru"`
+	UsedHRU           uint64     `json:"used_hru"`
+	FirstSeenAt       time.Time  `json:"first_seen_at"`
+	SyncedAt          time.Time  `json:"synced_at"`                         // Last sync that saw the node
+	MissedSyncs       int        `json:"missed_syncs"`                      // Complete syncs in a row that did not see the node
+	RemovedAt         *time.Time `json:"removed_at,omitempty" gorm:"index"` // Complete sync that missed the node for the second time in a row
+}
+
+// GridChange records a change the sync noticed between two snapshots,
+// e.g. a node going offline or its capacity changing
+type GridChange struct {
+	ID         uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	SyncRunID  uuid.UUID `json:"sync_run_id" gorm:"type:char(36);index"`
+	EntityType string    `json:"entity_type" gorm:"not null;index:idx_grid_change_entity"` // node or farm
+	EntityID   int       `json:"entity_id" gorm:"not null;index:idx_grid_change_entity"`
+	Kind       string    `json:"kind" gorm:"not null"` // added, removed, status, capacity, certification, rented, public_ips or name
+	OldValue   string    `json:"old_value,omitempty"`
+	NewValue   string    `json:"new_value"`
+	DetectedAt time.Time `json:"detected_at" gorm:"index"`
+}
+
+// BeforeCreate hook to set UUID
+func (gc *GridChange) BeforeCreate(tx *gorm.DB) error {
+	if gc.ID == uuid.Nil {
+		gc.ID = uuid.New()
+	}
+	return nil
+}
+
+// GridStatsSnapshot is the grid_stats result of one sync
+type GridStatsSnapshot struct {
+	ID        uuid.UUID `json:"id" gorm:"type:char(36);primary_key"`
+	SyncRunID uuid.UUID `json:"sync_run_id" gorm:"type:char(36);index"`
+	Data      string    `json:"data" gorm:"type:text"` // JSON as string
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// BeforeCreate hook to set UUID
+func (gs *GridStatsSnapshot) BeforeCreate(tx *gorm.DB) error {
+	if gs.ID == uuid.Nil {
+		gs.ID = uuid.New()
+	}
+	return nil
+}
+
+// GridSyncRun records one run of the grid snapshot sync
+type GridSyncRun struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:char(36);primary_key"`
+	Status     string     `json:"status" gorm:"not null;index" validate:"required,oneof=running success partial failed"`
+	Farms      int        `json:"farms"`   // Farms synced
+	Nodes      int        `json:"nodes"`   // Nodes synced
+	Changes    int        `json:"changes"` // Changes recorded
+	ErrorMsg   string     `json:"error_message,omitempty" gorm:"type:text"`
+	StartedAt  time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// BeforeCreate hook to set UUID
+func (gr *GridSyncRun) BeforeCreate(tx *gorm.DB) error {
+	if gr.ID == uuid.Nil {
+		gr.ID = uuid.New()
+	}
+	return nil
+}
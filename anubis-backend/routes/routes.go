@@ -39,8 +39,11 @@ func SetupRoutes(app *fiber.App, cfg *config.Config) {
 	// Authentication routes - for user login/registration
 	setupAuthRoutes(app, authService)
 
-	// Task execution routes - public for now, can be protected later
-	setupTaskRoutes(app)
+	// Task execution routes - public, with the caller's identity when a token is sent
+	setupTaskRoutes(app, authService)
+
+	// Grid snapshot routes - public, like the tasks they back
+	setupGridRoutes(app)
 
 	// Protected routes - require valid JWT authentication
 	setupProtectedRoutes(app, authService)
@@ -73,10 +76,18 @@ func setupAuthRoutes(app *fiber.App, authService *services.AuthService) {
 }
 
 // setupTaskRoutes configures ThreeFold Grid task execution endpoints.
-func setupTaskRoutes(app *fiber.App) {
+func setupTaskRoutes(app *fiber.App, authService *services.AuthService) {
 	// Task discovery and execution
 	app.Get("/available-tasks", handlers.AvailableTasks)
-	app.Post("/execute-task", handlers.ExecuteTask)
+	app.Post("/execute-task", middleware.OptionalAuthMiddleware(authService), handlers.ExecuteTask)
+}
+
+// setupGridRoutes configures endpoints that read the local grid snapshot.
+func setupGridRoutes(app *fiber.App) {
+	grid := app.Group("/grid")
+
+	grid.Get("/sync", handlers.GridSyncStatus)           // Latest sync runs
+	grid.Get("/nodes/:id/history", handlers.NodeHistory) // Node status and change history
 }
 
 // setupProtectedRoutes configures endpoints that require JWT authentication.
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"anubis-backend/models"
+
+	"anubis-executer/schema"
+	"anubis-executer/shape"
+
+	"gorm.io/gorm"
+)
+
+// Modes of a task execution request
+const (
+	TaskModeLive    = "live"     // Ask the executor only
+	TaskModeStaleOK = "stale_ok" // Fall back to the snapshot when the executor cannot answer
+	TaskModeOffline = "offline"  // Answer from the snapshot only
+)
+
+// defaultHistoryLimit is the number of changes GetNodeHistory returns by default
+const defaultHistoryLimit = 50
+
+// snapshotParams are the tasks the grid snapshot can answer and the
+// parameters each supports. List tasks also take the result shaping
+// parameters of the executor.
+var snapshotParams = map[string][]string{
+	"list_farms":  {"page", "page_size", "farm_id", "name", "location", "fields", "sort_by", "order", "limit", "format"},
+	"get_farm":    {"farm_id"},
+	"list_nodes":  {"page", "page_size", "status", "country", "city", "farm_ids", "dedicated", "rented", "has_gpu", "fields", "sort_by", "order", "limit", "format"},
+	"get_node":    {"node_id"},
+	"node_status": {"node_id"},
+	"grid_stats":  {},
+}
+
+// SnapshotListResult mirrors the executor's list envelope for snapshot rows,
+// which are served in the shape of the live task's items
+type SnapshotListResult struct {
+	Items      interface{} `json:"items"`
+	TotalCount int64       `json:"total_count"`
+	Page       int64       `json:"page"`
+	PageSize   int64       `json:"page_size"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// SnapshotNodeStatus is the snapshot answer to node_status
+type SnapshotNodeStatus struct {
+	NodeID      int       `json:"node_id"`
+	Status      string    `json:"status"`
+	Online      bool      `json:"online"`
+	StatusSince time.Time `json:"status_since"`
+}
+
+// NodeHistory is a node's last synced state and the changes recorded for it
+type NodeHistory struct {
+	Node           models.GridNode     `json:"node"`
+	Status         string              `json:"status"`
+	StatusSince    time.Time           `json:"status_since"`
+	StatusDuration string              `json:"status_duration"` // How long the node has had its status, at least
+	StatusSeconds  int64               `json:"status_seconds"`
+	Changes        []models.GridChange `json:"changes"` // Latest first
+	SnapshotAt     time.Time           `json:"snapshot_at"`
+}
+
+// SnapshotSupportsTask reports whether the grid snapshot can answer taskName
+func SnapshotSupportsTask(taskName string) bool {
+	_, ok := snapshotParams[taskName]
+	return ok
+}
+
+// CanServeStale reports whether a failed live task may be answered from the
+// snapshot in stale_ok mode: when GridProxy or the executor is unavailable,
+// timed out or busy. Other failures, such as a wrong request or a bug, are
+// returned as they are.
+func CanServeStale(err error) bool {
+	switch ErrorCode(err) {
+	case ErrorCodeUpstreamUnavailable, ErrorCodeTimeout, ErrorCodeBusy:
+		return true
+	default:
+		return false
+	}
+}
+
+// LatestSnapshotTime returns when the last sync that saved grid data finished
+func LatestSnapshotTime(db *gorm.DB) (time.Time, error) {
+	var run models.GridSyncRun
+	err := db.Where("status IN ?", []string{SyncStatusSuccess, SyncStatusPartial}).
+		Order("started_at DESC").
+		First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, newTaskError(ErrorCodeUpstreamUnavailable, "no grid snapshot has been synced yet")
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load grid sync runs: %w", err)
+	}
+	return *run.FinishedAt, nil
+}
+
+// ExecuteSnapshotTask answers a task from the local grid snapshot instead of
+// the executor. It returns the result and when the snapshot was taken.
+// Parameters the snapshot cannot apply are rejected unless left at their
+// defaults. Farms and nodes have the JSON fields of the live task's result,
+// so fields and sort_by name the same fields in both modes.
+func ExecuteSnapshotTask(db *gorm.DB, taskName string, params map[string]interface{}) (interface{}, time.Time, error) {
+	supported, ok := snapshotParams[taskName]
+	if !ok {
+		return nil, time.Time{}, newTaskError(ErrorCodeInvalidRequest, "task %s cannot be answered from the grid snapshot", taskName)
+	}
+	if err := checkSnapshotParams(taskName, supported, params); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	snapshotAt, err := LatestSnapshotTime(db)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var result interface{}
+	switch taskName {
+	case "list_farms":
+		result, err = snapshotFarms(db, params)
+	case "get_farm":
+		result, err = snapshotFarm(db, params)
+	case "list_nodes":
+		result, err = snapshotNodes(db, params)
+	case "get_node":
+		var node models.GridNode
+		if node, err = snapshotNode(db, params); err == nil {
+			result = newNodeItem(node)
+		}
+	case "node_status":
+		var node models.GridNode
+		if node, err = snapshotNode(db, params); err == nil {
+			result = SnapshotNodeStatus{NodeID: node.NodeID, Status: node.Status, Online: node.Status == "up", StatusSince: node.StatusSince}
+		}
+	case "grid_stats":
+		result, err = snapshotStats(db)
+	}
+	if list, ok := result.(SnapshotListResult); ok && err == nil {
+		result, err = shape.Apply(list, shape.OptionsFromParams(params))
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return result, snapshotAt, nil
+}
+
+// GetNodeHistory returns a node's synced state, how long it has had its status,
+// and its latest changes
+func GetNodeHistory(db *gorm.DB, nodeID int, limit int) (*NodeHistory, error) {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	snapshotAt, err := LatestSnapshotTime(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var node models.GridNode
+	if err := db.First(&node, "node_id = ?", nodeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newTaskError(ErrorCodeNotFound, "node with ID %d not found in the grid snapshot", nodeID)
+		}
+		return nil, fmt.Errorf("failed to load node: %w", err)
+	}
+
+	history := &NodeHistory{
+		Node:        node,
+		Status:      node.Status,
+		StatusSince: node.StatusSince,
+		SnapshotAt:  snapshotAt,
+	}
+	duration := node.SyncedAt.Sub(node.StatusSince).Round(time.Second)
+	history.StatusDuration = duration.String()
+	history.StatusSeconds = int64(duration.Seconds())
+
+	if err := db.Where("entity_type = ? AND entity_id = ?", "node", nodeID).
+		Order("detected_at DESC").
+		Limit(limit).
+		Find(&history.Changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to load node changes: %w", err)
+	}
+	return history, nil
+}
+
+// GetSyncRuns returns the latest grid sync runs, newest first
+func GetSyncRuns(db *gorm.DB, limit int) ([]models.GridSyncRun, error) {
+	var runs []models.GridSyncRun
+	if err := db.Order("started_at DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to load grid sync runs: %w", err)
+	}
+	return runs, nil
+}
+
+// checkSnapshotParams rejects parameters the snapshot cannot apply. Values
+// equal to the task's defaults are accepted, since validation fills them in.
+func checkSnapshotParams(taskName string, supported []string, params map[string]interface{}) error {
+	var defaults map[string]*ParamSpec
+	if def, ok := GetTaskDefinition(taskName); ok && def.Parameters != nil {
+		defaults = def.Parameters.Properties
+	}
+
+	var unsupported []string
+	for name, value := range params {
+		if slices.Contains(supported, name) {
+			continue
+		}
+		if spec, ok := defaults[name]; ok && spec.Default != nil && fmt.Sprint(spec.Default) == fmt.Sprint(value) {
+			continue
+		}
+		unsupported = append(unsupported, name)
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return &TaskError{
+			Code:    ErrorCodeInvalidParams,
+			Field:   unsupported[0],
+			Message: fmt.Sprintf("%s cannot be applied to the grid snapshot; supported parameters: %s", strings.Join(unsupported, ", "), strings.Join(supported, ", ")),
+		}
+	}
+	return nil
+}
+
+// snapshotPage reads page and page_size, defaulting to the executor's first page of 5
+func snapshotPage(params map[string]interface{}) (page, pageSize int64) {
+	page, pageSize = 1, 5
+	if value, ok := schema.ToInt64(params["page"]); ok && value > 0 {
+		page = value
+	}
+	if value, ok := schema.ToInt64(params["page_size"]); ok && value > 0 {
+		pageSize = value
+	}
+	return page, pageSize
+}
+
+// snapshotList counts the rows matched by query and returns the requested
+// page, each row converted to an item by newItem
+func snapshotList[Row, Item any](query *gorm.DB, params map[string]interface{}, order string, newItem func(Row) Item) (SnapshotListResult, error) {
+	page, pageSize := snapshotPage(params)
+	result := SnapshotListResult{Page: page, PageSize: pageSize}
+
+	if err := query.Count(&result.TotalCount).Error; err != nil {
+		return result, fmt.Errorf("failed to count snapshot rows: %w", err)
+	}
+	var rows []Row
+	if err := query.Order(order).Offset(int((page - 1) * pageSize)).Limit(int(pageSize)).Find(&rows).Error; err != nil {
+		return result, fmt.Errorf("failed to load snapshot rows: %w", err)
+	}
+
+	items := make([]Item, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, newItem(row))
+	}
+	result.Items = items
+	result.HasMore = page*pageSize < result.TotalCount
+	return result, nil
+}
+
+// snapshotFarms answers list_farms. As on GridProxy, name matches part of the
+// farm name and location matches farms with a node in that country.
+func snapshotFarms(db *gorm.DB, params map[string]interface{}) (interface{}, error) {
+	query := db.Model(&models.GridFarm{}).Where("removed_at IS NULL")
+	if farmID, ok := schema.ToInt64(params["farm_id"]); ok {
+		query = query.Where("farm_id = ?", farmID)
+	}
+	if name, ok := params["name"].(string); ok && name != "" {
+		query = query.Where(`LOWER(name) LIKE ? ESCAPE '\'`, "%"+likeEscaper.Replace(strings.ToLower(name))+"%")
+	}
+	if location, ok := params["location"].(string); ok && location != "" {
+		nodes := db.Model(&models.GridNode{}).Select("farm_id").Where("LOWER(country) = ? AND removed_at IS NULL", strings.ToLower(location))
+		query = query.Where("farm_id IN (?)", nodes)
+	}
+
+	return snapshotList(query, params, "farm_id", newFarmItem)
+}
+
+// snapshotFarm answers get_farm
+func snapshotFarm(db *gorm.DB, params map[string]interface{}) (interface{}, error) {
+	farmID, ok := schema.ToInt64(params["farm_id"])
+	if !ok {
+		return nil, &TaskError{Code: ErrorCodeInvalidParams, Field: "farm_id", Message: "farm_id parameter is required"}
+	}
+
+	var farm models.GridFarm
+	if err := db.First(&farm, "farm_id = ? AND removed_at IS NULL", farmID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newTaskError(ErrorCodeNotFound, "farm with ID %d not found in the grid snapshot", farmID)
+		}
+		return nil, fmt.Errorf("failed to load farm: %w", err)
+	}
+	return newFarmItem(farm), nil
+}
+
+// snapshotNodes answers list_nodes
+func snapshotNodes(db *gorm.DB, params map[string]interface{}) (interface{}, error) {
+	query := db.Model(&models.GridNode{}).Where("removed_at IS NULL")
+	if statuses := stringValues(params, "status"); len(statuses) > 0 {
+		query = query.Where("status IN ?", statuses)
+	}
+	if country, ok := params["country"].(string); ok && country != "" {
+		query = query.Where("LOWER(country) = ?", strings.ToLower(country))
+	}
+	if city, ok := params["city"].(string); ok && city != "" {
+		query = query.Where("LOWER(city) = ?", strings.ToLower(city))
+	}
+	if farmIDs, ok := params["farm_ids"]; ok && farmIDs != nil {
+		query = query.Where("farm_id IN ?", schema.ToSlice(farmIDs))
+	}
+	if dedicated, ok := params["dedicated"].(bool); ok {
+		query = query.Where("dedicated = ?", dedicated)
+	}
+	if rented, ok := params["rented"].(bool); ok {
+		query = query.Where("rented = ?", rented)
+	}
+	if hasGPU, ok := params["has_gpu"].(bool); ok {
+		if hasGPU {
+			query = query.Where("num_gpu > 0")
+		} else {
+			query = query.Where("num_gpu = 0")
+		}
+	}
+
+	return snapshotList(query, params, "node_id", newNodeItem)
+}
+
+// snapshotNode answers get_node; nodes removed from GridProxy are not found
+func snapshotNode(db *gorm.DB, params map[string]interface{}) (models.GridNode, error) {
+	var node models.GridNode
+	nodeID, ok := schema.ToInt64(params["node_id"])
+	if !ok {
+		return node, &TaskError{Code: ErrorCodeInvalidParams, Field: "node_id", Message: "node_id parameter is required"}
+	}
+
+	if err := db.First(&node, "node_id = ? AND removed_at IS NULL", nodeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return node, newTaskError(ErrorCodeNotFound, "node with ID %d not found in the grid snapshot", nodeID)
+		}
+		return node, fmt.Errorf("failed to load node: %w", err)
+	}
+	return node, nil
+}
+
+// snapshotStats answers grid_stats with the latest stored stats
+func snapshotStats(db *gorm.DB) (interface{}, error) {
+	var snapshot models.GridStatsSnapshot
+	if err := db.Order("created_at DESC").First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, newTaskError(ErrorCodeUpstreamUnavailable, "no grid stats have been synced yet")
+		}
+		return nil, fmt.Errorf("failed to load grid stats: %w", err)
+	}
+
+	var stats map[string]interface{}
+	if err := json.Unmarshal([]byte(snapshot.Data), &stats); err != nil {
+		return nil, fmt.Errorf("failed to decode grid stats: %w", err)
+	}
+	return stats, nil
+}
+
+// likeEscaper escapes the LIKE wildcards in a search string
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// newFarmItem returns a synced farm in the shape of a list_farms item
+func newFarmItem(farm models.GridFarm) farmItem {
+	return farmItem{
+		FarmID:            farm.FarmID,
+		Name:              farm.Name,
+		TwinID:            farm.TwinID,
+		PricingPolicyID:   farm.PricingPolicyID,
+		CertificationType: farm.CertificationType,
+		Dedicated:         farm.Dedicated,
+	}
+}
+
+// newNodeItem returns a synced node in the shape of a list_nodes item
+func newNodeItem(node models.GridNode) nodeItem {
+	return nodeItem{
+		NodeID:            node.NodeID,
+		FarmID:            node.FarmID,
+		TwinID:            node.TwinID,
+		Country:           node.Country,
+		City:              node.City,
+		Status:            node.Status,
+		CertificationType: node.CertificationType,
+		Dedicated:         node.Dedicated,
+		Rented:            node.Rented,
+		RentedByTwinID:    node.RentedByTwinID,
+		NumGPU:            node.NumGPU,
+		Uptime:            node.Uptime,
+		TotalResources:    itemCapacity{CRU: node.TotalCRU, MRU: node.TotalMRU, SRU: node.TotalSRU, HRU: node.TotalHRU},
+		UsedResources:     itemCapacity{CRU: node.UsedCRU, MRU: node.UsedMRU, SRU: node.UsedSRU, HRU: node.UsedHRU},
+	}
+}
+
+// stringValues returns the strings of the named array parameter
+func stringValues(params map[string]interface{}, name string) []string {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return nil
+	}
+
+	var values []string
+	for _, item := range schema.ToSlice(value) {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
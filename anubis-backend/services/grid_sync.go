@@ -0,0 +1,494 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"anubis-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// syncPageSize is the page size the grid sync requests from list tasks
+const syncPageSize = 100
+
+// maxSyncPages caps how many pages of farms or nodes one sync walks
+const maxSyncPages = 1000
+
+// removalSyncs is how many complete syncs in a row must miss a farm or node
+// before it is marked removed. Paging by offset can skip an item when others
+// are added or removed during the walk, so a single miss is not enough.
+const removalSyncs = 2
+
+// Statuses of a grid sync run
+const (
+	SyncStatusRunning = "running"
+	SyncStatusSuccess = "success"
+	SyncStatusPartial = "partial" // Some tasks failed; what was fetched is saved
+	SyncStatusFailed  = "failed"
+)
+
+// GridSyncer copies farms, nodes and grid stats from the task executor into
+// local tables and records what changed between runs, so tasks can be
+// answered from the snapshot while GridProxy is unavailable
+type GridSyncer struct {
+	db       *gorm.DB
+	interval time.Duration
+	now      func() time.Time
+
+	mu sync.Mutex // Serializes runs
+}
+
+// itemCapacity is a GridProxy capacity, in bytes except for cores
+type itemCapacity struct {
+	CRU uint64 `json:"cru"`
+	MRU uint64 `json:"mru"`
+	SRU uint64 `json:"sru"`
+	HRU uint64 `json:"hru"`
+}
+
+// farmItem is a farm item of list_farms. The sync reads farms in this shape
+// and the snapshot serves them in it, without their public IPs.
+type farmItem struct {
+	FarmID            int    `json:"farmId"`
+	Name              string `json:"name"`
+	TwinID            int    `json:"twinId"`
+	PricingPolicyID   int    `json:"pricingPolicyId"`
+	CertificationType string `json:"certificationType"`
+	Dedicated         bool   `json:"dedicated"`
+	PublicIPs         []struct {
+		ContractID int64 `json:"contract_id"`
+	} `json:"publicIps,omitempty"`
+}
+
+// nodeItem is a node item of list_nodes. The sync reads nodes in this shape
+// and the snapshot serves them in it.
+type nodeItem struct {
+	NodeID            int          `json:"nodeId"`
+	FarmID            int          `json:"farmId"`
+	TwinID            int          `json:"twinId"`
+	Country           string       `json:"country"`
+	City              string       `json:"city"`
+	Status            string       `json:"status"`
+	CertificationType string       `json:"certificationType"`
+	Dedicated         bool         `json:"dedicated"`
+	Rented            bool         `json:"rented"`
+	RentedByTwinID    int          `json:"rentedByTwinId"`
+	NumGPU            int          `json:"num_gpu"`
+	Uptime            int64        `json:"uptime"`
+	TotalResources    itemCapacity `json:"total_resources"`
+	UsedResources     itemCapacity `json:"used_resources"`
+}
+
+// NewGridSyncer creates a syncer that runs every interval once started
+func NewGridSyncer(db *gorm.DB, interval time.Duration) *GridSyncer {
+	return &GridSyncer{
+		db:       db,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Run syncs right away and then every interval until ctx is done
+func (s *GridSyncer) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		run, err := s.SyncOnce(ctx)
+		switch {
+		case err != nil:
+			log.Printf("Grid sync failed: %v", err)
+		case run.Status != SyncStatusSuccess:
+			log.Printf("Grid sync %s: %d farms, %d nodes, %d changes: %s", run.Status, run.Farms, run.Nodes, run.Changes, run.ErrorMsg)
+		default:
+			log.Printf("Grid sync completed: %d farms, %d nodes, %d changes", run.Farms, run.Nodes, run.Changes)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce pulls farms, nodes and stats through the executor and saves them.
+// Failed tasks are recorded in the returned run, which is then partial or
+// failed; an error is only returned when the run itself cannot be saved.
+func (s *GridSyncer) SyncOnce(ctx context.Context) (*models.GridSyncRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run := &models.GridSyncRun{Status: SyncStatusRunning, StartedAt: s.now()}
+	if err := s.db.Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to create sync run: %w", err)
+	}
+
+	var failures []string
+	steps := []func() error{
+		func() error {
+			farms, total, fetchErr := fetchAllItems[farmItem](ctx, "list_farms")
+			run.Farms = len(farms)
+			changes, err := s.saveFarms(run, farms, total)
+			run.Changes += changes
+			if err != nil {
+				return err
+			}
+			return fetchErr
+		},
+		func() error {
+			nodes, total, fetchErr := fetchAllItems[nodeItem](ctx, "list_nodes")
+			run.Nodes = len(nodes)
+			changes, err := s.saveNodes(run, nodes, total)
+			run.Changes += changes
+			if err != nil {
+				return err
+			}
+			return fetchErr
+		},
+		func() error {
+			return s.saveStats(ctx, run)
+		},
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+
+	switch len(failures) {
+	case 0:
+		run.Status = SyncStatusSuccess
+	case len(steps):
+		run.Status = SyncStatusFailed
+	default:
+		run.Status = SyncStatusPartial
+	}
+	run.ErrorMsg = strings.Join(failures, "; ")
+	finished := s.now()
+	run.FinishedAt = &finished
+
+	if err := s.db.Save(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to save sync run: %w", err)
+	}
+	return run, nil
+}
+
+// fetchAllItems walks every page of a list task and decodes its items. It
+// also returns the total_count the pages reported, or -1 when the walk failed
+// or the pages disagreed on it.
+func fetchAllItems[T any](ctx context.Context, taskName string) ([]T, int, error) {
+	if !IsTaskSupported(taskName) {
+		return nil, -1, fmt.Errorf("%s is not supported by the executor", taskName)
+	}
+
+	var items []T
+	total := 0
+	for page := 1; page <= maxSyncPages; page++ {
+		result, err := ExecuteTask(ctx, taskName, map[string]interface{}{"page": page, "page_size": syncPageSize}, nil)
+		if err != nil {
+			return items, -1, fmt.Errorf("%s page %d failed: %w", taskName, page, err)
+		}
+
+		var list struct {
+			Items      []T  `json:"items"`
+			TotalCount int  `json:"total_count"`
+			HasMore    bool `json:"has_more"`
+		}
+		if err := decodeTaskResult(result, &list); err != nil {
+			return items, -1, fmt.Errorf("%s page %d: %w", taskName, page, err)
+		}
+		items = append(items, list.Items...)
+
+		// A count that changed during the walk means items may have shifted pages
+		if page == 1 {
+			total = list.TotalCount
+		} else if list.TotalCount != total {
+			total = -1
+		}
+
+		if !list.HasMore || len(list.Items) == 0 {
+			return items, total, nil
+		}
+	}
+	return items, -1, fmt.Errorf("%s stopped after %d pages", taskName, maxSyncPages)
+}
+
+// decodeTaskResult converts a task result, typed or decoded JSON, into out
+func decodeTaskResult(result interface{}, out interface{}) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode task result: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode task result: %w", err)
+	}
+	return nil
+}
+
+// saveFarms upserts the farms and records how they changed since the last run.
+// When farms add up to total, known farms missing from it count a missed sync.
+func (s *GridSyncer) saveFarms(run *models.GridSyncRun, farms []farmItem, total int) (int, error) {
+	if len(farms) == 0 {
+		return 0, nil
+	}
+
+	var rows []models.GridFarm
+	if err := s.db.Find(&rows).Error; err != nil {
+		return 0, fmt.Errorf("failed to load farms: %w", err)
+	}
+	existing := make(map[int]models.GridFarm, len(rows))
+	for _, row := range rows {
+		existing[row.FarmID] = row
+	}
+
+	now := s.now()
+	var changes []models.GridChange
+	change := func(farmID int, kind, oldValue, newValue string) {
+		changes = append(changes, models.GridChange{
+			SyncRunID: run.ID, EntityType: "farm", EntityID: farmID,
+			Kind: kind, OldValue: oldValue, NewValue: newValue, DetectedAt: now,
+		})
+	}
+
+	fetched := make(map[int]bool, len(farms))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range farms {
+			// A farm that moved to a later page during the walk is seen twice
+			if fetched[item.FarmID] {
+				continue
+			}
+			fetched[item.FarmID] = true
+
+			farm := models.GridFarm{
+				FarmID:            item.FarmID,
+				Name:              item.Name,
+				TwinID:            item.TwinID,
+				PricingPolicyID:   item.PricingPolicyID,
+				CertificationType: item.CertificationType,
+				Dedicated:         item.Dedicated,
+				PublicIPs:         len(item.PublicIPs),
+				FirstSeenAt:       now,
+				SyncedAt:          now,
+			}
+			for _, ip := range item.PublicIPs {
+				if ip.ContractID == 0 {
+					farm.FreeIPs++
+				}
+			}
+
+			// The first sync only fills the tables; later ones record changes.
+			// A farm that was removed and is back counts as added again.
+			if old, seen := existing[farm.FarmID]; seen && old.RemovedAt != nil {
+				farm.FirstSeenAt = old.FirstSeenAt
+				change(farm.FarmID, "added", "", farm.Name)
+			} else if seen {
+				farm.FirstSeenAt = old.FirstSeenAt
+				if old.Name != farm.Name {
+					change(farm.FarmID, "name", old.Name, farm.Name)
+				}
+				if old.CertificationType != farm.CertificationType {
+					change(farm.FarmID, "certification", old.CertificationType, farm.CertificationType)
+				}
+				if old.PublicIPs != farm.PublicIPs {
+					change(farm.FarmID, "public_ips", strconv.Itoa(old.PublicIPs), strconv.Itoa(farm.PublicIPs))
+				}
+			} else if len(existing) > 0 {
+				change(farm.FarmID, "added", "", farm.Name)
+			}
+
+			if err := tx.Save(&farm).Error; err != nil {
+				return fmt.Errorf("failed to save farm %d: %w", farm.FarmID, err)
+			}
+		}
+
+		// A partial fetch cannot tell a removed farm from one on a missing page
+		if len(fetched) == total {
+			for _, old := range rows {
+				if fetched[old.FarmID] || old.RemovedAt != nil {
+					continue
+				}
+				removed, err := markMissed(tx, &old, old.MissedSyncs, now)
+				if err != nil {
+					return fmt.Errorf("failed to mark farm %d missed: %w", old.FarmID, err)
+				}
+				if removed {
+					change(old.FarmID, "removed", old.Name, "")
+				}
+			}
+		}
+		if len(changes) > 0 {
+			return tx.CreateInBatches(changes, 100).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(changes), nil
+}
+
+// saveNodes upserts the nodes and records how they changed since the last run.
+// Used capacity changes constantly, so only its latest value is kept. When
+// nodes add up to total, known nodes missing from it count a missed sync.
+func (s *GridSyncer) saveNodes(run *models.GridSyncRun, nodes []nodeItem, total int) (int, error) {
+	if len(nodes) == 0 {
+		return 0, nil
+	}
+
+	var rows []models.GridNode
+	if err := s.db.Find(&rows).Error; err != nil {
+		return 0, fmt.Errorf("failed to load nodes: %w", err)
+	}
+	existing := make(map[int]models.GridNode, len(rows))
+	for _, row := range rows {
+		existing[row.NodeID] = row
+	}
+
+	now := s.now()
+	var changes []models.GridChange
+	change := func(nodeID int, kind, oldValue, newValue string) {
+		changes = append(changes, models.GridChange{
+			SyncRunID: run.ID, EntityType: "node", EntityID: nodeID,
+			Kind: kind, OldValue: oldValue, NewValue: newValue, DetectedAt: now,
+		})
+	}
+
+	fetched := make(map[int]bool, len(nodes))
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range nodes {
+			// A node that moved to a later page during the walk is seen twice
+			if fetched[item.NodeID] {
+				continue
+			}
+			fetched[item.NodeID] = true
+
+			node := models.GridNode{
+				NodeID:            item.NodeID,
+				FarmID:            item.FarmID,
+				TwinID:            item.TwinID,
+				Country:           item.Country,
+				City:              item.City,
+				Status:            item.Status,
+				StatusSince:       now,
+				CertificationType: item.CertificationType,
+				Dedicated:         item.Dedicated,
+				Rented:            item.Rented,
+				RentedByTwinID:    item.RentedByTwinID,
+				NumGPU:            item.NumGPU,
+				Uptime:            item.Uptime,
+				TotalCRU:          item.TotalResources.CRU,
+				TotalMRU:          item.TotalResources.MRU,
+				TotalSRU:          item.TotalResources.SRU,
+				TotalHRU:          item.TotalResources.HRU,
+				UsedCRU:           item.UsedResources.CRU,
+				UsedMRU:           item.UsedResources.MRU,
+				UsedSRU:           item.UsedResources.SRU,
+				UsedHRU:           item.UsedResources.HRU,
+				FirstSeenAt:       now,
+				SyncedAt:          now,
+			}
+
+			// The first sync only fills the tables; later ones record changes.
+			// A node that was removed and is back counts as added again.
+			if old, seen := existing[node.NodeID]; seen && old.RemovedAt != nil {
+				node.FirstSeenAt = old.FirstSeenAt
+				change(node.NodeID, "added", "", node.Status)
+			} else if seen {
+				node.FirstSeenAt = old.FirstSeenAt
+				if old.Status == node.Status {
+					node.StatusSince = old.StatusSince
+				} else {
+					change(node.NodeID, "status", old.Status, node.Status)
+				}
+				if oldCapacity, newCapacity := nodeCapacity(old), nodeCapacity(node); oldCapacity != newCapacity {
+					change(node.NodeID, "capacity", oldCapacity, newCapacity)
+				}
+				if old.CertificationType != node.CertificationType {
+					change(node.NodeID, "certification", old.CertificationType, node.CertificationType)
+				}
+				if old.Rented != node.Rented {
+					change(node.NodeID, "rented", strconv.FormatBool(old.Rented), strconv.FormatBool(node.Rented))
+				}
+			} else if len(existing) > 0 {
+				change(node.NodeID, "added", "", node.Status)
+			}
+
+			if err := tx.Save(&node).Error; err != nil {
+				return fmt.Errorf("failed to save node %d: %w", node.NodeID, err)
+			}
+		}
+
+		// A partial fetch cannot tell a removed node from one on a missing page
+		if len(fetched) == total {
+			for _, old := range rows {
+				if fetched[old.NodeID] || old.RemovedAt != nil {
+					continue
+				}
+				removed, err := markMissed(tx, &old, old.MissedSyncs, now)
+				if err != nil {
+					return fmt.Errorf("failed to mark node %d missed: %w", old.NodeID, err)
+				}
+				if removed {
+					change(old.NodeID, "removed", old.Status, "")
+				}
+			}
+		}
+		if len(changes) > 0 {
+			return tx.CreateInBatches(changes, 100).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(changes), nil
+}
+
+// markMissed counts a complete sync that did not see row, which had missed
+// missedSyncs before, and marks it removed once removalSyncs is reached.
+// Saving the row when it is seen again resets both.
+func markMissed(tx *gorm.DB, row interface{}, missedSyncs int, now time.Time) (bool, error) {
+	updates := map[string]interface{}{"missed_syncs": missedSyncs + 1}
+	removed := missedSyncs+1 >= removalSyncs
+	if removed {
+		updates["removed_at"] = now
+	}
+	return removed, tx.Model(row).Updates(updates).Error
+}
+
+// saveStats stores the grid_stats result of the run
+func (s *GridSyncer) saveStats(ctx context.Context, run *models.GridSyncRun) error {
+	if !IsTaskSupported("grid_stats") {
+		return fmt.Errorf("grid_stats is not supported by the executor")
+	}
+
+	result, err := ExecuteTask(ctx, "grid_stats", map[string]interface{}{}, nil)
+	if err != nil {
+		return fmt.Errorf("grid_stats failed: %w", err)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode grid stats: %w", err)
+	}
+	snapshot := models.GridStatsSnapshot{SyncRunID: run.ID, Data: string(data), CreatedAt: s.now()}
+	if err := s.db.Create(&snapshot).Error; err != nil {
+		return fmt.Errorf("failed to save grid stats: %w", err)
+	}
+	return nil
+}
+
+// nodeCapacity describes a node's total capacity for the change history
+func nodeCapacity(node models.GridNode) string {
+	const gigabyte = 1 << 30
+	return fmt.Sprintf("cru=%d mru_gb=%d sru_gb=%d hru_gb=%d",
+		node.TotalCRU, node.TotalMRU/gigabyte, node.TotalSRU/gigabyte, node.TotalHRU/gigabyte)
+}
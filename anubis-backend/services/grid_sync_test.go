@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"anubis-backend/models"
+
+	"anubis-executer/schema"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const testGigabyte = 1 << 30
+
+// gridExecutor serves list_farms, list_nodes and grid_stats from fixed items
+type gridExecutor struct {
+	farms    []map[string]interface{}
+	nodes    []map[string]interface{}
+	down     bool  // Fail every task as if GridProxy were unreachable
+	lastPage int64 // Fail list pages after this one, when set
+	extra    int   // Added to total_count, as if items were skipped
+}
+
+func (e *gridExecutor) ExecuteTask(ctx context.Context, taskName string, params map[string]interface{}, caller *Caller) (interface{}, error) {
+	if e.down {
+		return nil, &TaskError{Code: ErrorCodeUpstreamUnavailable, Message: "failed to fetch: gridproxy unreachable", Retryable: true}
+	}
+
+	number, _ := schema.ToInt64(params["page"])
+	if e.lastPage > 0 && number > e.lastPage {
+		return nil, &TaskError{Code: ErrorCodeTimeout, Message: "task timed out", Retryable: true}
+	}
+
+	page := func(items []map[string]interface{}) interface{} {
+		size, _ := schema.ToInt64(params["page_size"])
+		start := min(int((number-1)*size), len(items))
+		end := min(start+int(size), len(items))
+		return map[string]interface{}{"items": items[start:end], "total_count": len(items) + e.extra, "has_more": end < len(items)}
+	}
+
+	switch taskName {
+	case "list_farms":
+		return page(e.farms), nil
+	case "list_nodes":
+		return page(e.nodes), nil
+	case "grid_stats":
+		return map[string]interface{}{"nodes": len(e.nodes), "farms": len(e.farms)}, nil
+	default:
+		return nil, newTaskError(ErrorCodeUnknownTask, "unsupported task: %s", taskName)
+	}
+}
+
+func (e *gridExecutor) GetSupportedTasks() []string {
+	return []string{"list_farms", "list_nodes", "grid_stats"}
+}
+
+func (e *gridExecutor) GetTaskDefinitions() []TaskDefinition {
+	return builtinTaskCatalog()
+}
+
+func testNode(nodeID, farmID int, status string, cru uint64) map[string]interface{} {
+	return map[string]interface{}{
+		"nodeId": nodeID, "farmId": farmID, "twinId": 100 + nodeID, "country": "Belgium", "status": status,
+		"total_resources": map[string]interface{}{"cru": cru, "mru": 32 * testGigabyte, "sru": 512 * testGigabyte},
+		"used_resources":  map[string]interface{}{"cru": 1},
+	}
+}
+
+// setupSyncTest points the task service at e and returns a syncer on a fresh
+// database whose clock is set with the returned function
+func setupSyncTest(t *testing.T, e TaskExecutor) (*GridSyncer, *gorm.DB, func(time.Time)) {
+	db := setupTestDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every SQLite connection would open its own database
+	t.Cleanup(func() { sqlDB.Close() })
+
+	executor = e
+	t.Cleanup(func() { executor = nil })
+
+	syncer := NewGridSyncer(db, time.Minute)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	syncer.now = func() time.Time { return now }
+	return syncer, db, func(t time.Time) { now = t }
+}
+
+func TestGridSyncerRecordsChanges(t *testing.T) {
+	grid := &gridExecutor{
+		farms: []map[string]interface{}{
+			{"farmId": 1, "name": "Freefarm", "certificationType": "NotCertified", "publicIps": []interface{}{map[string]interface{}{"contract_id": 7}}},
+			{"farmId": 2, "name": "NileFarm", "certificationType": "NotCertified"},
+		},
+		nodes: []map[string]interface{}{
+			testNode(11, 1, "up", 8),
+			testNode(12, 1, "up", 16),
+			testNode(21, 2, "up", 32),
+		},
+	}
+	syncer, db, setNow := setupSyncTest(t, grid)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// The first sync only fills the snapshot
+	run, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, SyncStatusSuccess, run.Status)
+	assert.Equal(t, 2, run.Farms)
+	assert.Equal(t, 3, run.Nodes)
+	assert.Equal(t, 0, run.Changes)
+
+	var farm models.GridFarm
+	require.NoError(t, db.First(&farm, "farm_id = ?", 1).Error)
+	assert.Equal(t, 1, farm.PublicIPs)
+	assert.Equal(t, 0, farm.FreeIPs)
+
+	// Node 12 goes down, node 21 loses cores, node 31 joins and farm 2 adds an IP
+	grid.nodes[1]["status"] = "down"
+	grid.nodes[2] = testNode(21, 2, "up", 24)
+	grid.nodes = append(grid.nodes, testNode(31, 2, "up", 64))
+	grid.farms[1]["publicIps"] = []interface{}{map[string]interface{}{"contract_id": 0}}
+	wentDown := start.Add(time.Hour)
+	setNow(wentDown)
+
+	run, err = syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, run.Changes)
+
+	var changes []models.GridChange
+	require.NoError(t, db.Order("entity_type, entity_id").Find(&changes).Error)
+	require.Len(t, changes, 4)
+	assert.Equal(t, []string{"public_ips", "status", "capacity", "added"}, []string{changes[0].Kind, changes[1].Kind, changes[2].Kind, changes[3].Kind})
+	assert.Equal(t, "up", changes[1].OldValue)
+	assert.Equal(t, "down", changes[1].NewValue)
+	assert.Equal(t, "cru=32 mru_gb=32 sru_gb=512 hru_gb=0", changes[2].OldValue)
+	assert.Equal(t, "cru=24 mru_gb=32 sru_gb=512 hru_gb=0", changes[2].NewValue)
+
+	// Node 12 stays down; its status keeps the time it went down
+	setNow(start.Add(3 * time.Hour))
+	run, err = syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Changes)
+
+	history, err := GetNodeHistory(db, 12, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "down", history.Status)
+	assert.WithinDuration(t, wentDown, history.StatusSince, time.Second)
+	assert.Equal(t, "2h0m0s", history.StatusDuration)
+	assert.Equal(t, int64(7200), history.StatusSeconds)
+	require.Len(t, history.Changes, 1)
+	assert.Equal(t, "status", history.Changes[0].Kind)
+}
+
+func TestGridSyncerMarksRemovedNodes(t *testing.T) {
+	grid := &gridExecutor{}
+	for i := 1; i <= 150; i++ {
+		grid.nodes = append(grid.nodes, testNode(i, 1, "up", 4))
+	}
+	syncer, db, setNow := setupSyncTest(t, grid)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+
+	// Node 1 is gone, but a sync that fails on a later page cannot tell
+	grid.nodes = grid.nodes[1:]
+	grid.lastPage = 1
+	setNow(start.Add(time.Hour))
+	run, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, SyncStatusPartial, run.Status)
+	assert.Equal(t, 0, run.Changes)
+
+	// Nor can a sync whose items do not add up to total_count
+	grid.lastPage = 0
+	grid.extra = 1
+	setNow(start.Add(2 * time.Hour))
+	run, err = syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, SyncStatusSuccess, run.Status)
+	assert.Equal(t, 0, run.Changes)
+
+	// The first complete sync without node 1 only counts the miss
+	grid.extra = 0
+	setNow(start.Add(3 * time.Hour))
+	run, err = syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Changes)
+	_, _, err = ExecuteSnapshotTask(db, "get_node", map[string]interface{}{"node_id": 1})
+	require.NoError(t, err)
+
+	// The second one in a row marks it removed
+	removed := start.Add(4 * time.Hour)
+	setNow(removed)
+	run, err = syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Changes)
+
+	_, _, err = ExecuteSnapshotTask(db, "get_node", map[string]interface{}{"node_id": 1})
+	assert.Equal(t, ErrorCodeNotFound, ErrorCode(err))
+	result, _, err := ExecuteSnapshotTask(db, "list_nodes", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(149), result.(SnapshotListResult).TotalCount)
+
+	history, err := GetNodeHistory(db, 1, 0)
+	require.NoError(t, err)
+	require.NotNil(t, history.Node.RemovedAt)
+	assert.WithinDuration(t, removed, *history.Node.RemovedAt, time.Second)
+	require.Len(t, history.Changes, 1)
+	assert.Equal(t, "removed", history.Changes[0].Kind)
+	assert.Equal(t, "up", history.Changes[0].OldValue)
+
+	// A node that comes back is added again
+	grid.nodes = append(grid.nodes, testNode(1, 1, "up", 4))
+	setNow(start.Add(5 * time.Hour))
+	run, err = syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, run.Changes)
+
+	history, err = GetNodeHistory(db, 1, 0)
+	require.NoError(t, err)
+	assert.Nil(t, history.Node.RemovedAt)
+	assert.Equal(t, 0, history.Node.MissedSyncs)
+	assert.Equal(t, "added", history.Changes[0].Kind)
+}
+
+func TestGridSyncerMarksRemovedFarms(t *testing.T) {
+	grid := &gridExecutor{
+		farms: []map[string]interface{}{
+			{"farmId": 1, "name": "Freefarm"},
+			{"farmId": 2, "name": "NileFarm"},
+		},
+	}
+	syncer, db, setNow := setupSyncTest(t, grid)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+
+	// Farm 2 is missed by one sync, then seen again: the miss is forgotten
+	grid.farms = grid.farms[:1]
+	setNow(start.Add(time.Hour))
+	run, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Changes)
+
+	grid.farms = append(grid.farms, map[string]interface{}{"farmId": 2, "name": "NileFarm"})
+	setNow(start.Add(2 * time.Hour))
+	run, err = syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, run.Changes)
+
+	var farm models.GridFarm
+	require.NoError(t, db.First(&farm, "farm_id = ?", 2).Error)
+	assert.Equal(t, 0, farm.MissedSyncs)
+
+	// Two complete syncs in a row without farm 2 mark it removed
+	grid.farms = grid.farms[:1]
+	for hour := 3; hour <= 4; hour++ {
+		setNow(start.Add(time.Duration(hour) * time.Hour))
+		run, err = syncer.SyncOnce(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, run.Changes)
+
+	var changes []models.GridChange
+	require.NoError(t, db.Find(&changes, "entity_type = ? AND entity_id = ?", "farm", 2).Error)
+	require.Len(t, changes, 1)
+	assert.Equal(t, "removed", changes[0].Kind)
+	assert.Equal(t, "NileFarm", changes[0].OldValue)
+
+	_, _, err = ExecuteSnapshotTask(db, "get_farm", map[string]interface{}{"farm_id": 2})
+	assert.Equal(t, ErrorCodeNotFound, ErrorCode(err))
+	result, _, err := ExecuteSnapshotTask(db, "list_farms", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), result.(SnapshotListResult).TotalCount)
+}
+
+func TestGridSyncerWalksEveryPage(t *testing.T) {
+	grid := &gridExecutor{}
+	for i := 1; i <= 250; i++ {
+		grid.nodes = append(grid.nodes, testNode(i, 1, "up", 4))
+	}
+	syncer, db, _ := setupSyncTest(t, grid)
+
+	run, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 250, run.Nodes)
+
+	var count int64
+	require.NoError(t, db.Model(&models.GridNode{}).Count(&count).Error)
+	assert.Equal(t, int64(250), count)
+}
+
+func TestGridSyncerPartialRun(t *testing.T) {
+	// The in-process executor only lists farms
+	syncer, db, _ := setupSyncTest(t, &SimpleTaskExecutor{network: "test"})
+
+	run, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, SyncStatusPartial, run.Status)
+	assert.Equal(t, 2, run.Farms)
+	assert.Contains(t, run.ErrorMsg, "list_nodes is not supported by the executor")
+	assert.NotNil(t, run.FinishedAt)
+
+	runs, err := GetSyncRuns(db, 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, SyncStatusPartial, runs[0].Status)
+}
+
+func TestGridSyncerFailedRun(t *testing.T) {
+	syncer, db, _ := setupSyncTest(t, &gridExecutor{down: true})
+
+	run, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, SyncStatusFailed, run.Status)
+
+	// A failed run leaves nothing to answer from
+	_, _, err = ExecuteSnapshotTask(db, "list_nodes", map[string]interface{}{})
+	assert.Equal(t, ErrorCodeUpstreamUnavailable, ErrorCode(err))
+}
+
+func TestExecuteSnapshotTask(t *testing.T) {
+	grid := &gridExecutor{
+		farms: []map[string]interface{}{{"farmId": 1, "name": "Freefarm"}},
+		nodes: []map[string]interface{}{
+			testNode(11, 1, "up", 8),
+			testNode(12, 1, "down", 16),
+			testNode(13, 1, "up", 4),
+		},
+	}
+	syncer, db, _ := setupSyncTest(t, grid)
+	_, err := syncer.SyncOnce(context.Background())
+	require.NoError(t, err)
+
+	result, syncedAt, err := ExecuteSnapshotTask(db, "list_nodes", map[string]interface{}{"status": []interface{}{"up"}, "page": 1, "page_size": 1, "order": "asc"})
+	require.NoError(t, err)
+	list := result.(SnapshotListResult)
+	assert.Equal(t, int64(2), list.TotalCount)
+	assert.True(t, list.HasMore)
+	nodes := list.Items.([]nodeItem)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, 11, nodes[0].NodeID)
+	assert.WithinDuration(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), syncedAt, time.Second)
+
+	// Items have the live task's fields, so they are shaped the same way
+	result, _, err = ExecuteSnapshotTask(db, "list_nodes", map[string]interface{}{
+		"fields": []interface{}{"nodeId", "total_resources.cru"}, "sort_by": "total_resources.cru", "order": "desc",
+	})
+	require.NoError(t, err)
+	data, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"items":[{"nodeId":12,"total_resources":{"cru":16}},{"nodeId":11,"total_resources":{"cru":8}},{"nodeId":13,"total_resources":{"cru":4}}],"total_count":3,"page":1,"page_size":5,"has_more":false}`, string(data))
+
+	_, _, err = ExecuteSnapshotTask(db, "list_nodes", map[string]interface{}{"sort_by": "colour"})
+	assert.Equal(t, ErrorCodeInvalidParams, ErrorCode(err))
+
+	// Farm names match in part and locations through the farm's nodes
+	for _, params := range []map[string]interface{}{{"name": "FREE"}, {"location": "belgium"}} {
+		result, _, err = ExecuteSnapshotTask(db, "list_farms", params)
+		require.NoError(t, err)
+		farms := result.(SnapshotListResult).Items.([]farmItem)
+		require.Len(t, farms, 1, params)
+		assert.Equal(t, 1, farms[0].FarmID)
+	}
+	for _, params := range []map[string]interface{}{{"name": "free_"}, {"location": "Egypt"}} {
+		result, _, err = ExecuteSnapshotTask(db, "list_farms", params)
+		require.NoError(t, err)
+		assert.Empty(t, result.(SnapshotListResult).Items, params)
+	}
+
+	result, _, err = ExecuteSnapshotTask(db, "get_node", map[string]interface{}{"node_id": 12})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), result.(nodeItem).TotalResources.CRU)
+
+	result, _, err = ExecuteSnapshotTask(db, "node_status", map[string]interface{}{"node_id": 12})
+	require.NoError(t, err)
+	assert.Equal(t, "down", result.(SnapshotNodeStatus).Status)
+	assert.False(t, result.(SnapshotNodeStatus).Online)
+
+	result, _, err = ExecuteSnapshotTask(db, "grid_stats", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), result.(map[string]interface{})["nodes"])
+
+	_, _, err = ExecuteSnapshotTask(db, "get_farm", map[string]interface{}{"farm_id": 9})
+	assert.Equal(t, ErrorCodeNotFound, ErrorCode(err))
+
+	_, _, err = ExecuteSnapshotTask(db, "list_nodes", map[string]interface{}{"free_cru": 4})
+	var taskErr *TaskError
+	require.True(t, errors.As(err, &taskErr))
+	assert.Equal(t, ErrorCodeInvalidParams, taskErr.Code)
+	assert.Equal(t, "free_cru", taskErr.Field)
+}
+
+func TestCanServeStale(t *testing.T) {
+	assert.True(t, CanServeStale(&TaskError{Code: ErrorCodeUpstreamUnavailable}))
+	assert.True(t, CanServeStale(&TaskError{Code: ErrorCodeTimeout}))
+	assert.True(t, CanServeStale(&TaskError{Code: ErrorCodeBusy}))
+	assert.True(t, CanServeStale(fmt.Errorf("list_nodes: %w", context.DeadlineExceeded)))
+	assert.False(t, CanServeStale(errors.New("failed to decode executor response")))
+	assert.False(t, CanServeStale(&TaskError{Code: ErrorCodeInternal}))
+	assert.False(t, CanServeStale(&TaskError{Code: ErrorCodeNotFound}))
+	assert.False(t, CanServeStale(&ValidationError{Errors: []FieldError{{Field: "node_id"}}}))
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"anubis-backend/config"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxExecutorTimeout is the longest task timeout the executor accepts
+const maxExecutorTimeout = 5 * time.Minute
+
+// taskDefinitionsTTL is how long the executor's task registry is reused
+// before it is fetched again
+const taskDefinitionsTTL = time.Minute
+
+// RemoteTaskExecutor runs tasks on an executor served over HTTP
+// (anubis-executer serve). It forwards the caller, the remaining time of
+// the request's context as the task timeout, and the request ID.
+type RemoteTaskExecutor struct {
+	baseURL string
+	secret  string
+	client  *http.Client
+
+	mu          sync.Mutex
+	definitions []TaskDefinition
+	fetchedAt   time.Time
+}
+
+// remoteTaskRequest is the task JSON accepted by the executor's /execute
+type remoteTaskRequest struct {
+	TaskName string                 `json:"task_name"`
+	Params   map[string]interface{} `json:"params"`
+	Caller   *Caller                `json:"caller,omitempty"`
+	Timeout  string                 `json:"timeout,omitempty"`
+}
+
+// remoteTaskResponse is the executor's TaskResponse
+type remoteTaskResponse struct {
+	Success   bool            `json:"success"`
+	Data      interface{}     `json:"data,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Errors    []FieldError    `json:"errors,omitempty"`
+	Code      string          `json:"code,omitempty"`
+	Retryable bool            `json:"retryable,omitempty"`
+	Field     string          `json:"field,omitempty"`
+	Upstream  *UpstreamStatus `json:"upstream,omitempty"`
+}
+
+// err converts a failed response into a ValidationError when it lists field
+// errors, and into a TaskError otherwise
+func (r remoteTaskResponse) err() error {
+	if len(r.Errors) > 0 {
+		return &ValidationError{Errors: r.Errors}
+	}
+
+	code := r.Code
+	if code == "" {
+		// Executors without error codes report timeouts only in the message
+		code = ErrorCodeInternal
+		if strings.Contains(r.Error, "timed out after") {
+			code = ErrorCodeTimeout
+		}
+	}
+	return &TaskError{
+		Code:      code,
+		Message:   r.Error,
+		Field:     r.Field,
+		Retryable: r.Retryable,
+		Upstream:  r.Upstream,
+	}
+}
+
+// NewRemoteTaskExecutor creates an executor client from the EXECUTOR_* settings
+func NewRemoteTaskExecutor(cfg config.ExecutorConfig) (*RemoteTaskExecutor, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.TLSCertFile != "" || cfg.TLSCAFile != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+		if cfg.TLSCertFile != "" {
+			certificate, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load executor client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+
+		if cfg.TLSCAFile != "" {
+			caPEM, err := os.ReadFile(cfg.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read executor CA: %w", err)
+			}
+			rootCAs := x509.NewCertPool()
+			if !rootCAs.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("no certificates found in %s", cfg.TLSCAFile)
+			}
+			tlsConfig.RootCAs = rootCAs
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &RemoteTaskExecutor{
+		baseURL: strings.TrimSuffix(cfg.URL, "/"),
+		secret:  cfg.Secret,
+		client:  &http.Client{Transport: transport},
+	}, nil
+}
+
+// ExecuteTask implements the TaskExecutor interface
+func (e *RemoteTaskExecutor) ExecuteTask(ctx context.Context, taskName string, params map[string]interface{}, caller *Caller) (interface{}, error) {
+	task := remoteTaskRequest{
+		TaskName: taskName,
+		Params:   params,
+		Caller:   caller,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline).Round(time.Millisecond)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("task %s did not start: %w", taskName, context.DeadlineExceeded)
+		}
+		task.Timeout = min(remaining, maxExecutorTimeout).String()
+	}
+
+	body, err := json.Marshal(task)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode task: %w", err)
+	}
+
+	var response remoteTaskResponse
+	if err := e.do(ctx, http.MethodPost, "/execute", body, &response); err != nil {
+		return nil, err
+	}
+
+	if !response.Success {
+		return nil, response.err()
+	}
+
+	return response.Data, nil
+}
+
+// GetSupportedTasks implements the TaskExecutor interface
+func (e *RemoteTaskExecutor) GetSupportedTasks() []string {
+	definitions := e.GetTaskDefinitions()
+	names := make([]string, 0, len(definitions))
+	for _, def := range definitions {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// GetTaskDefinitions implements the TaskExecutor interface. The registry is
+// fetched from the executor at most once per taskDefinitionsTTL; while the
+// executor is unreachable the last fetched registry, or the one embedded at
+// build time, is used.
+func (e *RemoteTaskExecutor) GetTaskDefinitions() []TaskDefinition {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.definitions != nil && time.Since(e.fetchedAt) < taskDefinitionsTTL {
+		return e.definitions
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var response struct {
+		Tasks []TaskDefinition `json:"tasks"`
+	}
+	if err := e.do(ctx, http.MethodGet, "/tasks", nil, &response); err != nil {
+		log.Printf("Failed to fetch task definitions from executor: %v", err)
+		if e.definitions != nil {
+			return e.definitions
+		}
+		return builtinTaskCatalog()
+	}
+
+	e.definitions = response.Tasks
+	e.fetchedAt = time.Now()
+	return e.definitions
+}
+
+// do sends a request to the executor and decodes its JSON response into out
+func (e *RemoteTaskExecutor) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, e.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create executor request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+e.secret)
+	}
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	resp, err := e.client.Do(req)
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded):
+		// The task ran out of time before the executor answered
+		return &TaskError{Code: ErrorCodeTimeout, Message: fmt.Sprintf("executor request timed out: %v", err), Retryable: true}
+	case errors.Is(err, context.Canceled):
+		return fmt.Errorf("executor request failed: %w", err)
+	default:
+		// The executor could not be reached; the task may not have run
+		return &TaskError{Code: ErrorCodeUpstreamUnavailable, Message: fmt.Sprintf("executor request failed: %v", err), Retryable: true}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read executor response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		message := fmt.Sprintf("executor returned %d", resp.StatusCode)
+		var failure remoteTaskResponse
+		if json.Unmarshal(data, &failure) == nil && failure.Error != "" {
+			message += ": " + failure.Error
+		}
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			// Every task slot is taken; the task was not run and may be retried
+			return &TaskError{Code: ErrorCodeBusy, Message: message, Retryable: true}
+		}
+		return errors.New(message)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode executor response: %w", err)
+	}
+	return nil
+}
+
+// requestIDKey is the context key of the API request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the API request ID, which is
+// forwarded to the executor so both logs can be correlated
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID set with WithRequestID
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"anubis-backend/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor serves /tasks and /execute like anubis-executer serve
+type fakeExecutor struct {
+	response    map[string]interface{}
+	status      int
+	taskFetches atomic.Int32
+	lastTask    remoteTaskRequest
+	lastHeaders http.Header
+}
+
+func (f *fakeExecutor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lastHeaders = r.Header.Clone()
+	if r.Header.Get("Authorization") != "Bearer s3cret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": false, "error": "invalid or missing shared secret"})
+		return
+	}
+
+	switch r.URL.Path {
+	case "/tasks":
+		f.taskFetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tasks": []map[string]interface{}{{"name": "list_farms", "category": "farms"}, {"name": "list_nodes", "category": "nodes"}},
+		})
+	case "/execute":
+		json.NewDecoder(r.Body).Decode(&f.lastTask)
+		if f.status != 0 {
+			w.WriteHeader(f.status)
+		}
+		json.NewEncoder(w).Encode(f.response)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newRemoteTestExecutor(t *testing.T, fake *fakeExecutor, secret string) *RemoteTaskExecutor {
+	t.Helper()
+
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	remote, err := NewRemoteTaskExecutor(config.ExecutorConfig{URL: srv.URL + "/", Secret: secret})
+	require.NoError(t, err)
+	return remote
+}
+
+func TestRemoteTaskExecutorExecuteTask(t *testing.T) {
+	fake := &fakeExecutor{response: map[string]interface{}{
+		"success": true,
+		"data":    map[string]interface{}{"items": []interface{}{}, "total_count": float64(0)},
+	}}
+	remote := newRemoteTestExecutor(t, fake, "s3cret")
+
+	twinID := int64(42)
+	ctx, cancel := context.WithTimeout(WithRequestID(context.Background(), "req_123"), 10*time.Second)
+	defer cancel()
+
+	result, err := remote.ExecuteTask(ctx, "list_contracts", map[string]interface{}{"state": []interface{}{"Created"}},
+		&Caller{TwinID: &twinID, WalletAddress: "5Bob"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"items": []interface{}{}, "total_count": float64(0)}, result)
+
+	// The task, caller, remaining time and request ID are forwarded
+	assert.Equal(t, "list_contracts", fake.lastTask.TaskName)
+	assert.Equal(t, []interface{}{"Created"}, fake.lastTask.Params["state"])
+	require.NotNil(t, fake.lastTask.Caller)
+	assert.Equal(t, int64(42), *fake.lastTask.Caller.TwinID)
+	assert.Equal(t, "5Bob", fake.lastTask.Caller.WalletAddress)
+	timeout, err := time.ParseDuration(fake.lastTask.Timeout)
+	require.NoError(t, err)
+	assert.InDelta(t, 10*time.Second, timeout, float64(time.Second))
+	assert.Equal(t, "req_123", fake.lastHeaders.Get("X-Request-ID"))
+}
+
+func TestRemoteTaskExecutorErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		status        int
+		response      map[string]interface{}
+		expectedError string
+		expectedCode  string
+		deadline      bool
+		validation    bool
+	}{
+		{"task failure", "s3cret", 0, map[string]interface{}{"success": false, "error": "farm with ID 9 not found", "code": "not_found"}, "farm with ID 9 not found", ErrorCodeNotFound, false, false},
+		{"executor timeout", "s3cret", 0, map[string]interface{}{"success": false, "error": "task list_farms timed out after 30s: context deadline exceeded", "code": "timeout", "retryable": true}, "timed out after 30s", ErrorCodeTimeout, true, false},
+		{"timeout without code", "s3cret", 0, map[string]interface{}{"success": false, "error": "task list_farms timed out after 30s: context deadline exceeded"}, "timed out after 30s", ErrorCodeTimeout, true, false},
+		{"uncoded failure", "s3cret", 0, map[string]interface{}{"success": false, "error": "boom"}, "boom", ErrorCodeInternal, false, false},
+		{"validation errors", "s3cret", 0, map[string]interface{}{"success": false, "error": "invalid", "errors": []map[string]interface{}{{"field": "page", "message": "must be at least 1"}}}, "page must be at least 1", ErrorCodeInvalidParams, false, true},
+		{"busy", "s3cret", http.StatusServiceUnavailable, map[string]interface{}{"success": false, "error": "executor is busy, retry later"}, "executor returned 503: executor is busy", ErrorCodeBusy, false, false},
+		{"wrong secret", "wrong", 0, nil, "executor returned 401: invalid or missing shared secret", ErrorCodeInternal, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeExecutor{response: tt.response, status: tt.status}
+			remote := newRemoteTestExecutor(t, fake, tt.secret)
+
+			_, err := remote.ExecuteTask(context.Background(), "list_farms", map[string]interface{}{}, nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+			assert.Equal(t, tt.expectedCode, ErrorCode(err))
+			assert.Equal(t, tt.deadline, errors.Is(err, context.DeadlineExceeded))
+
+			var validationErr *ValidationError
+			assert.Equal(t, tt.validation, errors.As(err, &validationErr))
+		})
+	}
+}
+
+func TestRemoteTaskExecutorErrorDetails(t *testing.T) {
+	fake := &fakeExecutor{response: map[string]interface{}{
+		"success":   false,
+		"error":     "failed to fetch farms: request failed with status code 502 (after 3 attempts)",
+		"code":      "upstream_unavailable",
+		"retryable": true,
+		"upstream":  map[string]interface{}{"endpoint": "https://gridproxy.grid.tf/", "status_code": 502, "attempts": 3, "message": "request failed with status code 502"},
+	}}
+	remote := newRemoteTestExecutor(t, fake, "s3cret")
+
+	_, err := remote.ExecuteTask(context.Background(), "list_farms", map[string]interface{}{}, nil)
+
+	var taskErr *TaskError
+	require.ErrorAs(t, err, &taskErr)
+	assert.Equal(t, ErrorCodeUpstreamUnavailable, taskErr.Code)
+	assert.True(t, taskErr.Retryable)
+	require.NotNil(t, taskErr.Upstream)
+	assert.Equal(t, UpstreamStatus{Endpoint: "https://gridproxy.grid.tf/", StatusCode: 502, Attempts: 3, Message: "request failed with status code 502"}, *taskErr.Upstream)
+}
+
+func TestRemoteTaskExecutorBusy(t *testing.T) {
+	fake := &fakeExecutor{status: http.StatusServiceUnavailable, response: map[string]interface{}{"success": false, "error": "executor is busy, retry later", "code": "busy", "retryable": true}}
+	remote := newRemoteTestExecutor(t, fake, "s3cret")
+
+	_, err := remote.ExecuteTask(context.Background(), "list_farms", map[string]interface{}{}, nil)
+
+	var taskErr *TaskError
+	require.ErrorAs(t, err, &taskErr)
+	assert.Equal(t, ErrorCodeBusy, taskErr.Code)
+	assert.True(t, taskErr.Retryable)
+	assert.True(t, CanServeStale(err))
+}
+
+func TestRemoteTaskExecutorTransportErrors(t *testing.T) {
+	unreachable, err := NewRemoteTaskExecutor(config.ExecutorConfig{URL: "http://127.0.0.1:1"})
+	require.NoError(t, err)
+
+	_, err = unreachable.ExecuteTask(context.Background(), "list_farms", map[string]interface{}{}, nil)
+	var taskErr *TaskError
+	require.ErrorAs(t, err, &taskErr)
+	assert.Equal(t, ErrorCodeUpstreamUnavailable, taskErr.Code)
+	assert.True(t, taskErr.Retryable)
+	assert.Contains(t, err.Error(), "executor request failed")
+
+	// An executor that does not answer before the deadline
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	remote, err := NewRemoteTaskExecutor(config.ExecutorConfig{URL: slow.URL})
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = remote.ExecuteTask(ctx, "list_farms", map[string]interface{}{}, nil)
+	assert.Equal(t, ErrorCodeTimeout, ErrorCode(err))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRemoteTaskExecutorDefinitions(t *testing.T) {
+	fake := &fakeExecutor{}
+	remote := newRemoteTestExecutor(t, fake, "s3cret")
+
+	assert.Equal(t, []string{"list_farms", "list_nodes"}, remote.GetSupportedTasks())
+	assert.Len(t, remote.GetTaskDefinitions(), 2)
+	assert.Equal(t, int32(1), fake.taskFetches.Load(), "definitions should be reused within the TTL")
+
+	// An unreachable executor falls back to the embedded catalog
+	unreachable, err := NewRemoteTaskExecutor(config.ExecutorConfig{URL: "http://127.0.0.1:1"})
+	require.NoError(t, err)
+	assert.Equal(t, len(builtinTaskCatalog()), len(unreachable.GetTaskDefinitions()))
+}
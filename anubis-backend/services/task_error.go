@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Task error codes, as reported by the executor in TaskResponse.code
+const (
+	ErrorCodeInvalidRequest      = "invalid_request"
+	ErrorCodeInvalidParams       = "invalid_params"
+	ErrorCodeUnknownTask         = "unknown_task"
+	ErrorCodeNotFound            = "not_found"
+	ErrorCodeUpstreamUnavailable = "upstream_unavailable"
+	ErrorCodeTimeout             = "timeout"
+	ErrorCodeCancelled           = "cancelled"
+	ErrorCodeInternal            = "internal"
+
+	// Reported by the executor server when all its task slots are taken
+	ErrorCodeBusy = "busy"
+)
+
+// UpstreamStatus describes the failed GridProxy call behind an
+// upstream_unavailable error
+type UpstreamStatus struct {
+	Endpoint   string `json:"endpoint,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Attempts   int    `json:"attempts,omitempty"`
+	Message    string `json:"message"`
+}
+
+// TaskError is a classified task failure. Timeouts match
+// context.DeadlineExceeded with errors.Is.
+type TaskError struct {
+	Code      string          `json:"code"`
+	Message   string          `json:"message"`
+	Field     string          `json:"field,omitempty"`
+	Retryable bool            `json:"retryable,omitempty"`
+	Upstream  *UpstreamStatus `json:"upstream,omitempty"`
+}
+
+// Error implements the error interface
+func (e *TaskError) Error() string {
+	return e.Message
+}
+
+// Is reports timeouts as context.DeadlineExceeded
+func (e *TaskError) Is(target error) bool {
+	return e.Code == ErrorCodeTimeout && target == context.DeadlineExceeded
+}
+
+// newTaskError creates a TaskError with a formatted message
+func newTaskError(code string, format string, args ...interface{}) *TaskError {
+	return &TaskError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrorCode returns the code of a task failure: that of a TaskError in err's
+// chain, invalid_params for validation errors, timeout or cancelled for
+// context errors, and internal otherwise
+func ErrorCode(err error) string {
+	var taskErr *TaskError
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &taskErr):
+		return taskErr.Code
+	case errors.As(err, &validationErr):
+		return ErrorCodeInvalidParams
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeCancelled
+	default:
+		return ErrorCodeInternal
+	}
+}
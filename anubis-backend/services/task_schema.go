@@ -0,0 +1,58 @@
+// Package services provides business logic for the Anubis API.
+// This file contains the task parameter schemas exported by anubis-executer
+// and applies them before a task is executed. Validation is done by the
+// executor's schema package, so the API and the executor reject the same
+// inputs with the same messages.
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"anubis-executer/schema"
+)
+
+// taskCatalogJSON is generated by `make schema` in anubis-executer.
+// Do not edit it by hand.
+//
+//go:embed task_schemas.json
+var taskCatalogJSON []byte
+
+// Parameter schemas and validation errors shared with the executor
+type (
+	ParamSpec       = schema.ParamSpec
+	ParamSchema     = schema.ParamSchema
+	FieldError      = schema.FieldError
+	ValidationError = schema.ValidationError
+)
+
+// LoadTaskCatalog parses the task definitions exported by the executor
+func LoadTaskCatalog(data []byte) ([]TaskDefinition, error) {
+	var catalog struct {
+		Tasks []TaskDefinition `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse task catalog: %w", err)
+	}
+	return catalog.Tasks, nil
+}
+
+// builtinTaskCatalog returns the task definitions embedded at build time
+func builtinTaskCatalog() []TaskDefinition {
+	definitions, err := LoadTaskCatalog(taskCatalogJSON)
+	if err != nil {
+		panic(err)
+	}
+	return definitions
+}
+
+// ValidateTaskParams validates params against the schema of the named task
+// and returns a copy with values coerced to their declared types and
+// defaults applied. Unknown tasks are accepted as-is.
+func ValidateTaskParams(taskName string, params map[string]interface{}) (map[string]interface{}, error) {
+	if def, ok := GetTaskDefinition(taskName); ok {
+		return def.Parameters.Validate(params)
+	}
+	return params, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinTaskCatalog(t *testing.T) {
+	definitions := builtinTaskCatalog()
+	require.NotEmpty(t, definitions)
+
+	for _, def := range definitions {
+		assert.NotEmpty(t, def.Name)
+		assert.NotEmpty(t, def.Description)
+		assert.NotEmpty(t, def.Category)
+		assert.NotEmpty(t, def.Version)
+		assert.NotNil(t, def.Parameters, "task %s has no parameter schema", def.Name)
+	}
+}
+
+func TestValidateTaskParams(t *testing.T) {
+	executor = &SimpleTaskExecutor{network: "test"}
+	defer func() { executor = nil }()
+
+	t.Run("coerces and applies defaults", func(t *testing.T) {
+		params, err := ValidateTaskParams("list_farms", map[string]interface{}{"farm_id": float64(3)})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), params["farm_id"])
+		assert.Equal(t, int64(1), params["page"])
+	})
+
+	t.Run("reports field errors", func(t *testing.T) {
+		_, err := ValidateTaskParams("list_farms", map[string]interface{}{"page": "invalid", "farm_id": float64(0)})
+		require.Error(t, err)
+
+		validationErr, ok := err.(*ValidationError)
+		require.True(t, ok)
+		require.Len(t, validationErr.Errors, 2)
+		assert.Equal(t, FieldError{Field: "farm_id", Message: "must be >= 1, got: 0"}, validationErr.Errors[0])
+		assert.Equal(t, FieldError{Field: "page", Message: "must be an integer, got: string"}, validationErr.Errors[1])
+	})
+
+	t.Run("required parameter", func(t *testing.T) {
+		_, err := ValidateTaskParams("get_farm", map[string]interface{}{})
+		require.Error(t, err)
+		assert.Equal(t, "farm_id parameter is required", err.Error())
+	})
+
+	t.Run("unknown task is passed through", func(t *testing.T) {
+		params, err := ValidateTaskParams("unknown", map[string]interface{}{"a": 1})
+		require.NoError(t, err)
+		assert.Equal(t, 1, params["a"])
+	})
+}
+
+func TestCallerFromUser(t *testing.T) {
+	twinID := int64(42)
+
+	assert.Nil(t, CallerFromUser(nil))
+	assert.Nil(t, CallerFromUser(&UserProfile{Email: "user@example.com"}))
+
+	caller := CallerFromUser(&UserProfile{TwinID: &twinID, WalletAddress: "5Bob"})
+	if assert.NotNil(t, caller) {
+		assert.Equal(t, int64(42), *caller.TwinID)
+		assert.Equal(t, "5Bob", caller.WalletAddress)
+	}
+}
@@ -2,39 +2,98 @@ package services
 
 import (
 	"anubis-backend/config"
+	"context"
 	"fmt"
 	"log"
+	"time"
 )
 
 // TaskExecutor interface for executing tasks
 type TaskExecutor interface {
-	ExecuteTask(taskName string, params map[string]interface{}) (interface{}, error)
+	ExecuteTask(ctx context.Context, taskName string, params map[string]interface{}, caller *Caller) (interface{}, error)
 	GetSupportedTasks() []string
+	GetTaskDefinitions() []TaskDefinition
+}
+
+// TaskDefinition describes a task the executor can run. It mirrors the
+// executor's task registry so the API can document and validate tasks
+// without keeping its own list.
+type TaskDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Category    string                 `json:"category"`
+	Version     string                 `json:"version"`
+	Parameters  *ParamSchema           `json:"parameters,omitempty"`
+	Example     map[string]interface{} `json:"example,omitempty"`
+}
+
+// Caller identifies the authenticated user a task runs on behalf of.
+// The executor exposes it to tasks as implicit parameters, e.g. get_twin
+// returns the caller's own twin when no twin_id is given.
+type Caller struct {
+	TwinID        *int64 `json:"twin_id,omitempty"`
+	WalletAddress string `json:"wallet_address,omitempty"`
+}
+
+// CallerFromUser builds the task caller for an authenticated user.
+// It returns nil for anonymous requests and users without a wallet.
+func CallerFromUser(user *UserProfile) *Caller {
+	if user == nil || (user.TwinID == nil && user.WalletAddress == "") {
+		return nil
+	}
+	return &Caller{
+		TwinID:        user.TwinID,
+		WalletAddress: user.WalletAddress,
+	}
 }
 
 var executor TaskExecutor
 
+// taskTimeout bounds every task execution (API_TIMEOUT); zero means no limit
+var taskTimeout time.Duration
+
 // InitTaskService initializes the task service with the executer
 func InitTaskService(cfg *config.Config) error {
-	// Import the executer package from anubis-executer
-	// For now, we'll create a simple implementation
-	// In production, this would use the actual executer package
-	
-	executor = &SimpleTaskExecutor{
-		network: cfg.TFGrid.Network,
-	}
-	
+	// Tasks run on the executor service when EXECUTOR_URL is set; otherwise
+	// the simple in-process implementation is used
+	if cfg.Executor.URL != "" {
+		remote, err := NewRemoteTaskExecutor(cfg.Executor)
+		if err != nil {
+			return fmt.Errorf("failed to configure remote executor: %w", err)
+		}
+		executor = remote
+		log.Printf("Task service using executor at %s", cfg.Executor.URL)
+	} else {
+		executor = &SimpleTaskExecutor{
+			network: cfg.TFGrid.Network,
+		}
+	}
+	taskTimeout = cfg.API.Timeout
+
 	log.Println("Task service initialized successfully")
 	return nil
 }
 
-// ExecuteTask executes a task using the configured executor
-func ExecuteTask(taskName string, params map[string]interface{}) (interface{}, error) {
+// ExecuteTask executes a task using the configured executor.
+// The task is cancelled when ctx is done or after API_TIMEOUT, whichever
+// comes first; the returned error then wraps the context error.
+func ExecuteTask(ctx context.Context, taskName string, params map[string]interface{}, caller *Caller) (interface{}, error) {
 	if executor == nil {
 		return nil, fmt.Errorf("task executor not initialized")
 	}
-	
-	return executor.ExecuteTask(taskName, params)
+
+	if taskTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, taskTimeout)
+		defer cancel()
+	}
+
+	result, err := executor.ExecuteTask(ctx, taskName, params, caller)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return nil, fmt.Errorf("task %s did not complete: %w", taskName, ctxErr)
+	}
+
+	return result, err
 }
 
 // GetSupportedTasks returns the list of supported tasks
@@ -42,10 +101,39 @@ func GetSupportedTasks() []string {
 	if executor == nil {
 		return []string{}
 	}
-	
+
 	return executor.GetSupportedTasks()
 }
 
+// GetTaskDefinitions returns the definitions of all supported tasks
+func GetTaskDefinitions() []TaskDefinition {
+	if executor == nil {
+		return []TaskDefinition{}
+	}
+
+	return executor.GetTaskDefinitions()
+}
+
+// GetTaskDefinition returns the definition of the named task
+func GetTaskDefinition(taskName string) (TaskDefinition, bool) {
+	for _, def := range GetTaskDefinitions() {
+		if def.Name == taskName {
+			return def, true
+		}
+	}
+	return TaskDefinition{}, false
+}
+
+// IsTaskSupported reports whether the configured executor supports taskName
+func IsTaskSupported(taskName string) bool {
+	for _, name := range GetSupportedTasks() {
+		if name == taskName {
+			return true
+		}
+	}
+	return false
+}
+
 // SimpleTaskExecutor is a simple implementation for demonstration
 // In production, this would be replaced with the actual executer from anubis-executer
 type SimpleTaskExecutor struct {
@@ -53,56 +141,75 @@ type SimpleTaskExecutor struct {
 }
 
 // ExecuteTask implements the TaskExecutor interface
-func (e *SimpleTaskExecutor) ExecuteTask(taskName string, params map[string]interface{}) (interface{}, error) {
+func (e *SimpleTaskExecutor) ExecuteTask(ctx context.Context, taskName string, params map[string]interface{}, caller *Caller) (interface{}, error) {
 	log.Printf("Executing task: %s with params: %v", taskName, params)
-	
+
 	switch taskName {
 	case "list_farms":
 		return e.listFarms(params)
 	case "get_farm":
 		return e.getFarm(params)
 	default:
-		return nil, fmt.Errorf("unsupported task: %s", taskName)
+		return nil, newTaskError(ErrorCodeUnknownTask, "unsupported task: %s", taskName)
 	}
 }
 
 // GetSupportedTasks implements the TaskExecutor interface
 func (e *SimpleTaskExecutor) GetSupportedTasks() []string {
-	return []string{"list_farms", "get_farm"}
+	definitions := e.GetTaskDefinitions()
+	names := make([]string, 0, len(definitions))
+	for _, def := range definitions {
+		names = append(names, def.Name)
+	}
+	return names
+}
+
+// GetTaskDefinitions implements the TaskExecutor interface.
+// Only the catalog entries this executor can simulate are returned.
+func (e *SimpleTaskExecutor) GetTaskDefinitions() []TaskDefinition {
+	var definitions []TaskDefinition
+	for _, def := range builtinTaskCatalog() {
+		switch def.Name {
+		case "list_farms", "get_farm":
+			definitions = append(definitions, def)
+		}
+	}
+	return definitions
 }
 
 // listFarms simulates the list_farms task
 func (e *SimpleTaskExecutor) listFarms(params map[string]interface{}) (interface{}, error) {
 	// This is a mock implementation
 	// In production, this would use the actual anubis-executer
-	
+
 	response := map[string]interface{}{
-		"farms": []map[string]interface{}{
+		"items": []map[string]interface{}{
 			{
-				"farmId": 1,
-				"name":   "Freefarm",
+				"farmId":            1,
+				"name":              "Freefarm",
 				"certificationType": "NotCertified",
-				"dedicated": false,
-				"pricingPolicyId": 1,
-				"stellarAddress": "GCIHPMKWFMP7OLU3ICJZN5AWLWVAKZNZIFPC6XKFMFDX5BLBA5KNVULR",
-				"twinId": 2,
+				"dedicated":         false,
+				"pricingPolicyId":   1,
+				"stellarAddress":    "GCIHPMKWFMP7OLU3ICJZN5AWLWVAKZNZIFPC6XKFMFDX5BLBA5KNVULR",
+				"twinId":            2,
 			},
 			{
-				"farmId": 2,
-				"name":   "MixNMatch",
+				"farmId":            2,
+				"name":              "MixNMatch",
 				"certificationType": "NotCertified",
-				"dedicated": false,
-				"pricingPolicyId": 1,
-				"stellarAddress": "GCZL3MUFKCHUH3PQPWAERMBYBGQXFYQXBU5ONFGJRTARFYGTLGFGOPCH",
-				"twinId": 8,
+				"dedicated":         false,
+				"pricingPolicyId":   1,
+				"stellarAddress":    "GCZL3MUFKCHUH3PQPWAERMBYBGQXFYQXBU5ONFGJRTARFYGTLGFGOPCH",
+				"twinId":            8,
 			},
 		},
 		"total_count": 2,
-		"page":       1,
-		"page_size":  5,
-		"network":    e.network,
+		"page":        1,
+		"page_size":   5,
+		"has_more":    false,
+		"network":     e.network,
 	}
-	
+
 	return response, nil
 }
 
@@ -110,9 +217,9 @@ func (e *SimpleTaskExecutor) listFarms(params map[string]interface{}) (interface
 func (e *SimpleTaskExecutor) getFarm(params map[string]interface{}) (interface{}, error) {
 	farmIDParam, exists := params["farm_id"]
 	if !exists {
-		return nil, fmt.Errorf("farm_id parameter is required")
+		return nil, &TaskError{Code: ErrorCodeInvalidParams, Field: "farm_id", Message: "farm_id parameter is required"}
 	}
-	
+
 	// Convert farm_id to int
 	var farmID int
 	switch v := farmIDParam.(type) {
@@ -120,20 +227,22 @@ func (e *SimpleTaskExecutor) getFarm(params map[string]interface{}) (interface{}
 		farmID = int(v)
 	case int:
 		farmID = v
+	case int64:
+		farmID = int(v)
 	default:
-		return nil, fmt.Errorf("invalid farm_id type")
+		return nil, &TaskError{Code: ErrorCodeInvalidParams, Field: "farm_id", Message: "invalid farm_id type"}
 	}
-	
+
 	// Mock response based on farm ID
 	if farmID == 1 {
 		return map[string]interface{}{
-			"farmId": 1,
-			"name":   "Freefarm",
+			"farmId":            1,
+			"name":              "Freefarm",
 			"certificationType": "NotCertified",
-			"dedicated": false,
-			"pricingPolicyId": 1,
-			"stellarAddress": "GCIHPMKWFMP7OLU3ICJZN5AWLWVAKZNZIFPC6XKFMFDX5BLBA5KNVULR",
-			"twinId": 2,
+			"dedicated":         false,
+			"pricingPolicyId":   1,
+			"stellarAddress":    "GCIHPMKWFMP7OLU3ICJZN5AWLWVAKZNZIFPC6XKFMFDX5BLBA5KNVULR",
+			"twinId":            2,
 			"publicIps": []map[string]interface{}{
 				{
 					"contract_id": 1230264,
@@ -144,6 +253,6 @@ func (e *SimpleTaskExecutor) getFarm(params map[string]interface{}) (interface{}
 			},
 		}, nil
 	}
-	
-	return nil, fmt.Errorf("farm with ID %d not found", farmID)
+
+	return nil, newTaskError(ErrorCodeNotFound, "farm with ID %d not found", farmID)
 }
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingExecutor never finishes a task before its context is done
+type blockingExecutor struct {
+	SimpleTaskExecutor
+}
+
+func (e *blockingExecutor) ExecuteTask(ctx context.Context, taskName string, params map[string]interface{}, caller *Caller) (interface{}, error) {
+	<-ctx.Done()
+	return nil, errors.New("failed to fetch farms: upstream closed")
+}
+
+func TestExecuteTaskTimeout(t *testing.T) {
+	executor = &blockingExecutor{}
+	taskTimeout = 10 * time.Millisecond
+	defer func() {
+		executor = nil
+		taskTimeout = 0
+	}()
+
+	_, err := ExecuteTask(context.Background(), "list_farms", nil, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "task list_farms did not complete")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ExecuteTask(ctx, "list_farms", nil, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,347 @@
+package executer
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"anubis-executer/shape"
+)
+
+// registerBuiltinTasks registers every task shipped with the executor.
+// New tasks are added here; ExecuteTask, GetSupportedTasks and the CLI
+// help all read from the registry.
+func registerBuiltinTasks(r *Registry) {
+	r.MustRegister(TaskDefinition{
+		Name:        "list_farms",
+		Description: "List ThreeFold farms with optional filtering and pagination",
+		Category:    "farms",
+		Version:     "1.0",
+		Params: Params(listParams(map[string]*ParamSpec{
+			"location": StringParam("Filter by country name or code (e.g. 'BE', 'Belgium')"),
+			"name":     StringParam("Filter by farm name using a case-insensitive contains search"),
+			"farm_id":  IntegerParam("Filter by a specific farm ID").WithMin(1),
+		})),
+		Example: map[string]interface{}{
+			"task_name": "list_farms",
+			"params":    map[string]interface{}{"page": 1, "location": "BE", "name": "freefarm"},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).listFarms),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "get_farm",
+		Description: "Get details of a specific ThreeFold farm including its public IPs",
+		Category:    "farms",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"farm_id": IntegerParam("The ID of the farm to retrieve").WithMin(1),
+		}, "farm_id"),
+		Example: map[string]interface{}{
+			"task_name": "get_farm",
+			"params":    map[string]interface{}{"farm_id": 1},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).getFarm),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "list_nodes",
+		Description: "List ThreeFold nodes filtered by status, location, free capacity and features",
+		Category:    "nodes",
+		Version:     "1.0",
+		Params: Params(listParams(map[string]*ParamSpec{
+			"status":      ArrayParam("Node statuses to include", StringParam("Node status").WithEnum("up", "down", "standby")),
+			"farm_ids":    ArrayParam("Only include nodes of these farms", IntegerParam("Farm ID").WithMin(1)),
+			"farm_name":   StringParam("Filter by farm name using a contains search"),
+			"country":     StringParam("Filter by country name (e.g. 'Belgium')"),
+			"city":        StringParam("Filter by city name"),
+			"free_cru":    IntegerParam("Minimum number of CPU cores (CPU is shared, so total cores are matched)").WithMin(0),
+			"free_mru_gb": IntegerParam("Minimum free memory in GB").WithMin(0),
+			"free_sru_gb": IntegerParam("Minimum free SSD storage in GB").WithMin(0),
+			"free_hru_gb": IntegerParam("Minimum free HDD storage in GB").WithMin(0),
+			"dedicated":   BooleanParam("Only dedicated (whole-node rental) nodes"),
+			"rentable":    BooleanParam("Only nodes that can currently be rented"),
+			"rented":      BooleanParam("Only nodes that are currently rented"),
+			"has_gpu":     BooleanParam("Only nodes with at least one GPU"),
+			"ipv4":        BooleanParam("Only nodes with a public IPv4 configuration"),
+			"domain":      BooleanParam("Only nodes with a public domain (gateway nodes)"),
+		})),
+		Example: map[string]interface{}{
+			"task_name": "list_nodes",
+			"params":    map[string]interface{}{"status": []string{"up"}, "country": "Belgium", "free_mru_gb": 8},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).listNodes),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "get_node",
+		Description: "Get details of a specific node: total vs. used capacity, uptime, certification and public config",
+		Category:    "nodes",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"node_id": IntegerParam("The ID of the node to retrieve").WithMin(1).WithMax(math.MaxUint32),
+		}, "node_id"),
+		Example: map[string]interface{}{
+			"task_name": "get_node",
+			"params":    map[string]interface{}{"node_id": 11},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).getNode),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "node_status",
+		Description: "Check whether a specific node is online",
+		Category:    "nodes",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"node_id": IntegerParam("The ID of the node to check").WithMin(1).WithMax(math.MaxUint32),
+		}, "node_id"),
+		Example: map[string]interface{}{
+			"task_name": "node_status",
+			"params":    map[string]interface{}{"node_id": 11},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).nodeStatus),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "list_contracts",
+		Description: "List contracts filtered by twin, node, type and state",
+		Category:    "contracts",
+		Version:     "1.0",
+		Params: Params(listParams(map[string]*ParamSpec{
+			"twin_id": IntegerParam("Only contracts owned by this twin; defaults to the caller's twin unless node_id is set").WithMin(1),
+			"node_id": IntegerParam("Only contracts on this node").WithMin(1).WithMax(math.MaxUint32),
+			"type":    StringParam("Contract type").WithEnum("node", "name", "rent"),
+			"state": ArrayParam("Contract states to include",
+				StringParam("Contract state").WithEnum("Created", "GracePeriod", "OutOfFunds", "Deleted")),
+		})),
+		Example: map[string]interface{}{
+			"task_name": "list_contracts",
+			"params":    map[string]interface{}{"twin_id": 42, "state": []string{"Created"}},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).listContracts),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "get_contract",
+		Description: "Get details of a specific contract",
+		Category:    "contracts",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"contract_id": IntegerParam("The ID of the contract to retrieve").WithMin(1).WithMax(math.MaxUint32),
+		}, "contract_id"),
+		Example: map[string]interface{}{
+			"task_name": "get_contract",
+			"params":    map[string]interface{}{"contract_id": 1234},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).getContract),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "contract_bills",
+		Description: "Page through a contract's billing history with billed totals in TFT",
+		Category:    "contracts",
+		Version:     "1.0",
+		Params: Params(listParams(map[string]*ParamSpec{
+			"contract_id":    IntegerParam("The ID of the contract").WithMin(1).WithMax(math.MaxUint32),
+			"include_totals": BooleanParam("Walk the whole billing history to compute the total billed").WithDefault(false),
+		}), "contract_id"),
+		Example: map[string]interface{}{
+			"task_name": "contract_bills",
+			"params":    map[string]interface{}{"contract_id": 1234, "page": 1},
+		},
+		Timeout: time.Minute, // walks every page for totals
+		Handler: TaskHandlerFunc((*TaskExecutor).contractBills),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "list_twins",
+		Description: "List twins (grid identities) filtered by twin ID, account ID and relay",
+		Category:    "twins",
+		Version:     "1.0",
+		Params: Params(listParams(map[string]*ParamSpec{
+			"twin_id":    IntegerParam("Filter by a specific twin ID").WithMin(1),
+			"account_id": StringParam("Filter by the twin's account (wallet) address"),
+			"relay":      StringParam("Filter by the twin's relay domain"),
+		})),
+		Example: map[string]interface{}{
+			"task_name": "list_twins",
+			"params":    map[string]interface{}{"relay": "relay.grid.tf"},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).listTwins),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "get_twin",
+		Description: "Get a twin by ID or account ID; defaults to the authenticated user's own twin",
+		Category:    "twins",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"twin_id":    IntegerParam("The ID of the twin to retrieve").WithMin(1),
+			"account_id": StringParam("The account (wallet) address of the twin to retrieve"),
+		}),
+		Example: map[string]interface{}{
+			"task_name": "get_twin",
+			"params":    map[string]interface{}{"twin_id": 42},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).getTwin),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "grid_stats",
+		Description: "Grid-wide node, farm, twin and contract counts with total vs. used capacity and a per-country node breakdown",
+		Category:    "stats",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"status":       ArrayParam("Only count nodes with these statuses", StringParam("Node status").WithEnum("up", "down", "standby")),
+			"include_used": BooleanParam("Walk the nodes to report used capacity (slower)").WithDefault(false),
+		}),
+		Example: map[string]interface{}{
+			"task_name": "grid_stats",
+			"params":    map[string]interface{}{"status": []string{"up"}, "include_used": true},
+		},
+		Timeout: time.Minute, // walks every page for totals
+		Handler: TaskHandlerFunc((*TaskExecutor).gridStats),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "list_public_ips",
+		Description: "Search public IPv4 addresses across farms by farm, free/used state, IP and gateway",
+		Category:    "farms",
+		Version:     "1.0",
+		Params: Params(listParams(map[string]*ParamSpec{
+			"farm_ids": ArrayParam("Only include IPs of these farms", IntegerParam("Farm ID").WithMin(1)),
+			"free":     BooleanParam("true for IPs not reserved by a contract, false for IPs in use"),
+			"ip":       StringParam("Filter by IP address in CIDR notation (e.g. '185.69.167.209/24')"),
+			"gateway":  StringParam("Filter by gateway address"),
+		})),
+		Example: map[string]interface{}{
+			"task_name": "list_public_ips",
+			"params":    map[string]interface{}{"farm_ids": []int{1}, "free": true},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).listPublicIPs),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "find_capacity",
+		Description: "Find and rank up nodes that can host a workload, scored by headroom left after deployment, uptime and certification, with the reasons for each match",
+		Category:    "nodes",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"cpu":            IntegerParam("Virtual CPU cores the workload needs").WithMin(1),
+			"memory_gb":      IntegerParam("Memory the workload needs in GB").WithMin(1),
+			"ssd_gb":         IntegerParam("SSD storage the workload needs in GB").WithMin(0),
+			"hdd_gb":         IntegerParam("HDD storage the workload needs in GB").WithMin(0),
+			"public_ipv4":    BooleanParam("The workload needs a public IPv4 from the node's farm"),
+			"gpu":            BooleanParam("The workload needs a GPU"),
+			"country":        StringParam("Only nodes in this country (e.g. 'Belgium')"),
+			"region":         StringParam("Only nodes in this region (e.g. 'Europe')"),
+			"certified_only": BooleanParam("Only certified nodes"),
+			"limit":          IntegerParam("Number of candidates to return").WithMin(1).WithMax(capacityPoolSize).WithDefault(defaultCandidates),
+		}, "cpu", "memory_gb"),
+		Example: map[string]interface{}{
+			"task_name": "find_capacity",
+			"params":    map[string]interface{}{"cpu": 4, "memory_gb": 8, "ssd_gb": 50, "region": "Europe"},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).findCapacity),
+	})
+
+	metrics := make([]interface{}, 0, len(aggregateMetrics))
+	for _, name := range aggregateMetricNames() {
+		metrics = append(metrics, name)
+	}
+	r.MustRegister(TaskDefinition{
+		Name:        "aggregate",
+		Description: "Group nodes or farms by country, farm, certification or status and sum their total, used and free capacity with averages and utilization, walking every page",
+		Category:    "stats",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"entity":    StringParam("What to count in each group; farms are counted through their nodes").WithEnum(aggregateEntities...).WithDefault("nodes"),
+			"group_by":  StringParam("How to group; farms cannot be grouped by status").WithEnum(aggregateGroupBys...),
+			"status":    ArrayParam("Only include nodes with these statuses", StringParam("Node status").WithEnum("up", "down", "standby")),
+			"country":   StringParam("Only include nodes in this country"),
+			"farm_ids":  ArrayParam("Only include these farms", IntegerParam("Farm ID").WithMin(1)),
+			"certified": BooleanParam("Only certified nodes, or certified farms when counting farms"),
+			"sort_by":   StringParam("Group value to sort by").WithEnum(metrics...).WithDefault("count"),
+			"order":     StringParam("Sort order").WithEnum("asc", "desc").WithDefault("desc"),
+			"limit":     IntegerParam("Number of groups to return").WithMin(1).WithMax(MaxListItems).WithDefault(defaultAggregateGroups),
+		}, "group_by"),
+		Example: map[string]interface{}{
+			"task_name": "aggregate",
+			"params":    map[string]interface{}{"entity": "nodes", "group_by": "country", "status": []string{"up"}, "sort_by": "free_sru_gb"},
+		},
+		Timeout: 2 * time.Minute, // walks every page of nodes and farms
+		Handler: TaskHandlerFunc((*TaskExecutor).aggregate),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "plan_vm_deployment",
+		Description: "Plan a VM deployment without submitting it: pick a node that fits, validate its resources and return the full deployment (image, resources, network, disks, IPs, SSH key) ready for signing",
+		Category:    "deployments",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"name":           StringParam("VM name: letters, digits and underscores"),
+			"cpu":            IntegerParam("Virtual CPU cores").WithMin(1).WithMax(32),
+			"memory_gb":      IntegerParam("Memory in GB").WithMin(1).WithMax(256),
+			"rootfs_gb":      IntegerParam("Root filesystem size in GB").WithMin(1).WithDefault(2),
+			"disk_gb":        IntegerParam("Size of a data disk mounted at /data in GB; 0 for none").WithMin(0),
+			"image":          StringParam("Official VM image").WithEnum("ubuntu-22.04", "alpine", "nixos").WithDefault("ubuntu-22.04"),
+			"flist":          StringParam("Custom image flist URL, instead of image"),
+			"entrypoint":     StringParam("Entrypoint of a custom flist").WithDefault("/sbin/zinit init"),
+			"public_ipv4":    BooleanParam("Give the VM a public IPv4 from the node's farm"),
+			"public_ipv6":    BooleanParam("Give the VM a public IPv6"),
+			"mycelium":       BooleanParam("Connect the VM to the Mycelium network").WithDefault(true),
+			"ssh_key":        StringParam("SSH public key; a placeholder is used when it is left out"),
+			"node_id":        IntegerParam("Deploy on this node instead of searching for one").WithMin(1),
+			"gpu":            BooleanParam("The VM needs a GPU"),
+			"country":        StringParam("Only consider nodes in this country"),
+			"region":         StringParam("Only consider nodes in this region (e.g. 'Europe')"),
+			"certified_only": BooleanParam("Only consider certified nodes"),
+		}, "name", "cpu", "memory_gb"),
+		Example: map[string]interface{}{
+			"task_name": "plan_vm_deployment",
+			"params":    map[string]interface{}{"name": "web", "cpu": 4, "memory_gb": 8, "disk_gb": 50, "region": "Europe", "public_ipv4": true},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).planVMDeployment),
+	})
+
+	r.MustRegister(TaskDefinition{
+		Name:        "estimate_cost",
+		Description: "Estimate the monthly cost of a deployment in USD and TFT from the farm's pricing policy, with the discounts for holding TFT",
+		Category:    "deployments",
+		Version:     "1.0",
+		Params: Params(map[string]*ParamSpec{
+			"cpu":            IntegerParam("Virtual CPU cores").WithMin(0),
+			"memory_gb":      IntegerParam("Memory in GB").WithMin(0),
+			"ssd_gb":         IntegerParam("SSD storage in GB").WithMin(0),
+			"hdd_gb":         IntegerParam("HDD storage in GB").WithMin(0),
+			"public_ips":     IntegerParam("Public IPv4 addresses").WithMin(0),
+			"name_contracts": IntegerParam("Name contracts, e.g. for gateway names").WithMin(0),
+			"dedicated":      BooleanParam("Rent the whole node; its full capacity is billed at the dedication discount"),
+			"node_id":        IntegerParam("Node the deployment runs on; required when dedicated").WithMin(1),
+			"farm_id":        IntegerParam("Farm whose pricing policy applies, when no node is given").WithMin(1),
+			"tft_price":      NumberParam("TFT price in USD, to convert costs to TFT").WithMin(0),
+			"balance_tft":    NumberParam("TFT held by the twin, to pick its discount tier; needs tft_price").WithMin(0),
+		}),
+		Example: map[string]interface{}{
+			"task_name": "estimate_cost",
+			"params":    map[string]interface{}{"cpu": 4, "memory_gb": 8, "ssd_gb": 50, "public_ips": 1, "node_id": 11, "tft_price": 0.01},
+		},
+		Handler: TaskHandlerFunc((*TaskExecutor).estimateCost),
+	})
+}
+
+// listParams adds the pagination and result shaping parameters shared by
+// every list task to props
+func listParams(props map[string]*ParamSpec) map[string]*ParamSpec {
+	props["page"] = IntegerParam("Page number for pagination").WithMin(1).WithMax(1000).WithDefault(1)
+	props["page_size"] = IntegerParam("Number of items per page").WithMin(1).WithMax(MaxPageSize).WithDefault(defaultPageSize)
+	props["all"] = BooleanParam(fmt.Sprintf("Walk every page and return all items, up to %d", MaxListItems))
+	props["max_items"] = IntegerParam("Walk pages until this many items are collected").WithMin(1).WithMax(MaxListItems)
+	props["fields"] = ArrayParam("Only return these item fields; nested fields use dots (e.g. 'total_resources.cru')", StringParam("Field name"))
+	props["sort_by"] = StringParam("Sort the returned items by this field")
+	props["order"] = StringParam("Sort order for sort_by").WithEnum("asc", "desc").WithDefault("asc")
+	props["limit"] = IntegerParam("Return at most this many items, after sorting").WithMin(1).WithMax(MaxListItems)
+	props["format"] = StringParam("full returns items as JSON, compact as a CSV table and markdown as a bullet list, both under text").WithEnum(shape.FormatFull, shape.FormatCompact, shape.FormatMarkdown).WithDefault(shape.FormatFull)
+	return props
+}
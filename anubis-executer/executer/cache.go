@@ -0,0 +1,364 @@
+package executer
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// Cache modes accepted in a task's "cache" field
+const (
+	CacheDefault = ""       // Use the cache
+	CacheBypass  = "bypass" // Always call GridProxy, without reading or filling the cache
+)
+
+// CacheConfig configures the GridProxy response cache
+type CacheConfig struct {
+	MaxEntries int                      // Entries kept before the least recently used is evicted
+	DefaultTTL time.Duration            // TTL for methods without an entry in TTLs
+	TTLs       map[string]time.Duration // Per-method TTLs keyed by client method name, e.g. "Farms"
+}
+
+// DefaultCacheConfig returns TTLs tuned to how quickly each kind of grid data changes
+func DefaultCacheConfig() CacheConfig {
+	return CacheConfig{
+		MaxEntries: 1000,
+		DefaultTTL: time.Minute,
+		TTLs: map[string]time.Duration{
+			"Farms":         5 * time.Minute,
+			"Twins":         10 * time.Minute,
+			"Stats":         5 * time.Minute,
+			"ContractBills": 5 * time.Minute,
+			"Nodes":         time.Minute,
+			"Node":          time.Minute,
+			"NodeStatus":    30 * time.Second,
+			"Contracts":     time.Minute,
+			"Contract":      time.Minute,
+			"PublicIps":     time.Minute,
+		},
+	}
+}
+
+// CachingClient is a client.Client decorator that caches GridProxy responses,
+// keyed on method and arguments, with per-method TTLs and LRU eviction.
+// Responses are stored encoded, so every hit returns a copy that callers may
+// modify freely. Errors are never cached.
+type CachingClient struct {
+	next   client.Client
+	config CacheConfig
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Front is most recently used
+}
+
+// cacheEntry is a single cached response, JSON encoded
+type cacheEntry struct {
+	key     string
+	method  string
+	data    []byte
+	expires time.Time
+}
+
+// listPage is a cached response of a paginated list call
+type listPage[T any, N any] struct {
+	Items []T `json:"items"`
+	Count N   `json:"count"`
+}
+
+// NewCachingClient wraps next with a response cache
+func NewCachingClient(next client.Client, config CacheConfig) *CachingClient {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = DefaultCacheConfig().MaxEntries
+	}
+	return &CachingClient{
+		next:    next,
+		config:  config,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Invalidate drops cached responses of the given methods, or every response when none are given
+func (c *CachingClient) Invalidate(methods ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(methods) == 0 {
+		c.entries = make(map[string]*list.Element)
+		c.lru.Init()
+		return
+	}
+
+	for key, element := range c.entries {
+		for _, method := range methods {
+			if element.Value.(*cacheEntry).method == method {
+				c.lru.Remove(element)
+				delete(c.entries, key)
+				break
+			}
+		}
+	}
+}
+
+// Len returns the number of cached responses
+func (c *CachingClient) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// ttl returns the TTL configured for method
+func (c *CachingClient) ttl(method string) time.Duration {
+	if ttl, ok := c.config.TTLs[method]; ok {
+		return ttl
+	}
+	return c.config.DefaultTTL
+}
+
+// get returns the live cached response for key, evicting it when expired
+func (c *CachingClient) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.lru.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(element)
+	return entry.data, true
+}
+
+// put stores an encoded response under key, evicting the least recently used entries beyond MaxEntries
+func (c *CachingClient) put(key, method string, data []byte) {
+	ttl := c.ttl(method)
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, method: method, data: data, expires: c.now().Add(ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.config.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey builds the cache key of a call from its method and arguments
+func cacheKey(method string, args ...interface{}) string {
+	parts := []string{method}
+	for _, arg := range args {
+		data, _ := json.Marshal(arg)
+		parts = append(parts, string(data))
+	}
+	return strings.Join(parts, "|")
+}
+
+// cached returns a copy of the cached response of a call, or calls fetch and
+// caches its result. GridProxy responses are JSON, so they survive the round trip.
+func cached[T any](c *CachingClient, ctx context.Context, method string, fetch func() (T, error), args ...interface{}) (T, error) {
+	if cacheBypassed(ctx) {
+		recordCache(ctx, false)
+		return fetch()
+	}
+
+	key := cacheKey(method, args...)
+	if data, ok := c.get(key); ok {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			recordCache(ctx, true)
+			return value, nil
+		}
+	}
+
+	recordCache(ctx, false)
+	value, err := fetch()
+	if err == nil {
+		if data, encodeErr := json.Marshal(value); encodeErr == nil {
+			c.put(key, method, data)
+		}
+	}
+	return value, err
+}
+
+// Ping implements client.Client and is never cached
+func (c *CachingClient) Ping() error {
+	return c.next.Ping()
+}
+
+// Farms implements client.Client
+func (c *CachingClient) Farms(ctx context.Context, filter types.FarmFilter, limit types.Limit) ([]types.Farm, int, error) {
+	page, err := cached(c, ctx, "Farms", func() (listPage[types.Farm, int], error) {
+		items, count, err := c.next.Farms(ctx, filter, limit)
+		return listPage[types.Farm, int]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Nodes implements client.Client
+func (c *CachingClient) Nodes(ctx context.Context, filter types.NodeFilter, limit types.Limit) ([]types.Node, int, error) {
+	page, err := cached(c, ctx, "Nodes", func() (listPage[types.Node, int], error) {
+		items, count, err := c.next.Nodes(ctx, filter, limit)
+		return listPage[types.Node, int]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Contracts implements client.Client
+func (c *CachingClient) Contracts(ctx context.Context, filter types.ContractFilter, limit types.Limit) ([]types.Contract, int, error) {
+	page, err := cached(c, ctx, "Contracts", func() (listPage[types.Contract, int], error) {
+		items, count, err := c.next.Contracts(ctx, filter, limit)
+		return listPage[types.Contract, int]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Contract implements client.Client
+func (c *CachingClient) Contract(ctx context.Context, contractID uint32) (types.Contract, error) {
+	return cached(c, ctx, "Contract", func() (types.Contract, error) {
+		return c.next.Contract(ctx, contractID)
+	}, contractID)
+}
+
+// ContractBills implements client.Client
+func (c *CachingClient) ContractBills(ctx context.Context, contractID uint32, limit types.Limit) ([]types.ContractBilling, uint, error) {
+	page, err := cached(c, ctx, "ContractBills", func() (listPage[types.ContractBilling, uint], error) {
+		items, count, err := c.next.ContractBills(ctx, contractID, limit)
+		return listPage[types.ContractBilling, uint]{items, count}, err
+	}, contractID, limit)
+	return page.Items, page.Count, err
+}
+
+// Twins implements client.Client
+func (c *CachingClient) Twins(ctx context.Context, filter types.TwinFilter, limit types.Limit) ([]types.Twin, int, error) {
+	page, err := cached(c, ctx, "Twins", func() (listPage[types.Twin, int], error) {
+		items, count, err := c.next.Twins(ctx, filter, limit)
+		return listPage[types.Twin, int]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Node implements client.Client
+func (c *CachingClient) Node(ctx context.Context, nodeID uint32) (types.NodeWithNestedCapacity, error) {
+	return cached(c, ctx, "Node", func() (types.NodeWithNestedCapacity, error) {
+		return c.next.Node(ctx, nodeID)
+	}, nodeID)
+}
+
+// NodeStatus implements client.Client
+func (c *CachingClient) NodeStatus(ctx context.Context, nodeID uint32) (types.NodeStatus, error) {
+	return cached(c, ctx, "NodeStatus", func() (types.NodeStatus, error) {
+		return c.next.NodeStatus(ctx, nodeID)
+	}, nodeID)
+}
+
+// Stats implements client.Client
+func (c *CachingClient) Stats(ctx context.Context, filter types.StatsFilter) (types.Stats, error) {
+	return cached(c, ctx, "Stats", func() (types.Stats, error) {
+		return c.next.Stats(ctx, filter)
+	}, filter)
+}
+
+// PublicIps implements client.Client
+func (c *CachingClient) PublicIps(ctx context.Context, filter types.PublicIpFilter, limit types.Limit) ([]types.PublicIP, uint, error) {
+	page, err := cached(c, ctx, "PublicIps", func() (listPage[types.PublicIP, uint], error) {
+		items, count, err := c.next.PublicIps(ctx, filter, limit)
+		return listPage[types.PublicIP, uint]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// CacheMetadata reports how a task's GridProxy calls were served
+type CacheMetadata struct {
+	Status string `json:"status"` // hit, miss, partial or bypass
+	Hits   int64  `json:"hits"`
+	Misses int64  `json:"misses"`
+}
+
+// cacheRecorder counts cache hits and misses of the calls made under a context
+type cacheRecorder struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+type cacheRecorderKey struct{}
+
+type cacheBypassKey struct{}
+
+// withCacheRecorder returns a context that records cache hits and misses
+func withCacheRecorder(ctx context.Context) (context.Context, *cacheRecorder) {
+	recorder := &cacheRecorder{}
+	return context.WithValue(ctx, cacheRecorderKey{}, recorder), recorder
+}
+
+// withCacheBypass returns a context under which the cache is skipped
+func withCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// cacheBypassed reports whether ctx asks to skip the cache
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// recordCache counts a hit or miss on the recorder of ctx, if any
+func recordCache(ctx context.Context, hit bool) {
+	recorder, ok := ctx.Value(cacheRecorderKey{}).(*cacheRecorder)
+	if !ok {
+		return
+	}
+	if hit {
+		recorder.hits.Add(1)
+	} else {
+		recorder.misses.Add(1)
+	}
+}
+
+// metadata summarizes the recorded calls, or returns nil when no cached call was made
+func (r *cacheRecorder) metadata(bypass bool) *CacheMetadata {
+	hits, misses := r.hits.Load(), r.misses.Load()
+	if hits+misses == 0 {
+		return nil
+	}
+
+	meta := &CacheMetadata{Hits: hits, Misses: misses}
+	switch {
+	case bypass:
+		meta.Status = "bypass"
+	case misses == 0:
+		meta.Status = "hit"
+	case hits == 0:
+		meta.Status = "miss"
+	default:
+		meta.Status = "partial"
+	}
+	return meta
+}
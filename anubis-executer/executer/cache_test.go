@@ -0,0 +1,194 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// countingClient counts the calls that reach the wrapped mock
+type countingClient struct {
+	*MockGridClient
+	farmCalls int
+	nodeCalls int
+}
+
+func (c *countingClient) Farms(ctx context.Context, filter types.FarmFilter, limit types.Limit) ([]types.Farm, int, error) {
+	c.farmCalls++
+	return c.MockGridClient.Farms(ctx, filter, limit)
+}
+
+func (c *countingClient) Node(ctx context.Context, nodeID uint32) (types.NodeWithNestedCapacity, error) {
+	c.nodeCalls++
+	return c.MockGridClient.Node(ctx, nodeID)
+}
+
+func newCountingClient() *countingClient {
+	return &countingClient{MockGridClient: &MockGridClient{
+		farms: []types.Farm{{FarmID: 1, Name: "Freefarm"}, {FarmID: 2, Name: "MixNMatch"}},
+		nodes: []types.Node{{NodeID: 11, FarmID: 1}, {NodeID: 12, FarmID: 1}, {NodeID: 13, FarmID: 2}},
+	}}
+}
+
+func TestCachingClientHitsAndKeys(t *testing.T) {
+	upstream := newCountingClient()
+	cache := NewCachingClient(upstream, DefaultCacheConfig())
+	ctx := context.Background()
+	limit := types.Limit{Size: 5, Page: 1}
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := cache.Farms(ctx, types.FarmFilter{}, limit); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if upstream.farmCalls != 1 {
+		t.Errorf("expected repeated calls to be served from cache, upstream saw %d", upstream.farmCalls)
+	}
+
+	farmID := uint64(2)
+	farms, _, _ := cache.Farms(ctx, types.FarmFilter{FarmID: &farmID}, limit)
+	if upstream.farmCalls != 2 || len(farms) != 1 || farms[0].FarmID != 2 {
+		t.Errorf("expected a different filter to miss the cache, got %v after %d calls", farms, upstream.farmCalls)
+	}
+
+	cache.Invalidate("Farms")
+	cache.Farms(ctx, types.FarmFilter{}, limit)
+	if upstream.farmCalls != 3 {
+		t.Errorf("expected invalidation to force a refetch, upstream saw %d", upstream.farmCalls)
+	}
+}
+
+func TestCachingClientHitsAreCopies(t *testing.T) {
+	upstream := newCountingClient()
+	upstream.farms[0].PublicIps = []types.PublicIP{{IP: "185.206.122.33/24"}}
+	cache := NewCachingClient(upstream, DefaultCacheConfig())
+	ctx := context.Background()
+	limit := types.Limit{Size: 5, Page: 1}
+
+	cache.Farms(ctx, types.FarmFilter{}, limit)
+	farms, _, _ := cache.Farms(ctx, types.FarmFilter{}, limit)
+	farms[0].Name = "changed"
+	farms[0].PublicIps[0].IP = "changed"
+	farms = append(farms[:1], farms[2:]...)
+
+	farms, _, err := cache.Farms(ctx, types.FarmFilter{}, limit)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if upstream.farmCalls != 1 {
+		t.Errorf("expected the reads to be served from cache, upstream saw %d calls", upstream.farmCalls)
+	}
+	if len(farms) != 2 || farms[0].Name != "Freefarm" || farms[0].PublicIps[0].IP != "185.206.122.33/24" || farms[1].Name != "MixNMatch" {
+		t.Errorf("expected changes to a hit not to reach the cache, got %+v", farms)
+	}
+}
+
+func TestCachingClientExpiryAndEviction(t *testing.T) {
+	upstream := newCountingClient()
+	cache := NewCachingClient(upstream, CacheConfig{
+		MaxEntries: 2,
+		TTLs:       map[string]time.Duration{"Node": time.Minute},
+	})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	cache.Node(ctx, 11)
+	now = now.Add(59 * time.Second)
+	cache.Node(ctx, 11)
+	if upstream.nodeCalls != 1 {
+		t.Errorf("expected entry to live for its TTL, upstream saw %d calls", upstream.nodeCalls)
+	}
+
+	now = now.Add(time.Second)
+	cache.Node(ctx, 11)
+	if upstream.nodeCalls != 2 {
+		t.Errorf("expected entry to expire after its TTL, upstream saw %d calls", upstream.nodeCalls)
+	}
+
+	// Node 11 is the least recently used once 12 and 13 are added
+	cache.Node(ctx, 12)
+	cache.Node(ctx, 13)
+	if cache.Len() != 2 {
+		t.Errorf("expected cache to hold at most 2 entries, got %d", cache.Len())
+	}
+	cache.Node(ctx, 11)
+	if upstream.nodeCalls != 5 {
+		t.Errorf("expected evicted entry to be refetched, upstream saw %d calls", upstream.nodeCalls)
+	}
+
+	// Farms has no TTL and no default, so it is not cached
+	cache.Farms(ctx, types.FarmFilter{}, types.Limit{Size: 5, Page: 1})
+	cache.Farms(ctx, types.FarmFilter{}, types.Limit{Size: 5, Page: 1})
+	if upstream.farmCalls != 2 {
+		t.Errorf("expected methods without a TTL to skip the cache, upstream saw %d calls", upstream.farmCalls)
+	}
+}
+
+func TestCachingClientDoesNotCacheErrors(t *testing.T) {
+	upstream := newCountingClient()
+	upstream.err = errors.New("API error")
+	cache := NewCachingClient(upstream, DefaultCacheConfig())
+
+	cache.Farms(context.Background(), types.FarmFilter{}, types.Limit{Size: 5, Page: 1})
+	upstream.err = nil
+	farms, _, err := cache.Farms(context.Background(), types.FarmFilter{}, types.Limit{Size: 5, Page: 1})
+	if err != nil || len(farms) != 2 || upstream.farmCalls != 2 {
+		t.Errorf("expected error not to be cached, got %v, %v after %d calls", farms, err, upstream.farmCalls)
+	}
+}
+
+func TestExecuteTaskJSONCacheMetadata(t *testing.T) {
+	upstream := newCountingClient()
+	cache := NewCachingClient(upstream, DefaultCacheConfig())
+	executor := &TaskExecutor{gridClient: cache, network: "test", cache: cache}
+
+	tests := []struct {
+		name           string
+		taskJSON       string
+		expectedStatus string
+		expectedCalls  int
+	}{
+		{"first call misses", `{"task_name": "list_farms", "params": {}}`, "miss", 1},
+		{"repeated call hits", `{"task_name": "list_farms", "params": {}}`, "hit", 1},
+		{"bypass skips the cache", `{"task_name": "list_farms", "params": {}, "cache": "bypass"}`, "bypass", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responseJSON, err := executor.ExecuteTaskJSON(context.Background(), []byte(tt.taskJSON))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var response TaskResponse
+			if err := json.Unmarshal(responseJSON, &response); err != nil {
+				t.Fatalf("failed to parse response: %v", err)
+			}
+
+			if response.Metadata == nil || response.Metadata.Cache == nil {
+				t.Fatalf("expected cache metadata, got %s", responseJSON)
+			}
+			if response.Metadata.Cache.Status != tt.expectedStatus {
+				t.Errorf("expected cache status %s, got %s", tt.expectedStatus, response.Metadata.Cache.Status)
+			}
+			if upstream.farmCalls != tt.expectedCalls {
+				t.Errorf("expected %d upstream calls, got %d", tt.expectedCalls, upstream.farmCalls)
+			}
+		})
+	}
+
+	executor.InvalidateCache()
+	if cache.Len() != 0 {
+		t.Errorf("expected InvalidateCache to empty the cache, %d entries left", cache.Len())
+	}
+
+	if _, err := executor.ExecuteTask(context.Background(), Task{TaskName: "list_farms", Cache: "always"}); err == nil ||
+		err.Error() != "cache must be one of [bypass], got: always" {
+		t.Errorf("expected invalid cache mode error, got %v", err)
+	}
+}
@@ -0,0 +1,367 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// CassetteEnv is the environment variable selecting the cassette mode
+const CassetteEnv = "GRIDPROXY_CASSETTE"
+
+// Cassette modes accepted in CassetteEnv
+const (
+	CassetteOff    = ""       // Use the live client
+	CassetteRecord = "record" // Use the live client and save its responses
+	CassetteReplay = "replay" // Serve saved responses without network access
+)
+
+// Cassette is a recording of GridProxy calls and their responses
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded client call
+type Interaction struct {
+	Method   string            `json:"method"`
+	Args     []json.RawMessage `json:"args,omitempty"`
+	Response json.RawMessage   `json:"response,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// key identifies the call of an interaction, as cacheKey does for live calls
+func (i Interaction) key() string {
+	args := make([]interface{}, len(i.Args))
+	for n, arg := range i.Args {
+		args[n] = arg
+	}
+	return cacheKey(i.Method, args...)
+}
+
+// CassetteMode returns the cassette mode set in CassetteEnv
+func CassetteMode() (string, error) {
+	mode := os.Getenv(CassetteEnv)
+	switch mode {
+	case CassetteOff, CassetteRecord, CassetteReplay:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%s must be one of [%s, %s], got: %s", CassetteEnv, CassetteRecord, CassetteReplay, mode)
+	}
+}
+
+// NewCassetteClient returns the client for the cassette mode set in
+// CassetteEnv: live itself, a RecordingClient saving live's responses to
+// path, or a ReplayingClient serving the responses saved in path
+func NewCassetteClient(path string, live client.Client) (client.Client, error) {
+	mode, err := CassetteMode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch mode {
+	case CassetteRecord:
+		return NewRecordingClient(live, path), nil
+	case CassetteReplay:
+		return LoadReplayingClient(path)
+	default:
+		return live, nil
+	}
+}
+
+// RecordingClient is a client.Client decorator that saves every call and
+// its response to a cassette file. The file is rewritten after every call
+// so an interrupted run keeps what it recorded.
+type RecordingClient struct {
+	next client.Client
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecordingClient wraps next, recording to the cassette at path
+func NewRecordingClient(next client.Client, path string) *RecordingClient {
+	return &RecordingClient{next: next, path: path}
+}
+
+// add records a call and saves the cassette
+func (c *RecordingClient) add(method string, response interface{}, callErr error, args ...interface{}) error {
+	interaction := Interaction{Method: method}
+	for _, arg := range args {
+		data, err := json.Marshal(arg)
+		if err != nil {
+			return fmt.Errorf("failed to record %s arguments: %v", method, err)
+		}
+		interaction.Args = append(interaction.Args, data)
+	}
+
+	if callErr != nil {
+		interaction.Error = callErr.Error()
+	} else {
+		data, err := json.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("failed to record %s response: %v", method, err)
+		}
+		interaction.Response = data
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cassette.Interactions = append(c.cassette.Interactions, interaction)
+	data, err := json.MarshalIndent(c.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %v", err)
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to save cassette: %v", err)
+	}
+	return nil
+}
+
+// record makes a live call and records it. A failure to save the cassette
+// is returned in place of the call's own result, so recordings never
+// silently miss calls.
+func record[T any](c *RecordingClient, method string, call func() (T, error), args ...interface{}) (T, error) {
+	value, err := call()
+	if recordErr := c.add(method, value, err, args...); recordErr != nil {
+		return value, recordErr
+	}
+	return value, err
+}
+
+// Ping implements client.Client
+func (c *RecordingClient) Ping() error {
+	_, err := record(c, "Ping", func() (struct{}, error) {
+		return struct{}{}, c.next.Ping()
+	})
+	return err
+}
+
+// Farms implements client.Client
+func (c *RecordingClient) Farms(ctx context.Context, filter types.FarmFilter, limit types.Limit) ([]types.Farm, int, error) {
+	page, err := record(c, "Farms", func() (listPage[types.Farm, int], error) {
+		items, count, err := c.next.Farms(ctx, filter, limit)
+		return listPage[types.Farm, int]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Nodes implements client.Client
+func (c *RecordingClient) Nodes(ctx context.Context, filter types.NodeFilter, limit types.Limit) ([]types.Node, int, error) {
+	page, err := record(c, "Nodes", func() (listPage[types.Node, int], error) {
+		items, count, err := c.next.Nodes(ctx, filter, limit)
+		return listPage[types.Node, int]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Contracts implements client.Client
+func (c *RecordingClient) Contracts(ctx context.Context, filter types.ContractFilter, limit types.Limit) ([]types.Contract, int, error) {
+	page, err := record(c, "Contracts", func() (listPage[types.Contract, int], error) {
+		items, count, err := c.next.Contracts(ctx, filter, limit)
+		return listPage[types.Contract, int]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Contract implements client.Client
+func (c *RecordingClient) Contract(ctx context.Context, contractID uint32) (types.Contract, error) {
+	return record(c, "Contract", func() (types.Contract, error) {
+		return c.next.Contract(ctx, contractID)
+	}, contractID)
+}
+
+// ContractBills implements client.Client
+func (c *RecordingClient) ContractBills(ctx context.Context, contractID uint32, limit types.Limit) ([]types.ContractBilling, uint, error) {
+	page, err := record(c, "ContractBills", func() (listPage[types.ContractBilling, uint], error) {
+		items, count, err := c.next.ContractBills(ctx, contractID, limit)
+		return listPage[types.ContractBilling, uint]{items, count}, err
+	}, contractID, limit)
+	return page.Items, page.Count, err
+}
+
+// Twins implements client.Client
+func (c *RecordingClient) Twins(ctx context.Context, filter types.TwinFilter, limit types.Limit) ([]types.Twin, int, error) {
+	page, err := record(c, "Twins", func() (listPage[types.Twin, int], error) {
+		items, count, err := c.next.Twins(ctx, filter, limit)
+		return listPage[types.Twin, int]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Node implements client.Client
+func (c *RecordingClient) Node(ctx context.Context, nodeID uint32) (types.NodeWithNestedCapacity, error) {
+	return record(c, "Node", func() (types.NodeWithNestedCapacity, error) {
+		return c.next.Node(ctx, nodeID)
+	}, nodeID)
+}
+
+// NodeStatus implements client.Client
+func (c *RecordingClient) NodeStatus(ctx context.Context, nodeID uint32) (types.NodeStatus, error) {
+	return record(c, "NodeStatus", func() (types.NodeStatus, error) {
+		return c.next.NodeStatus(ctx, nodeID)
+	}, nodeID)
+}
+
+// Stats implements client.Client
+func (c *RecordingClient) Stats(ctx context.Context, filter types.StatsFilter) (types.Stats, error) {
+	return record(c, "Stats", func() (types.Stats, error) {
+		return c.next.Stats(ctx, filter)
+	}, filter)
+}
+
+// PublicIps implements client.Client
+func (c *RecordingClient) PublicIps(ctx context.Context, filter types.PublicIpFilter, limit types.Limit) ([]types.PublicIP, uint, error) {
+	page, err := record(c, "PublicIps", func() (listPage[types.PublicIP, uint], error) {
+		items, count, err := c.next.PublicIps(ctx, filter, limit)
+		return listPage[types.PublicIP, uint]{items, count}, err
+	}, filter, limit)
+	return page.Items, page.Count, err
+}
+
+// ReplayingClient is a client.Client serving the responses of a cassette.
+// Calls are matched on method and arguments; repeated calls get the
+// recorded responses in order, then the last one again.
+type ReplayingClient struct {
+	path string
+
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	played       map[string]int
+}
+
+// LoadReplayingClient loads the cassette at path
+func LoadReplayingClient(path string) (*ReplayingClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %v", path, err)
+	}
+
+	return NewReplayingClient(path, cassette), nil
+}
+
+// NewReplayingClient serves the responses of cassette; path is only used in errors
+func NewReplayingClient(path string, cassette Cassette) *ReplayingClient {
+	c := &ReplayingClient{
+		path:         path,
+		interactions: make(map[string][]Interaction),
+		played:       make(map[string]int),
+	}
+	for _, interaction := range cassette.Interactions {
+		key := interaction.key()
+		c.interactions[key] = append(c.interactions[key], interaction)
+	}
+	return c
+}
+
+// next returns the next recorded interaction of a call
+func (c *ReplayingClient) next(method string, args ...interface{}) (Interaction, error) {
+	key := cacheKey(method, args...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	recorded := c.interactions[key]
+	if len(recorded) == 0 {
+		return Interaction{}, fmt.Errorf("cassette %s has no recorded response for %s", c.path, key)
+	}
+
+	played := c.played[key]
+	c.played[key] = played + 1
+	return recorded[min(played, len(recorded)-1)], nil
+}
+
+// replay returns the recorded response of a call
+func replay[T any](c *ReplayingClient, method string, args ...interface{}) (T, error) {
+	var value T
+	interaction, err := c.next(method, args...)
+	if err != nil {
+		return value, err
+	}
+
+	if interaction.Error != "" {
+		return value, errors.New(interaction.Error)
+	}
+	if err := json.Unmarshal(interaction.Response, &value); err != nil {
+		return value, fmt.Errorf("failed to decode recorded %s response: %v", method, err)
+	}
+	return value, nil
+}
+
+// Ping implements client.Client
+func (c *ReplayingClient) Ping() error {
+	_, err := replay[struct{}](c, "Ping")
+	return err
+}
+
+// Farms implements client.Client
+func (c *ReplayingClient) Farms(ctx context.Context, filter types.FarmFilter, limit types.Limit) ([]types.Farm, int, error) {
+	page, err := replay[listPage[types.Farm, int]](c, "Farms", filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Nodes implements client.Client
+func (c *ReplayingClient) Nodes(ctx context.Context, filter types.NodeFilter, limit types.Limit) ([]types.Node, int, error) {
+	page, err := replay[listPage[types.Node, int]](c, "Nodes", filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Contracts implements client.Client
+func (c *ReplayingClient) Contracts(ctx context.Context, filter types.ContractFilter, limit types.Limit) ([]types.Contract, int, error) {
+	page, err := replay[listPage[types.Contract, int]](c, "Contracts", filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Contract implements client.Client
+func (c *ReplayingClient) Contract(ctx context.Context, contractID uint32) (types.Contract, error) {
+	return replay[types.Contract](c, "Contract", contractID)
+}
+
+// ContractBills implements client.Client
+func (c *ReplayingClient) ContractBills(ctx context.Context, contractID uint32, limit types.Limit) ([]types.ContractBilling, uint, error) {
+	page, err := replay[listPage[types.ContractBilling, uint]](c, "ContractBills", contractID, limit)
+	return page.Items, page.Count, err
+}
+
+// Twins implements client.Client
+func (c *ReplayingClient) Twins(ctx context.Context, filter types.TwinFilter, limit types.Limit) ([]types.Twin, int, error) {
+	page, err := replay[listPage[types.Twin, int]](c, "Twins", filter, limit)
+	return page.Items, page.Count, err
+}
+
+// Node implements client.Client
+func (c *ReplayingClient) Node(ctx context.Context, nodeID uint32) (types.NodeWithNestedCapacity, error) {
+	return replay[types.NodeWithNestedCapacity](c, "Node", nodeID)
+}
+
+// NodeStatus implements client.Client
+func (c *ReplayingClient) NodeStatus(ctx context.Context, nodeID uint32) (types.NodeStatus, error) {
+	return replay[types.NodeStatus](c, "NodeStatus", nodeID)
+}
+
+// Stats implements client.Client
+func (c *ReplayingClient) Stats(ctx context.Context, filter types.StatsFilter) (types.Stats, error) {
+	return replay[types.Stats](c, "Stats", filter)
+}
+
+// PublicIps implements client.Client
+func (c *ReplayingClient) PublicIps(ctx context.Context, filter types.PublicIpFilter, limit types.Limit) ([]types.PublicIP, uint, error) {
+	page, err := replay[listPage[types.PublicIP, uint]](c, "PublicIps", filter, limit)
+	return page.Items, page.Count, err
+}
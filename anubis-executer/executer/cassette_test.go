@@ -0,0 +1,143 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassettes", "grid.json")
+	live := &MockGridClient{
+		farms: []types.Farm{{FarmID: 1, Name: "Freefarm"}, {FarmID: 4, Name: "BelgianFarm"}},
+		nodes: []types.Node{{NodeID: 11, FarmID: 4, Status: "up"}},
+	}
+
+	tasks := []string{
+		`{"task_name": "list_farms", "params": {"all": true}}`,
+		`{"task_name": "get_farm", "params": {"farm_id": 4}}`,
+		`{"task_name": "get_farm", "params": {"farm_id": 9}}`,
+		`{"task_name": "list_nodes", "params": {"farm_ids": [4]}}`,
+	}
+
+	recording := &TaskExecutor{gridClient: NewRecordingClient(live, path), network: "test"}
+	var recorded []string
+	for _, task := range tasks {
+		response, err := recording.ExecuteTaskJSON(context.Background(), []byte(task))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		recorded = append(recorded, string(response))
+	}
+
+	replaying, err := LoadReplayingClient(path)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %v", err)
+	}
+
+	// The live client is gone: every response comes from the cassette
+	executor := &TaskExecutor{gridClient: replaying, network: "test"}
+	for i, task := range tasks {
+		response, err := executor.ExecuteTaskJSON(context.Background(), []byte(task))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(response) != recorded[i] {
+			t.Errorf("task %d: replayed response differs\nrecorded: %s\nreplayed: %s", i, recorded[i], response)
+		}
+	}
+
+	if !strings.Contains(recorded[2], "farm with ID 9 not found") {
+		t.Errorf("expected the recorded not found error to be replayed, got %s", recorded[2])
+	}
+}
+
+func TestReplayingClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grid.json")
+	live := &MockGridClient{nodes: []types.Node{{NodeID: 11, Status: "up"}}}
+	recording := NewRecordingClient(live, path)
+
+	ctx := context.Background()
+	if _, err := recording.NodeStatus(ctx, 11); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	live.nodes[0].Status = "down"
+	if _, err := recording.NodeStatus(ctx, 11); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	live.err = errors.New("upstream unavailable")
+	if err := recording.Ping(); err == nil {
+		t.Fatalf("expected the live ping to fail")
+	}
+
+	replaying, err := LoadReplayingClient(path)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %v", err)
+	}
+
+	// Repeated calls get the recorded responses in order, then the last one again
+	for _, expected := range []string{"up", "down", "down"} {
+		status, err := replaying.NodeStatus(ctx, 11)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if status.Status != expected {
+			t.Errorf("expected status %s, got %s", expected, status.Status)
+		}
+	}
+
+	if err := replaying.Ping(); err == nil || err.Error() != "upstream unavailable" {
+		t.Errorf("expected the recorded ping error, got %v", err)
+	}
+
+	if _, err := replaying.NodeStatus(ctx, 12); err == nil || !strings.Contains(err.Error(), "no recorded response for NodeStatus|12") {
+		t.Errorf("expected a missing interaction error, got %v", err)
+	}
+}
+
+func TestNewCassetteClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grid.json")
+	live := &MockGridClient{}
+
+	tests := []struct {
+		mode          string
+		expectedType  string
+		expectedError string
+	}{
+		{"", "*executer.MockGridClient", ""},
+		{CassetteRecord, "*executer.RecordingClient", ""},
+		{CassetteReplay, "", "failed to read cassette"},
+		{"rewind", "", "GRIDPROXY_CASSETTE must be one of [record, replay], got: rewind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			t.Setenv(CassetteEnv, tt.mode)
+
+			gridClient, err := NewCassetteClient(path, live)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := fmt.Sprintf("%T", gridClient); got != tt.expectedType {
+				t.Errorf("expected %s, got %s", tt.expectedType, got)
+			}
+		})
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no cassette to be written before a call, got %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrorCode classifies why a task failed, so callers can react to a failure
+// without parsing its message
+type ErrorCode string
+
+// Error codes reported in TaskResponse.Code
+const (
+	CodeInvalidRequest      ErrorCode = "invalid_request"      // The task or plan JSON is malformed or inconsistent
+	CodeInvalidParams       ErrorCode = "invalid_params"       // A parameter is missing or invalid; see Field
+	CodeUnknownTask         ErrorCode = "unknown_task"         // No task is registered under the name
+	CodeNotFound            ErrorCode = "not_found"            // The requested farm, node, contract or twin does not exist
+	CodeUpstreamUnavailable ErrorCode = "upstream_unavailable" // GridProxy could not be reached or kept failing
+	CodeTimeout             ErrorCode = "timeout"              // The task ran out of time
+	CodeCancelled           ErrorCode = "cancelled"            // The caller cancelled the task
+	CodeInternal            ErrorCode = "internal"             // Any other failure
+)
+
+// ErrorDetails is the machine-readable part of a failed TaskResponse or StepResult
+type ErrorDetails struct {
+	Code      ErrorCode       `json:"code,omitempty"`
+	Retryable bool            `json:"retryable,omitempty"` // The same task may succeed when retried later
+	Field     string          `json:"field,omitempty"`     // Offending parameter, for invalid_params
+	Upstream  *UpstreamStatus `json:"upstream,omitempty"`  // Failed GridProxy call, for upstream_unavailable
+}
+
+// UpstreamStatus describes the GridProxy call behind an upstream_unavailable failure
+type UpstreamStatus struct {
+	Endpoint   string `json:"endpoint,omitempty"`    // Endpoint of the last attempt
+	StatusCode int    `json:"status_code,omitempty"` // HTTP status GridProxy answered with, if it answered
+	Attempts   int    `json:"attempts,omitempty"`
+	Message    string `json:"message"`
+}
+
+// TaskError is a task failure that handlers have already classified.
+// It may be wrapped; ClassifyError finds it with errors.As.
+type TaskError struct {
+	ErrorDetails
+	Message string
+	Err     error // Underlying error, if any
+}
+
+// Error implements the error interface
+func (e *TaskError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the underlying error
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// paramError reports an invalid or missing parameter
+func paramError(field string, format string, args ...interface{}) error {
+	return &TaskError{
+		ErrorDetails: ErrorDetails{Code: CodeInvalidParams, Field: field},
+		Message:      fmt.Sprintf(format, args...),
+	}
+}
+
+// notFoundError reports an object that does not exist on the grid
+func notFoundError(format string, args ...interface{}) error {
+	return &TaskError{
+		ErrorDetails: ErrorDetails{Code: CodeNotFound},
+		Message:      fmt.Sprintf(format, args...),
+	}
+}
+
+// ClassifyError returns the error details of a task failure: those of a
+// TaskError in err's chain, or else ones derived from the kind of error
+func ClassifyError(err error) ErrorDetails {
+	var taskErr *TaskError
+	if errors.As(err, &taskErr) {
+		return taskErr.ErrorDetails
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		details := ErrorDetails{Code: CodeInvalidParams}
+		if len(validationErr.Errors) > 0 {
+			details.Field = validationErr.Errors[0].Field
+		}
+		return details
+	}
+
+	var upstreamErr *UpstreamError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorDetails{Code: CodeTimeout, Retryable: true}
+	case errors.Is(err, context.Canceled):
+		return ErrorDetails{Code: CodeCancelled}
+	case errors.As(err, &upstreamErr):
+		return ErrorDetails{
+			Code:      CodeUpstreamUnavailable,
+			Retryable: true,
+			Upstream: &UpstreamStatus{
+				Endpoint:   upstreamErr.Endpoint,
+				StatusCode: upstreamStatusCode(upstreamErr.Err),
+				Attempts:   upstreamErr.Attempts,
+				Message:    upstreamErr.Err.Error(),
+			},
+		}
+	case isNotFound(err):
+		return ErrorDetails{Code: CodeNotFound}
+	case isRetryable(err):
+		// A GridProxy failure from a client without failover
+		return ErrorDetails{
+			Code:      CodeUpstreamUnavailable,
+			Retryable: true,
+			Upstream:  &UpstreamStatus{StatusCode: upstreamStatusCode(err), Message: err.Error()},
+		}
+	default:
+		return ErrorDetails{Code: CodeInternal}
+	}
+}
+
+// upstreamStatusCode returns the status GridProxy answered err with, or zero
+func upstreamStatusCode(err error) int {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
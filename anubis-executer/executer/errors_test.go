@@ -0,0 +1,111 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ErrorDetails
+	}{
+		{"param error", fmt.Errorf("wrapped: %w", paramError("farm_id", "farm_id parameter is required")), ErrorDetails{Code: CodeInvalidParams, Field: "farm_id"}},
+		{"validation error", &ValidationError{Errors: []FieldError{{Field: "page", Message: "must be at least 1"}}}, ErrorDetails{Code: CodeInvalidParams, Field: "page"}},
+		{"not found error", notFoundError("farm with ID %d not found", 9), ErrorDetails{Code: CodeNotFound}},
+		{"GridProxy not found", errors.New("failed to fetch node: node not found"), ErrorDetails{Code: CodeNotFound}},
+		{"timeout", fmt.Errorf("task list_farms timed out after 30s: %w", context.DeadlineExceeded), ErrorDetails{Code: CodeTimeout, Retryable: true}},
+		{"cancelled", fmt.Errorf("task list_farms was cancelled: %w", context.Canceled), ErrorDetails{Code: CodeCancelled}},
+		{"unclassified", errors.New("something else"), ErrorDetails{Code: CodeInternal}},
+		{"5xx-range ID in a 404", errors.New("request failed with status code 404: node 503 not found"), ErrorDetails{Code: CodeNotFound}},
+		{"5xx-range ID", errors.New("contract 512 has no bills"), ErrorDetails{Code: CodeInternal}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClassifyUpstreamError(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.Jitter = 0
+	f, _, _ := newTestFailoverClient(policy,
+		newFlakyClient(&HTTPStatusError{StatusCode: 502}),
+		newFlakyClient(&HTTPStatusError{StatusCode: 503}))
+
+	_, _, err := f.Farms(context.Background(), types.FarmFilter{}, types.Limit{Size: 5, Page: 1})
+	details := ClassifyError(fmt.Errorf("failed to fetch farms: %w", err))
+
+	if details.Code != CodeUpstreamUnavailable || !details.Retryable || details.Upstream == nil {
+		t.Fatalf("expected a retryable upstream failure, got %+v", details)
+	}
+	expected := UpstreamStatus{
+		Endpoint:   "https://proxya.example/",
+		StatusCode: 502,
+		Attempts:   3,
+		Message:    "request failed with status code 502",
+	}
+	if *details.Upstream != expected {
+		t.Errorf("expected %+v, got %+v", expected, *details.Upstream)
+	}
+}
+
+func TestTaskResponseErrorDetails(t *testing.T) {
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{farms: []types.Farm{{FarmID: 1, Name: "Freefarm"}}},
+		network:    "test",
+	}
+	upstream := &TaskExecutor{
+		gridClient: &MockGridClient{err: &HTTPStatusError{StatusCode: 504}},
+		network:    "test",
+	}
+
+	tests := []struct {
+		name              string
+		executor          *TaskExecutor
+		task              string
+		expectedCode      ErrorCode
+		expectedField     string
+		expectedRetryable bool
+	}{
+		{"malformed JSON", executor, `{"task_name":`, CodeInvalidRequest, "", false},
+		{"unknown task", executor, `{"task_name": "unknown_task"}`, CodeUnknownTask, "", false},
+		{"missing parameter", executor, `{"task_name": "get_farm", "params": {}}`, CodeInvalidParams, "farm_id", false},
+		{"invalid timeout", executor, `{"task_name": "list_farms", "timeout": "soon"}`, CodeInvalidParams, "timeout", false},
+		{"not found", executor, `{"task_name": "get_farm", "params": {"farm_id": 9}}`, CodeNotFound, "", false},
+		{"upstream failure", upstream, `{"task_name": "list_farms"}`, CodeUpstreamUnavailable, "", true},
+		{"failed plan step", executor, `{"steps": [{"task_name": "get_farm", "params": {"farm_id": 9}}]}`, CodeNotFound, "", false},
+		{"invalid plan", executor, `{"steps": []}`, CodeInvalidRequest, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responseJSON, err := tt.executor.ExecuteTaskJSON(context.Background(), []byte(tt.task))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var response TaskResponse
+			if err := json.Unmarshal(responseJSON, &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			if response.Success {
+				t.Fatalf("expected failure, got %s", responseJSON)
+			}
+			if response.Code != tt.expectedCode || response.Field != tt.expectedField || response.Retryable != tt.expectedRetryable {
+				t.Errorf("expected code %s, field %q, retryable %v, got %s", tt.expectedCode, tt.expectedField, tt.expectedRetryable, responseJSON)
+			}
+		})
+	}
+}
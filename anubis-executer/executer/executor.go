@@ -1,95 +1,245 @@
 package executer
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+
+	"anubis-executer/shape"
 )
 
 // TaskExecutor handles the execution of tasks
 type TaskExecutor struct {
-	gridClient client.Client
-	network    string // dev, test, qa, main
+	gridClient  client.Client
+	pricing     PricingPolicySource // Pricing policies of farms, for estimate_cost
+	network     string              // dev, test, qa, main
+	registry    *Registry           // nil means DefaultRegistry
+	cache       *CachingClient      // Response cache in front of the GridProxy client, if any
+	failover    *FailoverClient     // Endpoint failover and health tracking, if any
+	maxPageSize int                 // Largest page size list tasks use; zero means MaxPageSize
+	timeout     time.Duration       // Timeout of tasks without their own; zero means DefaultTaskTimeout
+	logger      *log.Logger         // nil means the standard logger
+}
+
+// NewTaskExecutor creates a TaskExecutor for a GridProxy network: dev, test,
+// qa or main. An empty network means main; any other name is an error.
+//
+// By default the executor fails over between the network's endpoints and
+// caches responses with DefaultCacheConfig, and reads pricing policies from
+// the network's GraphQL indexer; opts change the endpoints, the HTTP client,
+// the client, the pricing policies, the cache, the default task timeout, the
+// logger and the page size.
+func NewTaskExecutor(network string, opts ...Option) (*TaskExecutor, error) {
+	if network == "" {
+		network = "main"
+	}
+	if !slices.Contains(Networks, network) {
+		return nil, fmt.Errorf("network must be one of [%s], got: %s", strings.Join(Networks, ", "), network)
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	te := &TaskExecutor{
+		network:     network,
+		maxPageSize: o.maxPageSize,
+		timeout:     o.timeout,
+		logger:      o.logger,
+	}
+
+	te.pricing = o.pricing
+	if te.pricing == nil {
+		te.pricing = NewGraphQLPricingPolicies(defaultGraphQLEndpoint(network), o.httpClient)
+	}
+
+	te.gridClient = o.gridClient
+	if te.gridClient == nil {
+		endpoints := o.endpoints
+		if endpoints == nil {
+			endpoints = defaultEndpoints(network)
+		}
+		te.failover = newFailoverClient(endpoints, proxyClients(endpoints, o.httpClient), DefaultRetryPolicy())
+		te.gridClient = te.failover
+	}
+
+	if !o.noCache {
+		config := DefaultCacheConfig()
+		if o.cache != nil {
+			config = *o.cache
+		}
+		te.cache = NewCachingClient(te.gridClient, config)
+		te.gridClient = te.cache
+	}
+
+	return te, nil
 }
 
-// NewTaskExecutor creates a new TaskExecutor instance
-func NewTaskExecutor(network string) *TaskExecutor {
-	// Default GridProxy endpoints for different networks
-	var endpoints []string
+// defaultGraphQLEndpoint returns the TFChain GraphQL indexer of one of Networks
+func defaultGraphQLEndpoint(network string) string {
+	if network == "main" {
+		return "https://graphql.grid.tf/graphql"
+	}
+	return fmt.Sprintf("https://graphql.%s.grid.tf/graphql", network)
+}
+
+// defaultEndpoints returns the GridProxy endpoints of one of Networks
+func defaultEndpoints(network string) []string {
 	switch network {
 	case "dev":
-		endpoints = []string{
+		return []string{
 			"https://gridproxy.dev.grid.tf/",
 			"https://gridproxy.02.dev.grid.tf/",
 		}
 	case "test":
-		endpoints = []string{
+		return []string{
 			"https://gridproxy.test.grid.tf/",
 			"https://gridproxy.02.test.grid.tf/",
 		}
 	case "qa":
-		endpoints = []string{
+		return []string{
 			"https://gridproxy.qa.grid.tf/",
 			"https://gridproxy.02.qa.grid.tf/",
 		}
-	case "main":
-		endpoints = []string{
-			"https://gridproxy.grid.tf/",
-			"https://gridproxy.02.grid.tf/",
-		}
 	default:
-		// Default to main network
-		network = "main"
-		endpoints = []string{
+		return []string{
 			"https://gridproxy.grid.tf/",
 			"https://gridproxy.02.grid.tf/",
 		}
 	}
+}
+
+// GetEndpointHealth returns the health of the executor's GridProxy endpoints
+// in order of preference, or nil when the executor does not track it
+func (te *TaskExecutor) GetEndpointHealth() []EndpointHealth {
+	if te.failover == nil {
+		return nil
+	}
+	return te.failover.GetEndpointHealth()
+}
+
+// InvalidateCache drops cached GridProxy responses of the given client
+// methods (e.g. "Farms"), or every cached response when none are given
+func (te *TaskExecutor) InvalidateCache(methods ...string) {
+	if te.cache != nil {
+		te.cache.Invalidate(methods...)
+	}
+}
+
+// logf logs through the executor's logger
+func (te *TaskExecutor) logf(format string, args ...interface{}) {
+	if te.logger != nil {
+		te.logger.Printf(format, args...)
+		return
+	}
+	log.Printf(format, args...)
+}
 
-	gridClient := client.NewClient(endpoints...)
+// taskTimeout returns the timeout of tasks that set none
+func (te *TaskExecutor) taskTimeout() time.Duration {
+	if te.timeout > 0 {
+		return te.timeout
+	}
+	return DefaultTaskTimeout
+}
 
-	return &TaskExecutor{
-		gridClient: gridClient,
-		network:    network,
+// tasks returns the registry used to resolve task names
+func (te *TaskExecutor) tasks() *Registry {
+	if te.registry != nil {
+		return te.registry
 	}
+	return DefaultRegistry
 }
 
-// ExecuteTask routes and executes a task based on its name
-func (te *TaskExecutor) ExecuteTask(task Task) (interface{}, error) {
-	log.Printf("Executing task: %s with params: %v", task.TaskName, task.Params)
+// ExecuteTask looks up the task in the registry and executes its handler.
+// The handler runs under ctx bounded by the task's timeout: the request's
+// Timeout when set, otherwise the one from the task's registration.
+func (te *TaskExecutor) ExecuteTask(ctx context.Context, task Task) (interface{}, error) {
+	te.logf("Executing task: %s with params: %v", task.TaskName, task.Params)
 
-	switch task.TaskName {
-	case "list_farms":
-		return te.listFarms(task.Params)
-	case "get_farm":
-		return te.getFarm(task.Params)
-	default:
-		return nil, fmt.Errorf("unknown task: %s", task.TaskName)
+	def, ok := te.tasks().Lookup(task.TaskName)
+	if !ok {
+		return nil, &TaskError{ErrorDetails: ErrorDetails{Code: CodeUnknownTask}, Message: fmt.Sprintf("unknown task: %s", task.TaskName)}
 	}
+
+	// Validate and coerce parameters against the task's schema
+	params, err := def.Params.Validate(task.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := task.timeout(def, te.taskTimeout())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err = task.withCacheMode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := def.Handler.Handle(ctx, te, task.Caller.applyTo(params))
+	if err != nil && ctx.Err() != nil {
+		// Report the cancellation rather than the upstream error it caused
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("task %s timed out after %s: %w", task.TaskName, timeout, ctx.Err())
+		}
+		return nil, fmt.Errorf("task %s was cancelled: %w", task.TaskName, ctx.Err())
+	}
+	if err == nil && def.shapesResults() {
+		return shape.Apply(result, shape.OptionsFromParams(params))
+	}
+
+	return result, err
 }
 
-// ExecuteTaskJSON is a convenience method that takes JSON input and returns JSON output
-func (te *TaskExecutor) ExecuteTaskJSON(taskJSON []byte) ([]byte, error) {
+// ExecuteTaskJSON is a convenience method that takes JSON input and returns JSON output.
+// The input is either a single task or a plan (an object with "steps").
+func (te *TaskExecutor) ExecuteTaskJSON(ctx context.Context, taskJSON []byte) ([]byte, error) {
+	if isPlanJSON(taskJSON) {
+		return te.executePlanJSON(ctx, taskJSON)
+	}
+
 	// Parse the task
 	task, err := ParseTask(taskJSON)
 	if err != nil {
 		response := TaskResponse{
-			Success: false,
-			Error:   fmt.Sprintf("Failed to parse task: %v", err),
+			Success:      false,
+			Error:        fmt.Sprintf("Failed to parse task: %v", err),
+			ErrorDetails: ErrorDetails{Code: CodeInvalidRequest},
 		}
 		return response.ToJSON()
 	}
 
-	// Execute the task
-	result, err := te.ExecuteTask(*task)
+	// Execute the task, recording how the cache served it
+	ctx, recorder := withCacheRecorder(ctx)
+	result, err := te.ExecuteTask(ctx, *task)
 
 	// Create response
 	var response TaskResponse
 	if err != nil {
 		response = TaskResponse{
-			Success: false,
-			Error:   err.Error(),
+			Success:      false,
+			Error:        err.Error(),
+			ErrorDetails: ClassifyError(err),
+		}
+
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			response.Errors = validationErr.Errors
 		}
 	} else {
 		response = TaskResponse{
@@ -97,14 +247,56 @@ func (te *TaskExecutor) ExecuteTaskJSON(taskJSON []byte) ([]byte, error) {
 			Data:    result,
 		}
 	}
+	response.Metadata = newTaskMetadata(recorder, task.Cache == CacheBypass)
+
+	return response.ToJSON()
+}
+
+// executePlanJSON executes a JSON plan and returns the per-step results as JSON
+func (te *TaskExecutor) executePlanJSON(ctx context.Context, planJSON []byte) ([]byte, error) {
+	plan, err := ParsePlan(planJSON)
+	if err != nil {
+		response := TaskResponse{
+			Success:      false,
+			Error:        fmt.Sprintf("Failed to parse plan: %v", err),
+			ErrorDetails: ErrorDetails{Code: CodeInvalidRequest},
+		}
+		return response.ToJSON()
+	}
+
+	ctx, recorder := withCacheRecorder(ctx)
+	result, err := te.ExecutePlan(ctx, *plan)
+	if err != nil {
+		response := TaskResponse{
+			Success:      false,
+			Error:        fmt.Sprintf("Invalid plan: %v", err),
+			ErrorDetails: ErrorDetails{Code: CodeInvalidRequest},
+		}
+		return response.ToJSON()
+	}
 
+	response := TaskResponse{
+		Success:  result.Success,
+		Error:    planSummary(result),
+		Steps:    result.Steps,
+		Metadata: newTaskMetadata(recorder, false),
+	}
+	// A failed plan reports the details of its first failed step
+	for _, step := range result.Steps {
+		if !step.Success && !step.Skipped {
+			response.ErrorDetails = step.ErrorDetails
+			break
+		}
+	}
 	return response.ToJSON()
 }
 
 // GetSupportedTasks returns a list of supported task names
 func (te *TaskExecutor) GetSupportedTasks() []string {
-	return []string{
-		"list_farms",
-		"get_farm",
-	}
+	return te.tasks().Names()
+}
+
+// GetTaskDefinitions returns the definitions of all supported tasks
+func (te *TaskExecutor) GetTaskDefinitions() []TaskDefinition {
+	return te.tasks().Definitions()
 }
@@ -1,10 +1,19 @@
 package executer
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+
+	"anubis-executer/gridproxytest"
 )
 
 func TestNewTaskExecutor(t *testing.T) {
@@ -12,40 +21,163 @@ func TestNewTaskExecutor(t *testing.T) {
 		name            string
 		network         string
 		expectedNetwork string
+		expectedError   string
 	}{
-		{"main network", "main", "main"},
-		{"dev network", "dev", "dev"},
-		{"test network", "test", "test"},
-		{"qa network", "qa", "qa"},
-		{"invalid network defaults to main", "invalid", "main"},
-		{"empty network defaults to main", "", "main"},
+		{"main network", "main", "main", ""},
+		{"dev network", "dev", "dev", ""},
+		{"test network", "test", "test", ""},
+		{"qa network", "qa", "qa", ""},
+		{"empty network defaults to main", "", "main", ""},
+		{"invalid network", "invalid", "", "network must be one of [dev, test, qa, main], got: invalid"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			executor := NewTaskExecutor(tt.network)
-
-			if executor == nil {
-				t.Errorf("expected non-nil executor")
+			executor, err := NewTaskExecutor(tt.network)
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
 				return
 			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 
 			if executor.network != tt.expectedNetwork {
 				t.Errorf("expected network %q, got %q", tt.expectedNetwork, executor.network)
 			}
 
-			if executor.gridClient == nil {
-				t.Errorf("expected non-nil gridClient")
+			if executor.gridClient == nil || executor.cache == nil || executor.failover == nil {
+				t.Errorf("expected a cached failover client, got %T", executor.gridClient)
+			}
+		})
+	}
+}
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	next     http.RoundTripper
+	requests atomic.Int64
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return t.next.RoundTrip(req)
+}
+
+func TestNewTaskExecutorOptions(t *testing.T) {
+	mock := &MockGridClient{farms: []types.Farm{{FarmID: 1, Name: "Freefarm"}}}
+
+	t.Run("endpoints", func(t *testing.T) {
+		executor, err := NewTaskExecutor("test", WithEndpoints("http://127.0.0.1:1/", "http://127.0.0.1:2/"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		health := executor.GetEndpointHealth()
+		if len(health) != 2 || health[0].URL != "http://127.0.0.1:1/" {
+			t.Errorf("expected the given endpoints, got %+v", health)
+		}
+	})
+
+	t.Run("HTTP client", func(t *testing.T) {
+		gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+		defer gridProxy.Close()
+
+		transport := &countingTransport{next: http.DefaultTransport}
+		executor, err := NewTaskExecutor("test", WithEndpoints(gridProxy.URL, gridProxy.URL),
+			WithHTTPClient(&http.Client{Transport: transport}), WithoutCache())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := executor.ExecuteTask(context.Background(), Task{TaskName: "list_farms"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if transport.requests.Load() != 1 {
+			t.Errorf("expected the request to go through the given client, it saw %d", transport.requests.Load())
+		}
+	})
+
+	t.Run("client without cache", func(t *testing.T) {
+		executor, err := NewTaskExecutor("test", WithClient(mock), WithoutCache())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if executor.gridClient != mock || executor.cache != nil || executor.failover != nil {
+			t.Errorf("expected the client to be used as is, got %T", executor.gridClient)
+		}
+	})
+
+	t.Run("client with cache", func(t *testing.T) {
+		config := CacheConfig{MaxEntries: 10, DefaultTTL: time.Second}
+		executor, err := NewTaskExecutor("test", WithClient(mock), WithCache(config))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if executor.cache == nil || executor.cache.next != mock || executor.cache.config.MaxEntries != 10 {
+			t.Errorf("expected the client behind the configured cache")
+		}
+	})
+
+	t.Run("timeout, logger and page size", func(t *testing.T) {
+		var logs strings.Builder
+		executor, err := NewTaskExecutor("test", WithClient(mock), WithTimeout(time.Second),
+			WithLogger(log.New(&logs, "", 0)), WithMaxPageSize(2))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if executor.taskTimeout() != time.Second {
+			t.Errorf("expected a 1s default task timeout, got %s", executor.taskTimeout())
+		}
+		result, err := executor.ExecuteTask(context.Background(), Task{TaskName: "list_farms", Params: map[string]interface{}{"page_size": 3}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if pageSize := result.(ListResult[Farm]).PageSize; pageSize != 2 {
+			t.Errorf("expected page_size to be capped at 2, got %d", pageSize)
+		}
+		if !strings.Contains(logs.String(), "Executing task: list_farms") {
+			t.Errorf("expected the task to be logged to the given logger, got %q", logs.String())
+		}
+	})
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedError string
+	}{
+		{"client and endpoints", []Option{WithClient(mock), WithEndpoints("http://127.0.0.1:1/")}, "cannot be combined"},
+		{"client and HTTP client", []Option{WithClient(mock), WithHTTPClient(http.DefaultClient)}, "cannot be combined"},
+		{"no endpoints", []Option{WithEndpoints()}, "at least one endpoint"},
+		{"timeout above maximum", []Option{WithTimeout(time.Hour)}, "timeout must be between 0s and 5m0s"},
+		{"negative page size", []Option{WithMaxPageSize(-1)}, "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTaskExecutor("test", tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("expected error containing %q, got %v", tt.expectedError, err)
 			}
 		})
 	}
 }
 
 func TestGetSupportedTasks(t *testing.T) {
-	executor := NewTaskExecutor("test")
+	executor, err := NewTaskExecutor("test")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
 	tasks := executor.GetSupportedTasks()
 
-	expectedTasks := []string{"list_farms", "get_farm"}
+	expectedTasks := []string{"list_farms", "get_farm", "list_nodes", "get_node", "node_status",
+		"list_contracts", "get_contract", "contract_bills",
+		"list_twins", "get_twin", "grid_stats", "list_public_ips", "find_capacity", "aggregate", "plan_vm_deployment", "estimate_cost"}
 
 	if len(tasks) != len(expectedTasks) {
 		t.Errorf("expected %d tasks, got %d", len(expectedTasks), len(tasks))
@@ -117,7 +249,7 @@ func TestExecuteTask(t *testing.T) {
 				network: "test",
 			}
 
-			result, err := executor.ExecuteTask(tt.task)
+			result, err := executor.ExecuteTask(context.Background(), tt.task)
 
 			if tt.expectedError {
 				if err == nil {
@@ -186,7 +318,7 @@ func TestExecuteTaskJSON(t *testing.T) {
 				network: "test",
 			}
 
-			responseJSON, err := executor.ExecuteTaskJSON([]byte(tt.taskJSON))
+			responseJSON, err := executor.ExecuteTaskJSON(context.Background(), []byte(tt.taskJSON))
 
 			if err != nil {
 				t.Errorf("ExecuteTaskJSON should not return error, got: %v", err)
@@ -235,7 +367,7 @@ func TestExecuteTaskJSONStructure(t *testing.T) {
 
 	// Test list_farms response structure
 	taskJSON := `{"task_name": "list_farms", "params": {}}`
-	responseJSON, err := executor.ExecuteTaskJSON([]byte(taskJSON))
+	responseJSON, err := executor.ExecuteTaskJSON(context.Background(), []byte(taskJSON))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -257,7 +389,7 @@ func TestExecuteTaskJSONStructure(t *testing.T) {
 	}
 
 	// Check data structure for list_farms
-	expectedFields := []string{"farms", "total_count", "page", "page_size", "network"}
+	expectedFields := []string{"items", "total_count", "page", "page_size", "has_more", "network"}
 	for _, field := range expectedFields {
 		if _, exists := data[field]; !exists {
 			t.Errorf("expected field %q in data, but not found", field)
@@ -269,3 +401,71 @@ func TestExecuteTaskJSONStructure(t *testing.T) {
 		t.Errorf("expected network=test, got %v", data["network"])
 	}
 }
+
+// blockingHandler waits until its context is done, like a stuck GridProxy call
+func blockingHandler(te *TaskExecutor, ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	<-ctx.Done()
+	return nil, fmt.Errorf("failed to fetch farms: %v", ctx.Err())
+}
+
+func TestExecuteTaskTimeout(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister(TaskDefinition{Name: "stuck", Timeout: 20 * time.Millisecond, Handler: TaskHandlerFunc(blockingHandler)})
+	registry.MustRegister(TaskDefinition{Name: "noop", Handler: TaskHandlerFunc(noopHandler)})
+
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{},
+		network:    "test",
+		registry:   registry,
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		task          Task
+		expectedError string
+	}{
+		{
+			name:          "registered timeout",
+			ctx:           context.Background(),
+			task:          Task{TaskName: "stuck"},
+			expectedError: "task stuck timed out after 20ms: context deadline exceeded",
+		},
+		{
+			name:          "request override",
+			ctx:           context.Background(),
+			task:          Task{TaskName: "stuck", Timeout: "5ms"},
+			expectedError: "task stuck timed out after 5ms: context deadline exceeded",
+		},
+		{
+			name:          "caller cancellation",
+			ctx:           cancelled,
+			task:          Task{TaskName: "stuck"},
+			expectedError: "task stuck was cancelled: context canceled",
+		},
+		{
+			name:          "invalid timeout",
+			ctx:           context.Background(),
+			task:          Task{TaskName: "noop", Timeout: "soon"},
+			expectedError: `invalid timeout: time: invalid duration "soon"`,
+		},
+		{
+			name:          "timeout above maximum",
+			ctx:           context.Background(),
+			task:          Task{TaskName: "noop", Timeout: "1h"},
+			expectedError: "timeout must be between 0s and 5m0s, got: 1h0m0s",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executor.ExecuteTask(tt.ctx, tt.task)
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("expected error %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
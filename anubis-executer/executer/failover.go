@@ -0,0 +1,372 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// RetryPolicy controls how GridProxy calls are retried across endpoints
+// and when an endpoint is considered unhealthy
+type RetryPolicy struct {
+	MaxAttempts      int           // Total attempts per call, across all endpoints
+	BaseDelay        time.Duration // Backoff before the second attempt; doubles after each attempt
+	MaxDelay         time.Duration // Upper bound of the backoff
+	Jitter           float64       // Random +/- fraction applied to each backoff, 0 to 1
+	FailureThreshold int           // Consecutive failures before an endpoint is demoted
+	Cooldown         time.Duration // How long a demoted endpoint is tried only as a last resort
+}
+
+// DefaultRetryPolicy returns the retry policy used by NewTaskExecutor
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:      3,
+		BaseDelay:        200 * time.Millisecond,
+		MaxDelay:         2 * time.Second,
+		Jitter:           0.2,
+		FailureThreshold: 2,
+		Cooldown:         30 * time.Second,
+	}
+}
+
+// backoff returns the delay before the given retry (1 for the first retry)
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// EndpointHealth reports the passively tracked health of a GridProxy endpoint
+type EndpointHealth struct {
+	URL                 string     `json:"url"`
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Requests            int64      `json:"requests"`
+	Failures            int64      `json:"failures"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	DemotedUntil        *time.Time `json:"demoted_until,omitempty"`
+}
+
+// endpoint is a GridProxy endpoint and its health
+type endpoint struct {
+	client client.Client
+	health EndpointHealth
+}
+
+// FailoverClient is a client.Client that spreads calls over several GridProxy
+// endpoints. Retryable failures (timeouts, connection errors and 5xx responses)
+// are retried on the next endpoint with exponential backoff, and endpoints that
+// keep failing are demoted for a cool-down period.
+type FailoverClient struct {
+	policy RetryPolicy
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+
+	mu        sync.Mutex
+	endpoints []*endpoint // In order of preference
+}
+
+// NewFailoverClient creates a client for the given endpoints, preferred in the order given
+func NewFailoverClient(urls []string, policy RetryPolicy) *FailoverClient {
+	return newFailoverClient(urls, proxyClients(urls, nil), policy)
+}
+
+// newFailoverClient creates a failover client over already constructed endpoint clients
+func newFailoverClient(urls []string, clients []client.Client, policy RetryPolicy) *FailoverClient {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+
+	f := &FailoverClient{
+		policy: policy,
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+	for i, url := range urls {
+		f.endpoints = append(f.endpoints, &endpoint{
+			client: clients[i],
+			health: EndpointHealth{URL: url, Healthy: true},
+		})
+	}
+	return f
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// GetEndpointHealth returns the current health of every endpoint in order of preference
+func (f *FailoverClient) GetEndpointHealth() []EndpointHealth {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	health := make([]EndpointHealth, len(f.endpoints))
+	for i, ep := range f.endpoints {
+		health[i] = ep.health
+		if ep.health.DemotedUntil != nil && !now.Before(*ep.health.DemotedUntil) {
+			health[i].Healthy = true
+			health[i].DemotedUntil = nil
+		}
+	}
+	return health
+}
+
+// candidates returns the endpoints to try, healthy ones first in order of
+// preference, then demoted ones by the end of their cool-down
+func (f *FailoverClient) candidates() []*endpoint {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	var healthy, demoted []*endpoint
+	for _, ep := range f.endpoints {
+		if ep.health.DemotedUntil != nil && now.Before(*ep.health.DemotedUntil) {
+			demoted = append(demoted, ep)
+		} else {
+			healthy = append(healthy, ep)
+		}
+	}
+
+	sort.SliceStable(demoted, func(i, j int) bool {
+		return demoted[i].health.DemotedUntil.Before(*demoted[j].health.DemotedUntil)
+	})
+	return append(healthy, demoted...)
+}
+
+// record updates an endpoint's health after a call
+func (f *FailoverClient) record(ep *endpoint, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	ep.health.Requests++
+
+	if err == nil || !isRetryable(err) {
+		// A definite answer such as "not found" means the endpoint works
+		ep.health.ConsecutiveFailures = 0
+		ep.health.Healthy = true
+		ep.health.DemotedUntil = nil
+		ep.health.LastSuccess = &now
+		return
+	}
+
+	ep.health.Failures++
+	ep.health.ConsecutiveFailures++
+	ep.health.LastError = err.Error()
+	ep.health.LastFailure = &now
+
+	if f.policy.FailureThreshold > 0 && ep.health.ConsecutiveFailures >= f.policy.FailureThreshold {
+		until := now.Add(f.policy.Cooldown)
+		ep.health.Healthy = false
+		ep.health.DemotedUntil = &until
+	}
+}
+
+// withFailover runs call against the endpoints, retrying retryable failures
+// on the next endpoint with backoff until the policy's attempts are used up
+func withFailover[T any](f *FailoverClient, ctx context.Context, call func(client.Client) (T, error)) (T, error) {
+	var zero T
+	endpoints := f.candidates()
+	if len(endpoints) == 0 {
+		return zero, fmt.Errorf("no GridProxy endpoints configured")
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < f.policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := f.sleep(ctx, f.policy.backoff(attempt)); err != nil {
+				return zero, lastErr
+			}
+		}
+
+		ep := endpoints[attempt%len(endpoints)]
+		result, err := call(ep.client)
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up, e.g. the task timed out; that says nothing about the endpoint
+			return zero, err
+		}
+
+		f.record(ep, err)
+		if err == nil {
+			return result, nil
+		}
+		if !isRetryable(err) {
+			return zero, err
+		}
+		lastErr = &UpstreamError{Endpoint: ep.health.URL, Attempts: attempt + 1, Err: err}
+	}
+
+	return zero, lastErr
+}
+
+// UpstreamError is a GridProxy call that kept failing with retryable errors
+// until the retry policy's attempts were used up
+type UpstreamError struct {
+	Endpoint string // Endpoint of the last attempt
+	Attempts int
+	Err      error // Failure of the last attempt
+}
+
+// Error implements the error interface
+func (e *UpstreamError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the failure of the last attempt
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// isRetryable reports whether err is a transient failure worth retrying on
+// another endpoint: timeouts, connection errors and 5xx responses
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// contextPinger is a client whose Ping can be cancelled
+type contextPinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingContext checks that an endpoint answers, failing over like any other
+// call, and gives up when ctx is done
+func (f *FailoverClient) PingContext(ctx context.Context) error {
+	_, err := withFailover(f, ctx, func(c client.Client) (struct{}, error) {
+		if pinger, ok := c.(contextPinger); ok {
+			return struct{}{}, pinger.PingContext(ctx)
+		}
+		return struct{}{}, c.Ping()
+	})
+	return err
+}
+
+// Ping implements client.Client
+func (f *FailoverClient) Ping() error {
+	return f.PingContext(context.Background())
+}
+
+// Farms implements client.Client
+func (f *FailoverClient) Farms(ctx context.Context, filter types.FarmFilter, limit types.Limit) ([]types.Farm, int, error) {
+	page, err := withFailover(f, ctx, func(c client.Client) (listPage[types.Farm, int], error) {
+		items, count, err := c.Farms(ctx, filter, limit)
+		return listPage[types.Farm, int]{items, count}, err
+	})
+	return page.Items, page.Count, err
+}
+
+// Nodes implements client.Client
+func (f *FailoverClient) Nodes(ctx context.Context, filter types.NodeFilter, limit types.Limit) ([]types.Node, int, error) {
+	page, err := withFailover(f, ctx, func(c client.Client) (listPage[types.Node, int], error) {
+		items, count, err := c.Nodes(ctx, filter, limit)
+		return listPage[types.Node, int]{items, count}, err
+	})
+	return page.Items, page.Count, err
+}
+
+// Contracts implements client.Client
+func (f *FailoverClient) Contracts(ctx context.Context, filter types.ContractFilter, limit types.Limit) ([]types.Contract, int, error) {
+	page, err := withFailover(f, ctx, func(c client.Client) (listPage[types.Contract, int], error) {
+		items, count, err := c.Contracts(ctx, filter, limit)
+		return listPage[types.Contract, int]{items, count}, err
+	})
+	return page.Items, page.Count, err
+}
+
+// Contract implements client.Client
+func (f *FailoverClient) Contract(ctx context.Context, contractID uint32) (types.Contract, error) {
+	return withFailover(f, ctx, func(c client.Client) (types.Contract, error) {
+		return c.Contract(ctx, contractID)
+	})
+}
+
+// ContractBills implements client.Client
+func (f *FailoverClient) ContractBills(ctx context.Context, contractID uint32, limit types.Limit) ([]types.ContractBilling, uint, error) {
+	page, err := withFailover(f, ctx, func(c client.Client) (listPage[types.ContractBilling, uint], error) {
+		items, count, err := c.ContractBills(ctx, contractID, limit)
+		return listPage[types.ContractBilling, uint]{items, count}, err
+	})
+	return page.Items, page.Count, err
+}
+
+// Twins implements client.Client
+func (f *FailoverClient) Twins(ctx context.Context, filter types.TwinFilter, limit types.Limit) ([]types.Twin, int, error) {
+	page, err := withFailover(f, ctx, func(c client.Client) (listPage[types.Twin, int], error) {
+		items, count, err := c.Twins(ctx, filter, limit)
+		return listPage[types.Twin, int]{items, count}, err
+	})
+	return page.Items, page.Count, err
+}
+
+// Node implements client.Client
+func (f *FailoverClient) Node(ctx context.Context, nodeID uint32) (types.NodeWithNestedCapacity, error) {
+	return withFailover(f, ctx, func(c client.Client) (types.NodeWithNestedCapacity, error) {
+		return c.Node(ctx, nodeID)
+	})
+}
+
+// NodeStatus implements client.Client
+func (f *FailoverClient) NodeStatus(ctx context.Context, nodeID uint32) (types.NodeStatus, error) {
+	return withFailover(f, ctx, func(c client.Client) (types.NodeStatus, error) {
+		return c.NodeStatus(ctx, nodeID)
+	})
+}
+
+// Stats implements client.Client
+func (f *FailoverClient) Stats(ctx context.Context, filter types.StatsFilter) (types.Stats, error) {
+	return withFailover(f, ctx, func(c client.Client) (types.Stats, error) {
+		return c.Stats(ctx, filter)
+	})
+}
+
+// PublicIps implements client.Client
+func (f *FailoverClient) PublicIps(ctx context.Context, filter types.PublicIpFilter, limit types.Limit) ([]types.PublicIP, uint, error) {
+	page, err := withFailover(f, ctx, func(c client.Client) (listPage[types.PublicIP, uint], error) {
+		items, count, err := c.PublicIps(ctx, filter, limit)
+		return listPage[types.PublicIP, uint]{items, count}, err
+	})
+	return page.Items, page.Count, err
+}
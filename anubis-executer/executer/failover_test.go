@@ -0,0 +1,234 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+
+	"anubis-executer/gridproxytest"
+)
+
+// flakyClient fails Farms calls with err and counts every call
+type flakyClient struct {
+	*MockGridClient
+	farmsErr error
+	calls    int
+}
+
+func (c *flakyClient) Farms(ctx context.Context, filter types.FarmFilter, limit types.Limit) ([]types.Farm, int, error) {
+	c.calls++
+	if c.farmsErr != nil {
+		return nil, 0, c.farmsErr
+	}
+	return c.MockGridClient.Farms(ctx, filter, limit)
+}
+
+// newTestFailoverClient builds a failover client over flaky endpoints with a fake clock and no real sleeping
+func newTestFailoverClient(policy RetryPolicy, endpoints ...*flakyClient) (*FailoverClient, *time.Time, *[]time.Duration) {
+	urls := make([]string, len(endpoints))
+	clients := make([]client.Client, len(endpoints))
+	for i, ep := range endpoints {
+		urls[i] = "https://proxy" + string(rune('a'+i)) + ".example/"
+		clients[i] = ep
+	}
+
+	f := newFailoverClient(urls, clients, policy)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var sleeps []time.Duration
+	f.now = func() time.Time { return now }
+	f.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return ctx.Err()
+	}
+	return f, &now, &sleeps
+}
+
+func newFlakyClient(err error) *flakyClient {
+	return &flakyClient{
+		MockGridClient: &MockGridClient{farms: []types.Farm{{FarmID: 1, Name: "Freefarm"}}},
+		farmsErr:       err,
+	}
+}
+
+func TestFailoverRetriesOnNextEndpoint(t *testing.T) {
+	primary := newFlakyClient(&HTTPStatusError{StatusCode: 502})
+	secondary := newFlakyClient(nil)
+
+	policy := DefaultRetryPolicy()
+	policy.Jitter = 0
+	f, _, sleeps := newTestFailoverClient(policy, primary, secondary)
+
+	farms, _, err := f.Farms(context.Background(), types.FarmFilter{}, types.Limit{Size: 5, Page: 1})
+	if err != nil || len(farms) != 1 {
+		t.Fatalf("expected secondary endpoint to answer, got %v, %v", farms, err)
+	}
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Errorf("expected one call per endpoint, got %d and %d", primary.calls, secondary.calls)
+	}
+	if len(*sleeps) != 1 || (*sleeps)[0] != policy.BaseDelay {
+		t.Errorf("expected a single base backoff, got %v", *sleeps)
+	}
+}
+
+func TestFailoverDoesNotRetryDefiniteErrors(t *testing.T) {
+	definite := []error{
+		errors.New("farm not found"),
+		errors.New("request failed with status code 502"), // Only a status GridProxy answered with counts
+		&HTTPStatusError{StatusCode: 400, Message: "bad filter"},
+		&HTTPStatusError{StatusCode: 404, Message: "contract 503 not found"},
+		context.Canceled,
+	}
+	for _, err := range definite {
+		primary := newFlakyClient(err)
+		secondary := newFlakyClient(nil)
+		f, _, _ := newTestFailoverClient(DefaultRetryPolicy(), primary, secondary)
+
+		if _, _, got := f.Farms(context.Background(), types.FarmFilter{}, types.Limit{Size: 5, Page: 1}); got != err {
+			t.Errorf("expected %q to be returned as-is, got %v", err, got)
+		}
+		if secondary.calls != 0 {
+			t.Errorf("expected %q not to be retried", err)
+		}
+		if health := f.GetEndpointHealth()[0]; !health.Healthy || health.Failures != 0 {
+			t.Errorf("expected %q not to count against the endpoint, got %+v", err, health)
+		}
+	}
+}
+
+func TestFailoverIgnoresCallerDeadline(t *testing.T) {
+	primary := newFlakyClient(context.DeadlineExceeded)
+	secondary := newFlakyClient(nil)
+	f, _, _ := newTestFailoverClient(DefaultRetryPolicy(), primary, secondary)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	for i := 0; i < 3; i++ {
+		if _, _, err := f.Farms(ctx, types.FarmFilter{}, types.Limit{Size: 5, Page: 1}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the deadline to be returned, got %v", err)
+		}
+	}
+
+	// The task's own timeout is not the endpoint's fault
+	if secondary.calls != 0 {
+		t.Errorf("expected no retry after the caller's deadline, got %d calls", secondary.calls)
+	}
+	if health := f.GetEndpointHealth()[0]; !health.Healthy || health.Failures != 0 {
+		t.Errorf("expected the primary to stay healthy, got %+v", health)
+	}
+}
+
+func TestFailoverGivesUpAfterMaxAttempts(t *testing.T) {
+	primary := newFlakyClient(&net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded})
+	secondary := newFlakyClient(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED})
+	f, _, sleeps := newTestFailoverClient(DefaultRetryPolicy(), primary, secondary)
+
+	_, _, err := f.Farms(context.Background(), types.FarmFilter{}, types.Limit{Size: 5, Page: 1})
+	if err == nil || !strings.Contains(err.Error(), "(after 3 attempts)") {
+		t.Fatalf("expected error after 3 attempts, got %v", err)
+	}
+	if primary.calls != 2 || secondary.calls != 1 {
+		t.Errorf("expected attempts to alternate endpoints, got %d and %d", primary.calls, secondary.calls)
+	}
+	if len(*sleeps) != 2 {
+		t.Errorf("expected backoff between attempts, got %v", *sleeps)
+	}
+}
+
+func TestFailoverHealthTracking(t *testing.T) {
+	primary := newFlakyClient(&HTTPStatusError{StatusCode: 503})
+	secondary := newFlakyClient(nil)
+
+	policy := DefaultRetryPolicy()
+	policy.FailureThreshold = 2
+	f, now, _ := newTestFailoverClient(policy, primary, secondary)
+	limit := types.Limit{Size: 5, Page: 1}
+
+	// Two failed calls demote the primary
+	f.Farms(context.Background(), types.FarmFilter{}, limit)
+	f.Farms(context.Background(), types.FarmFilter{}, limit)
+
+	health := f.GetEndpointHealth()
+	if health[0].Healthy || health[0].ConsecutiveFailures != 2 || health[0].DemotedUntil == nil {
+		t.Fatalf("expected primary to be demoted, got %+v", health[0])
+	}
+	if !health[1].Healthy || health[1].Requests != 2 {
+		t.Errorf("expected secondary to be healthy, got %+v", health[1])
+	}
+
+	// While demoted, the secondary is tried first
+	f.Farms(context.Background(), types.FarmFilter{}, limit)
+	if primary.calls != 2 {
+		t.Errorf("expected demoted primary to be skipped, it saw %d calls", primary.calls)
+	}
+
+	// After the cool-down the recovered primary is preferred again
+	*now = now.Add(policy.Cooldown)
+	primary.farmsErr = nil
+	if health := f.GetEndpointHealth()[0]; !health.Healthy {
+		t.Errorf("expected primary to be healthy after the cool-down")
+	}
+	f.Farms(context.Background(), types.FarmFilter{}, limit)
+	if primary.calls != 3 {
+		t.Errorf("expected primary to be tried after the cool-down, it saw %d calls", primary.calls)
+	}
+	if health := f.GetEndpointHealth()[0]; health.ConsecutiveFailures != 0 || health.LastSuccess == nil {
+		t.Errorf("expected success to reset the primary's failures, got %+v", health)
+	}
+}
+
+func TestFailoverRetriesServerErrorsOverHTTP(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"error": "bad gateway"}`))
+	}))
+	defer failing.Close()
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = time.Millisecond
+	f := NewFailoverClient([]string{failing.URL, gridProxy.URL}, policy)
+
+	farms, _, err := f.Farms(context.Background(), types.FarmFilter{}, types.Limit{Size: 5, Page: 1})
+	if err != nil || len(farms) == 0 {
+		t.Fatalf("expected the second endpoint to answer, got %v, %v", farms, err)
+	}
+	health := f.GetEndpointHealth()[0]
+	if health.Failures != 1 || health.LastError != "request failed with status code 502: bad gateway" {
+		t.Errorf("expected the 502 to count against the first endpoint, got %+v", health)
+	}
+
+	// A definite answer is returned without trying the failing endpoint again
+	_, err = f.Node(context.Background(), 999)
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || !isNotFound(err) {
+		t.Errorf("expected a 404, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := f.PingContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled ping, got %v", err)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: 0.5}
+
+	for retry, base := range map[int]time.Duration{1: 100 * time.Millisecond, 3: 400 * time.Millisecond, 10: time.Second} {
+		delay := policy.backoff(retry)
+		if delay < base/2 || delay > base*3/2 {
+			t.Errorf("retry %d: expected %v +/- 50%%, got %v", retry, base, delay)
+		}
+	}
+}
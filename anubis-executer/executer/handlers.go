@@ -2,11 +2,15 @@ package executer
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+
+	"anubis-executer/schema"
 )
 
 // parseUint64 parses various types to uint64
@@ -27,9 +31,77 @@ func parseUint64(value interface{}) (uint64, error) {
 	}
 }
 
+// isNotFound reports whether a GridProxy error means the requested object does not exist
+func isNotFound(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusNotFound
+	}
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "not found")
+}
+
+// optionalUint64 returns the named parameter as a uint64 pointer, or nil when it is absent
+func optionalUint64(params map[string]interface{}, name string) (*uint64, error) {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	n, err := parseUint64(value)
+	if err != nil {
+		return nil, paramError(name, "invalid %s format: %v", name, err)
+	}
+	return &n, nil
+}
+
+// optionalString returns the named parameter as a string pointer, or nil when it is absent or empty
+func optionalString(params map[string]interface{}, name string) *string {
+	if value, ok := params[name].(string); ok && value != "" {
+		return &value
+	}
+	return nil
+}
+
+// optionalBool returns the named parameter as a bool pointer, or nil when it is absent
+func optionalBool(params map[string]interface{}, name string) *bool {
+	if value, ok := params[name].(bool); ok {
+		return &value
+	}
+	return nil
+}
+
+// stringSlice returns the named array parameter as strings, skipping non-string elements
+func stringSlice(params map[string]interface{}, name string) []string {
+	var result []string
+	for _, item := range schema.ToSlice(params[name]) {
+		if str, ok := item.(string); ok && str != "" {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
+// uint64Slice returns the named array parameter as uint64 values
+func uint64Slice(params map[string]interface{}, name string) ([]uint64, error) {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return nil, nil
+	}
+
+	var result []uint64
+	for _, item := range schema.ToSlice(value) {
+		n, err := parseUint64(item)
+		if err != nil {
+			return nil, paramError(name, "invalid %s format: %v", name, err)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
 // listFarms returns a list of available ThreeFold farms
-func (te *TaskExecutor) listFarms(params map[string]interface{}) (interface{}, error) {
-	log.Println("Executing listFarms task")
+func (te *TaskExecutor) listFarms(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing listFarms task")
 
 	// Create filter from parameters
 	filter := types.FarmFilter{}
@@ -46,56 +118,42 @@ func (te *TaskExecutor) listFarms(params map[string]interface{}) (interface{}, e
 
 	// Apply farm ID filter if specified
 	if farmIDParam, ok := params["farm_id"]; ok {
-		if farmID, err := parseUint64(farmIDParam); err == nil {
-			filter.FarmID = &farmID
+		farmID, err := parseUint64(farmIDParam)
+		if err != nil {
+			return nil, paramError("farm_id", "invalid farm_id format: %v", err)
 		}
+		filter.FarmID = &farmID
 	}
 
-	// Set pagination limit (max 5 per page as requested)
-	limit := types.Limit{
-		Size:     5, // Max 5 per page
-		Page:     1, // Default to first page
-		RetCount: true,
-	}
-
-	// Apply page parameter if specified
-	if pageParam, ok := params["page"]; ok {
-		if page, err := parseUint64(pageParam); err == nil {
-			limit.Page = page
-		}
-	}
-
-	// Make the API call
-	ctx := context.Background()
-	farms, totalCount, err := te.gridClient.Farms(ctx, filter, limit)
+	// Set pagination from the page, page_size, all and max_items parameters
+	opts, err := te.listOptionsFromParams(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch farms: %v", err)
+		return nil, err
 	}
 
-	// Return response with pagination info
-	response := map[string]interface{}{
-		"farms":       farms,
-		"total_count": totalCount,
-		"page":        limit.Page,
-		"page_size":   limit.Size,
-		"network":     te.network,
+	// Make the API call(s)
+	result, err := listPages(ctx, te, opts, func(ctx context.Context, limit types.Limit) ([]types.Farm, int, error) {
+		return te.gridClient.Farms(ctx, filter, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch farms: %w", err)
 	}
 
-	return response, nil
+	return result, nil
 }
 
 // getFarm returns details of a specific farm
-func (te *TaskExecutor) getFarm(params map[string]interface{}) (interface{}, error) {
-	log.Println("Executing getFarm task")
+func (te *TaskExecutor) getFarm(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing getFarm task")
 
 	farmIDParam, ok := params["farm_id"]
 	if !ok {
-		return nil, fmt.Errorf("farm_id parameter is required")
+		return nil, paramError("farm_id", "farm_id parameter is required")
 	}
 
 	farmID, err := parseUint64(farmIDParam)
 	if err != nil {
-		return nil, fmt.Errorf("invalid farm_id format: %v", err)
+		return nil, paramError("farm_id", "invalid farm_id format: %v", err)
 	}
 
 	// Create filter for specific farm
@@ -111,14 +169,13 @@ func (te *TaskExecutor) getFarm(params map[string]interface{}) (interface{}, err
 	}
 
 	// Make the API call
-	ctx := context.Background()
 	farms, _, err := te.gridClient.Farms(ctx, filter, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch farm: %v", err)
+		return nil, fmt.Errorf("failed to fetch farm: %w", err)
 	}
 
 	if len(farms) == 0 {
-		return nil, fmt.Errorf("farm with ID %d not found", farmID)
+		return nil, notFoundError("farm with ID %d not found", farmID)
 	}
 
 	return farms[0], nil
@@ -0,0 +1,313 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// defaultAggregateGroups is the number of groups aggregate returns by default
+const defaultAggregateGroups = 20
+
+// Entities and groupings accepted by aggregate
+var (
+	aggregateEntities = []interface{}{"nodes", "farms"}
+	aggregateGroupBys = []interface{}{"country", "farm", "certification", "status"}
+)
+
+// aggregateMetrics are the group values aggregate can sort by
+var aggregateMetrics = map[string]func(AggregateGroup) float64{
+	"count":           func(g AggregateGroup) float64 { return float64(g.Count) },
+	"nodes":           func(g AggregateGroup) float64 { return float64(g.Nodes) },
+	"total_cru":       func(g AggregateGroup) float64 { return float64(g.Total.CRU) },
+	"total_mru_gb":    func(g AggregateGroup) float64 { return float64(g.Total.MRUGB) },
+	"total_sru_gb":    func(g AggregateGroup) float64 { return float64(g.Total.SRUGB) },
+	"total_hru_gb":    func(g AggregateGroup) float64 { return float64(g.Total.HRUGB) },
+	"used_cru":        func(g AggregateGroup) float64 { return float64(g.Used.CRU) },
+	"used_mru_gb":     func(g AggregateGroup) float64 { return float64(g.Used.MRUGB) },
+	"used_sru_gb":     func(g AggregateGroup) float64 { return float64(g.Used.SRUGB) },
+	"used_hru_gb":     func(g AggregateGroup) float64 { return float64(g.Used.HRUGB) },
+	"free_cru":        func(g AggregateGroup) float64 { return float64(g.Free.CRU) },
+	"free_mru_gb":     func(g AggregateGroup) float64 { return float64(g.Free.MRUGB) },
+	"free_sru_gb":     func(g AggregateGroup) float64 { return float64(g.Free.SRUGB) },
+	"free_hru_gb":     func(g AggregateGroup) float64 { return float64(g.Free.HRUGB) },
+	"utilization_cru": func(g AggregateGroup) float64 { return g.Utilization.CRU },
+	"utilization_mru": func(g AggregateGroup) float64 { return g.Utilization.MRU },
+	"utilization_sru": func(g AggregateGroup) float64 { return g.Utilization.SRU },
+	"utilization_hru": func(g AggregateGroup) float64 { return g.Utilization.HRU },
+}
+
+// Utilization is the percentage of capacity in use
+type Utilization struct {
+	CRU float64 `json:"cru"`
+	MRU float64 `json:"mru"`
+	SRU float64 `json:"sru"`
+	HRU float64 `json:"hru"`
+}
+
+// AggregateGroup sums the capacity of the nodes in one group
+type AggregateGroup struct {
+	Key            string          `json:"key"`
+	Name           string          `json:"name,omitempty"` // Farm name, when grouped by farm
+	Count          int             `json:"count"`          // Nodes or farms in the group
+	Nodes          int             `json:"nodes"`          // Nodes whose capacity is summed
+	Total          CapacitySummary `json:"total"`
+	Used           CapacitySummary `json:"used"`
+	Free           CapacitySummary `json:"free"`
+	AveragePerNode CapacitySummary `json:"average_per_node"` // Total capacity divided by nodes
+	Utilization    Utilization     `json:"utilization"`
+}
+
+// AggregateResult lists the groups of an aggregate task, largest first by default
+type AggregateResult struct {
+	Entity      string           `json:"entity"`
+	GroupBy     string           `json:"group_by"`
+	SortBy      string           `json:"sort_by"`
+	Order       string           `json:"order"`
+	Groups      []AggregateGroup `json:"groups"`
+	TotalGroups int              `json:"total_groups"`
+	Totals      AggregateGroup   `json:"totals"`   // Every node or farm, as one group
+	Complete    bool             `json:"complete"` // False when a walk hit its page cap
+	Network     string           `json:"network"`
+}
+
+// capacityTotals accumulates capacity in the units GridProxy reports
+type capacityTotals struct {
+	total, used types.Capacity
+	nodes       int
+	members     map[string]bool // Counted nodes or farms
+}
+
+// add sums a node into the totals under member
+func (c *capacityTotals) add(member string, node types.Node) {
+	if c.members == nil {
+		c.members = make(map[string]bool)
+	}
+	c.members[member] = true
+	c.nodes++
+	c.total.CRU += node.TotalResources.CRU
+	c.total.MRU += node.TotalResources.MRU
+	c.total.SRU += node.TotalResources.SRU
+	c.total.HRU += node.TotalResources.HRU
+	c.used.CRU += node.UsedResources.CRU
+	c.used.MRU += node.UsedResources.MRU
+	c.used.SRU += node.UsedResources.SRU
+	c.used.HRU += node.UsedResources.HRU
+}
+
+// group summarizes the totals as the group named key
+func (c *capacityTotals) group(key string) AggregateGroup {
+	summary := func(capacity types.Capacity) CapacitySummary {
+		return CapacitySummary{
+			CRU:   capacity.CRU,
+			MRUGB: uint64(capacity.MRU) / gigabyte,
+			SRUGB: uint64(capacity.SRU) / gigabyte,
+			HRUGB: uint64(capacity.HRU) / gigabyte,
+		}
+	}
+	percent := func(used, total uint64) float64 {
+		if total == 0 {
+			return 0
+		}
+		return math.Round(float64(used)/float64(total)*1000) / 10
+	}
+
+	group := AggregateGroup{
+		Key:   key,
+		Count: len(c.members),
+		Nodes: c.nodes,
+		Total: summary(c.total),
+		Used:  summary(c.used),
+		Utilization: Utilization{
+			CRU: percent(c.used.CRU, c.total.CRU),
+			MRU: percent(uint64(c.used.MRU), uint64(c.total.MRU)),
+			SRU: percent(uint64(c.used.SRU), uint64(c.total.SRU)),
+			HRU: percent(uint64(c.used.HRU), uint64(c.total.HRU)),
+		},
+	}
+
+	free := func(total, used uint64) uint64 {
+		if used > total {
+			return 0
+		}
+		return total - used
+	}
+	group.Free = CapacitySummary{
+		CRU:   free(group.Total.CRU, group.Used.CRU),
+		MRUGB: free(group.Total.MRUGB, group.Used.MRUGB),
+		SRUGB: free(group.Total.SRUGB, group.Used.SRUGB),
+		HRUGB: free(group.Total.HRUGB, group.Used.HRUGB),
+	}
+	if c.nodes > 0 {
+		n := uint64(c.nodes)
+		group.AveragePerNode = CapacitySummary{
+			CRU:   group.Total.CRU / n,
+			MRUGB: group.Total.MRUGB / n,
+			SRUGB: group.Total.SRUGB / n,
+			HRUGB: group.Total.HRUGB / n,
+		}
+	}
+	return group
+}
+
+// aggregate groups nodes or farms and sums their capacity, walking every page
+// of nodes (and of farms, when farm details are needed) server-side
+func (te *TaskExecutor) aggregate(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing aggregate task")
+
+	entity, _ := params["entity"].(string)
+	groupBy, _ := params["group_by"].(string)
+	sortBy, _ := params["sort_by"].(string)
+	order, _ := params["order"].(string)
+	certified := params["certified"] == true
+	if entity == "farms" && groupBy == "status" {
+		return nil, paramError("group_by", "farms cannot be grouped by status")
+	}
+	if _, ok := aggregateMetrics[sortBy]; !ok {
+		return nil, paramError("sort_by", "sort_by must be one of [%s], got: %s", strings.Join(aggregateMetricNames(), ", "), sortBy)
+	}
+
+	filter := types.NodeFilter{
+		Status:  stringSlice(params, "status"),
+		Country: optionalString(params, "country"),
+	}
+	farmIDs, err := uint64Slice(params, "farm_ids")
+	if err != nil {
+		return nil, err
+	}
+	filter.FarmIDs = farmIDs
+
+	limit := defaultAggregateGroups
+	if value, err := optionalUint64(params, "limit"); err != nil {
+		return nil, err
+	} else if value != nil {
+		limit = int(*value)
+	}
+
+	// Farm names and certifications come from the farms themselves
+	complete := true
+	var farms map[int]types.Farm
+	if entity == "farms" || groupBy == "farm" {
+		farms = make(map[int]types.Farm)
+		farmsComplete, err := walkPages(ctx, func(ctx context.Context, limit types.Limit) ([]types.Farm, int, error) {
+			return te.gridClient.Farms(ctx, types.FarmFilter{}, limit)
+		}, func(farm types.Farm) {
+			if len(farmIDs) == 0 || slices.Contains(farmIDs, uint64(farm.FarmID)) {
+				farms[farm.FarmID] = farm
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch farms: %w", err)
+		}
+		complete = farmsComplete
+	}
+
+	groups := make(map[string]*capacityTotals)
+	var totals capacityTotals
+	addTo := func(key, member string, node types.Node) {
+		if groups[key] == nil {
+			groups[key] = &capacityTotals{}
+		}
+		groups[key].add(member, node)
+	}
+
+	nodesComplete, err := walkPages(ctx, func(ctx context.Context, limit types.Limit) ([]types.Node, int, error) {
+		return te.gridClient.Nodes(ctx, filter, limit)
+	}, func(node types.Node) {
+		farmKey := strconv.Itoa(node.FarmID)
+
+		if entity == "farms" {
+			farm, ok := farms[node.FarmID]
+			if !ok || (certified && !isCertifiedFarm(farm)) {
+				return
+			}
+			totals.add(farmKey, node)
+			switch groupBy {
+			case "country":
+				addTo(node.Country, farmKey, node)
+			case "farm":
+				addTo(farmKey, farmKey, node)
+			case "certification":
+				addTo(farm.CertificationType, farmKey, node)
+			}
+			return
+		}
+
+		if certified && node.CertificationType != "Certified" {
+			return
+		}
+		nodeKey := strconv.Itoa(node.NodeID)
+		totals.add(nodeKey, node)
+		switch groupBy {
+		case "country":
+			addTo(node.Country, nodeKey, node)
+		case "farm":
+			addTo(farmKey, nodeKey, node)
+		case "certification":
+			addTo(node.CertificationType, nodeKey, node)
+		case "status":
+			addTo(node.Status, nodeKey, node)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch nodes: %w", err)
+	}
+
+	result := AggregateResult{
+		Entity:      entity,
+		GroupBy:     groupBy,
+		SortBy:      sortBy,
+		Order:       order,
+		Groups:      make([]AggregateGroup, 0, len(groups)),
+		TotalGroups: len(groups),
+		Totals:      totals.group("all"),
+		Complete:    complete && nodesComplete,
+		Network:     te.network,
+	}
+	for key, group := range groups {
+		aggregated := group.group(key)
+		if groupBy == "farm" {
+			farmID, _ := strconv.Atoi(key)
+			aggregated.Name = farms[farmID].Name
+		}
+		result.Groups = append(result.Groups, aggregated)
+	}
+
+	metric := aggregateMetrics[sortBy]
+	sort.Slice(result.Groups, func(i, j int) bool {
+		a, b := metric(result.Groups[i]), metric(result.Groups[j])
+		if a == b {
+			return result.Groups[i].Key < result.Groups[j].Key
+		}
+		if order == "asc" {
+			return a < b
+		}
+		return a > b
+	})
+	if len(result.Groups) > limit {
+		result.Groups = result.Groups[:limit]
+	}
+
+	return result, nil
+}
+
+// isCertifiedFarm reports whether a farm has any certification
+func isCertifiedFarm(farm types.Farm) bool {
+	return farm.CertificationType != "" && farm.CertificationType != "NotCertified"
+}
+
+// aggregateMetricNames returns the sort_by values of aggregate in order
+func aggregateMetricNames() []string {
+	names := make([]string, 0, len(aggregateMetrics))
+	for name := range aggregateMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
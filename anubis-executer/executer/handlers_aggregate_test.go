@@ -0,0 +1,126 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"anubis-executer/gridproxytest"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func TestAggregate(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		params         map[string]interface{}
+		expectedKeys   []string
+		expectedCounts []int
+		expectedGroups int
+	}{
+		{"countries with the most free SSD", map[string]interface{}{"group_by": "country", "status": []interface{}{"up"}, "sort_by": "free_sru_gb"}, []string{"Germany", "Egypt", "Belgium"}, []int{1, 1, 2}, 3},
+		{"farm SSD utilization", map[string]interface{}{"entity": "farms", "group_by": "farm", "sort_by": "utilization_sru"}, []string{"2", "1", "3"}, []int{1, 1, 1}, 3},
+		{"certified farms", map[string]interface{}{"entity": "farms", "group_by": "certification", "certified": true}, []string{"Gold"}, []int{1}, 1},
+		{"certified nodes", map[string]interface{}{"group_by": "farm", "certified": true}, []string{"3"}, []int{1}, 1},
+		{"limit", map[string]interface{}{"group_by": "status", "limit": 2}, []string{"up", "down"}, []int{4, 1}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.ExecuteTask(context.Background(), Task{TaskName: "aggregate", Params: tt.params})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			aggregated := result.(AggregateResult)
+			var keys []string
+			var counts []int
+			for _, group := range aggregated.Groups {
+				keys = append(keys, group.Key)
+				counts = append(counts, group.Count)
+			}
+			if !slices.Equal(keys, tt.expectedKeys) || !slices.Equal(counts, tt.expectedCounts) {
+				t.Errorf("expected groups %v with counts %v, got %v with %v", tt.expectedKeys, tt.expectedCounts, keys, counts)
+			}
+			if aggregated.TotalGroups != tt.expectedGroups || !aggregated.Complete {
+				t.Errorf("expected %d complete groups, got %d (complete: %v)", tt.expectedGroups, aggregated.TotalGroups, aggregated.Complete)
+			}
+		})
+	}
+}
+
+func TestAggregateCapacity(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{
+		TaskName: "aggregate",
+		Params:   map[string]interface{}{"group_by": "farm", "country": "Belgium", "status": []interface{}{"up"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	groups := result.(AggregateResult).Groups
+	if len(groups) != 1 {
+		t.Fatalf("expected farm 1 only, got %+v", groups)
+	}
+	expected := AggregateGroup{
+		Key:            "1",
+		Name:           "Freefarm",
+		Count:          2,
+		Nodes:          2,
+		Total:          CapacitySummary{CRU: 24, MRUGB: 96, SRUGB: 1536, HRUGB: 6000},
+		Used:           CapacitySummary{CRU: 2, MRUGB: 8, SRUGB: 100},
+		Free:           CapacitySummary{CRU: 22, MRUGB: 88, SRUGB: 1436, HRUGB: 6000},
+		AveragePerNode: CapacitySummary{CRU: 12, MRUGB: 48, SRUGB: 768, HRUGB: 3000},
+		Utilization:    Utilization{CRU: 8.3, MRU: 8.3, SRU: 6.5},
+	}
+	if groups[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, groups[0])
+	}
+}
+
+func TestAggregateWalksEveryPage(t *testing.T) {
+	nodes := make([]types.Node, 250)
+	for i := range nodes {
+		nodes[i] = types.Node{NodeID: i + 1, Status: "up", Country: "Belgium", TotalResources: types.Capacity{CRU: 2}}
+	}
+	executor := &TaskExecutor{gridClient: &MockGridClient{nodes: nodes}, network: "test"}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{TaskName: "aggregate", Params: map[string]interface{}{"group_by": "country"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	aggregated := result.(AggregateResult)
+	if aggregated.Totals.Nodes != 250 || aggregated.Totals.Total.CRU != 500 || !aggregated.Complete {
+		t.Errorf("expected every node to be counted, got %+v", aggregated.Totals)
+	}
+}
+
+func TestAggregateInvalidGrouping(t *testing.T) {
+	executor := &TaskExecutor{gridClient: &MockGridClient{}, network: "test"}
+
+	_, err := executor.ExecuteTask(context.Background(), Task{TaskName: "aggregate", Params: map[string]interface{}{"entity": "farms", "group_by": "status"}})
+
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) || taskErr.Code != CodeInvalidParams || taskErr.Field != "group_by" {
+		t.Errorf("expected a group_by error, got %v", err)
+	}
+}
@@ -0,0 +1,373 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// capacityPoolSize is how many matching nodes find_capacity fetches and scores
+const capacityPoolSize = 50
+
+// farmFetchConcurrency is how many farms are fetched from GridProxy at once
+const farmFetchConcurrency = 8
+
+// defaultCandidates is the number of candidates find_capacity returns by default
+const defaultCandidates = 5
+
+// fullUptime is the uptime at which a node gets the full uptime score
+const fullUptime = 30 * 24 * time.Hour
+
+// Weights of the find_capacity score components; they add up to 100
+const (
+	headroomWeight      = 50.0
+	uptimeWeight        = 30.0
+	certificationWeight = 20.0
+)
+
+// WorkloadSpec is the capacity a workload needs from a single node
+type WorkloadSpec struct {
+	CRU       uint64 `json:"cru"`
+	MRUGB     uint64 `json:"mru_gb"`
+	SRUGB     uint64 `json:"sru_gb,omitempty"`
+	HRUGB     uint64 `json:"hru_gb,omitempty"`
+	PublicIP  bool   `json:"public_ipv4,omitempty"`
+	GPU       bool   `json:"gpu,omitempty"`
+	Country   string `json:"country,omitempty"`
+	Region    string `json:"region,omitempty"`
+	Certified bool   `json:"certified_only,omitempty"`
+}
+
+// CapacityCandidate is a node that can host the workload, with why it ranks where it does
+type CapacityCandidate struct {
+	NodeID            int             `json:"node_id"`
+	TwinID            int             `json:"twin_id"` // Node twin that deployments are sent to
+	FarmID            int             `json:"farm_id"`
+	FarmName          string          `json:"farm_name,omitempty"`
+	Country           string          `json:"country"`
+	City              string          `json:"city,omitempty"`
+	Score             float64         `json:"score"` // 0 to 100, higher is better
+	Free              CapacitySummary `json:"free"`  // Free capacity before the workload is deployed
+	UptimeDays        float64         `json:"uptime_days"`
+	Certification     string          `json:"certification"`
+	FarmCertification string          `json:"farm_certification,omitempty"`
+	Reasons           []string        `json:"reasons"`
+}
+
+// FindCapacityResult lists the best candidate nodes for a workload
+type FindCapacityResult struct {
+	Workload   WorkloadSpec        `json:"workload"`
+	Candidates []CapacityCandidate `json:"candidates"`
+	Matched    int                 `json:"matched"` // Nodes matching the workload's filters
+	Scored     int                 `json:"scored"`  // Matching nodes that were fetched and ranked
+	Network    string              `json:"network"`
+}
+
+// findCapacity finds up nodes with enough free capacity for a workload and
+// ranks them by headroom left after deployment, uptime and certification
+func (te *TaskExecutor) findCapacity(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing findCapacity task")
+
+	spec, err := workloadSpecFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := defaultCandidates
+	if limit, err := optionalUint64(params, "limit"); err != nil {
+		return nil, err
+	} else if limit != nil {
+		candidates = int(*limit)
+	}
+
+	var callerTwinID *uint64
+	if twinID, err := optionalUint64(params, callerTwinIDParam); err == nil {
+		callerTwinID = twinID
+	}
+
+	ranked, matched, err := te.rankCandidates(ctx, spec, spec.nodeFilter(), callerTwinID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := FindCapacityResult{
+		Workload:   spec,
+		Candidates: ranked,
+		Matched:    matched,
+		Scored:     len(ranked),
+		Network:    te.network,
+	}
+	if len(result.Candidates) > candidates {
+		result.Candidates = result.Candidates[:candidates]
+	}
+
+	return result, nil
+}
+
+// rankCandidates fetches the capacityPoolSize nodes matching filter with the
+// most free memory and returns them scored for spec, best first, with the
+// number of matching nodes. Nodes rented by a twin other than callerTwinID
+// are left out.
+func (te *TaskExecutor) rankCandidates(ctx context.Context, spec WorkloadSpec, filter types.NodeFilter, callerTwinID *uint64) ([]CapacityCandidate, int, error) {
+	// Dedicated nodes rented by someone else cannot host the workload
+	if callerTwinID != nil {
+		filter.AvailableFor = callerTwinID
+	} else {
+		rented := false
+		filter.Rented = &rented
+	}
+
+	// Make the API call; sorting upstream keeps the pool from being the
+	// lowest node IDs when more nodes match than are scored
+	limit := types.Limit{Size: capacityPoolSize, Page: 1, RetCount: true, SortBy: "free_mru", SortOrder: "desc"}
+	nodes, matched, err := te.gridClient.Nodes(ctx, filter, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch nodes: %w", err)
+	}
+
+	farms, err := te.fetchFarms(ctx, nodes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	candidates := make([]CapacityCandidate, 0, len(nodes))
+	for _, node := range nodes {
+		candidates = append(candidates, spec.score(node, farms[node.FarmID]))
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates, matched, nil
+}
+
+// workloadSpecFromParams reads the find_capacity workload parameters
+func workloadSpecFromParams(params map[string]interface{}) (WorkloadSpec, error) {
+	spec := WorkloadSpec{
+		PublicIP:  params["public_ipv4"] == true,
+		GPU:       params["gpu"] == true,
+		Certified: params["certified_only"] == true,
+	}
+	if country := optionalString(params, "country"); country != nil {
+		spec.Country = *country
+	}
+	if region := optionalString(params, "region"); region != nil {
+		spec.Region = *region
+	}
+
+	for name, target := range map[string]*uint64{
+		"cpu":       &spec.CRU,
+		"memory_gb": &spec.MRUGB,
+		"ssd_gb":    &spec.SRUGB,
+		"hdd_gb":    &spec.HRUGB,
+	} {
+		value, err := optionalUint64(params, name)
+		if err != nil {
+			return spec, err
+		}
+		if value != nil {
+			*target = *value
+		}
+	}
+
+	if spec.CRU == 0 {
+		return spec, paramError("cpu", "cpu parameter is required")
+	}
+	if spec.MRUGB == 0 {
+		return spec, paramError("memory_gb", "memory_gb parameter is required")
+	}
+	return spec, nil
+}
+
+// nodeFilter returns the GridProxy filter for up nodes that fit the workload.
+// CPU is shared between workloads, so cores are matched against the node total.
+func (spec WorkloadSpec) nodeFilter() types.NodeFilter {
+	bytes := func(gb uint64) *uint64 {
+		if gb == 0 {
+			return nil
+		}
+		value := gb * gigabyte
+		return &value
+	}
+	optional := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+		return &value
+	}
+	enabled := func(value bool) *bool {
+		if !value {
+			return nil
+		}
+		return &value
+	}
+
+	filter := types.NodeFilter{
+		Status:   []string{"up"},
+		TotalCRU: &spec.CRU,
+		FreeMRU:  bytes(spec.MRUGB),
+		FreeSRU:  bytes(spec.SRUGB),
+		FreeHRU:  bytes(spec.HRUGB),
+		Country:  optional(spec.Country),
+		Region:   optional(spec.Region),
+		HasGPU:   enabled(spec.GPU),
+	}
+	if spec.PublicIP {
+		freeIPs := uint64(1)
+		filter.FreeIPs = &freeIPs
+	}
+	if spec.Certified {
+		filter.CertificationType = optional("Certified")
+	}
+	return filter
+}
+
+// fetchFarms returns the farms of the given nodes by ID. Each farm is
+// fetched once, with up to farmFetchConcurrency requests in flight.
+func (te *TaskExecutor) fetchFarms(ctx context.Context, nodes []types.Node) (map[int]types.Farm, error) {
+	var farmIDs []int
+	seen := make(map[int]bool)
+	for _, node := range nodes {
+		if !seen[node.FarmID] {
+			seen[node.FarmID] = true
+			farmIDs = append(farmIDs, node.FarmID)
+		}
+	}
+
+	found := make([]types.Farm, len(farmIDs))
+	errs := make([]error, len(farmIDs))
+	slots := make(chan struct{}, farmFetchConcurrency)
+	var wg sync.WaitGroup
+	for i, farmID := range farmIDs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i, farmID int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			found[i], errs[i] = te.fetchFarm(ctx, farmID)
+		}(i, farmID)
+	}
+	wg.Wait()
+
+	farms := make(map[int]types.Farm, len(farmIDs))
+	for i, farmID := range farmIDs {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		farms[farmID] = found[i]
+	}
+	return farms, nil
+}
+
+// fetchFarm returns the farm with the given ID, or a farm with only its ID
+// set when GridProxy does not know it
+func (te *TaskExecutor) fetchFarm(ctx context.Context, farmID int) (types.Farm, error) {
+	id := uint64(farmID)
+	found, _, err := te.gridClient.Farms(ctx, types.FarmFilter{FarmID: &id}, types.Limit{Size: 1, Page: 1})
+	if err != nil {
+		return types.Farm{}, fmt.Errorf("failed to fetch farm %d: %w", farmID, err)
+	}
+	if len(found) == 0 {
+		return types.Farm{FarmID: farmID}, nil
+	}
+	return found[0], nil
+}
+
+// score ranks a node for the workload and explains the ranking
+func (spec WorkloadSpec) score(node types.Node, farm types.Farm) CapacityCandidate {
+	free := func(total, used types.Unit) uint64 {
+		if used > total {
+			return 0
+		}
+		return uint64(total-used) / gigabyte
+	}
+
+	candidate := CapacityCandidate{
+		NodeID:   node.NodeID,
+		TwinID:   node.TwinID,
+		FarmID:   node.FarmID,
+		FarmName: farm.Name,
+		Country:  node.Country,
+		City:     node.City,
+		Free: CapacitySummary{
+			MRUGB: free(node.TotalResources.MRU, node.UsedResources.MRU),
+			SRUGB: free(node.TotalResources.SRU, node.UsedResources.SRU),
+			HRUGB: free(node.TotalResources.HRU, node.UsedResources.HRU),
+		},
+		UptimeDays:        math.Round(float64(node.Uptime)/(24*3600)*10) / 10,
+		Certification:     node.CertificationType,
+		FarmCertification: farm.CertificationType,
+	}
+	if node.UsedResources.CRU < node.TotalResources.CRU {
+		candidate.Free.CRU = node.TotalResources.CRU - node.UsedResources.CRU
+	}
+
+	// Headroom is the share of each requested resource left after deploying
+	headroom := func(free, total, required uint64) float64 {
+		if total == 0 || free < required {
+			return 0
+		}
+		return float64(free-required) / float64(total)
+	}
+	shares := []float64{
+		headroom(candidate.Free.CRU, node.TotalResources.CRU, spec.CRU),
+		headroom(candidate.Free.MRUGB, uint64(node.TotalResources.MRU)/gigabyte, spec.MRUGB),
+	}
+	if spec.SRUGB > 0 {
+		shares = append(shares, headroom(candidate.Free.SRUGB, uint64(node.TotalResources.SRU)/gigabyte, spec.SRUGB))
+	}
+	if spec.HRUGB > 0 {
+		shares = append(shares, headroom(candidate.Free.HRUGB, uint64(node.TotalResources.HRU)/gigabyte, spec.HRUGB))
+	}
+	var sum float64
+	for _, share := range shares {
+		sum += share
+	}
+	score := headroomWeight * sum / float64(len(shares))
+
+	uptime := time.Duration(node.Uptime) * time.Second
+	score += uptimeWeight * math.Min(float64(uptime)/float64(fullUptime), 1)
+
+	reasons := []string{
+		fmt.Sprintf("%d of %d cores free for %d requested", candidate.Free.CRU, node.TotalResources.CRU, spec.CRU),
+		fmt.Sprintf("%d GB memory free for %d GB requested", candidate.Free.MRUGB, spec.MRUGB),
+	}
+	if spec.SRUGB > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d GB SSD free for %d GB requested", candidate.Free.SRUGB, spec.SRUGB))
+	}
+	if spec.HRUGB > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d GB HDD free for %d GB requested", candidate.Free.HRUGB, spec.HRUGB))
+	}
+	reasons = append(reasons, fmt.Sprintf("up for %.1f days", candidate.UptimeDays))
+
+	if node.CertificationType == "Certified" {
+		score += certificationWeight / 2
+		reasons = append(reasons, "certified node")
+	}
+	if isCertifiedFarm(farm) {
+		score += certificationWeight / 2
+		reasons = append(reasons, fmt.Sprintf("%s certified farm", farm.CertificationType))
+	}
+
+	if spec.GPU {
+		reasons = append(reasons, fmt.Sprintf("%d GPU(s)", node.NumGPU))
+	}
+	if spec.PublicIP {
+		reasons = append(reasons, "farm has a free public IPv4")
+	}
+	if spec.Country != "" || spec.Region != "" {
+		reasons = append(reasons, fmt.Sprintf("located in %s", node.Country))
+	}
+	if node.InDedicatedFarm && !node.Rented {
+		reasons = append(reasons, "dedicated node: rent it before deploying")
+	}
+
+	candidate.Score = math.Round(score*10) / 10
+	candidate.Reasons = reasons
+	return candidate
+}
@@ -0,0 +1,146 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"anubis-executer/gridproxytest"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+)
+
+func TestFindCapacity(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alice := uint64(7)
+
+	tests := []struct {
+		name           string
+		params         map[string]interface{}
+		caller         *Caller
+		expectedNodes  []int
+		expectedScores []float64
+	}{
+		{"ranked by headroom", map[string]interface{}{"cpu": 4, "memory_gb": 8, "region": "Europe"}, nil, []int{12, 11}, []float64{41.6, 19.8}},
+		{"rented node of the caller", map[string]interface{}{"cpu": 4, "memory_gb": 8, "region": "Europe"}, &Caller{TwinID: &alice}, []int{31, 12, 11}, []float64{68.7, 41.6, 19.8}},
+		{"public IP and GPU", map[string]interface{}{"cpu": 4, "memory_gb": 8, "public_ipv4": true, "gpu": true}, nil, []int{21}, nil},
+		{"certified only", map[string]interface{}{"cpu": 4, "memory_gb": 8, "certified_only": true}, &Caller{TwinID: &alice}, []int{31}, nil},
+		{"limit", map[string]interface{}{"cpu": 4, "memory_gb": 8, "limit": 1}, nil, []int{12}, nil},
+		{"nothing fits", map[string]interface{}{"cpu": 128, "memory_gb": 8}, nil, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.ExecuteTask(context.Background(), Task{TaskName: "find_capacity", Params: tt.params, Caller: tt.caller})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			capacity := result.(FindCapacityResult)
+			var nodes []int
+			var scores []float64
+			for _, candidate := range capacity.Candidates {
+				nodes = append(nodes, candidate.NodeID)
+				scores = append(scores, candidate.Score)
+			}
+			if !slices.Equal(nodes, tt.expectedNodes) {
+				t.Errorf("expected nodes %v, got %v", tt.expectedNodes, nodes)
+			}
+			if tt.expectedScores != nil && !slices.Equal(scores, tt.expectedScores) {
+				t.Errorf("expected scores %v, got %v", tt.expectedScores, scores)
+			}
+		})
+	}
+}
+
+func TestFindCapacityScoresNodesWithMostFreeMemory(t *testing.T) {
+	dataset := gridproxytest.DefaultDataset()
+	small, large := dataset.Nodes[0], dataset.Nodes[1]
+	for nodeID := 100; nodeID < 100+capacityPoolSize; nodeID++ {
+		small.NodeID = nodeID
+		dataset.Nodes = append(dataset.Nodes, small)
+	}
+	// The node with the most free memory has the highest ID
+	large.NodeID = 1000
+	large.TotalResources.MRU = 512 * gigabyte
+	dataset.Nodes = append(dataset.Nodes, large)
+
+	gridProxy := gridproxytest.NewServer(dataset)
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{TaskName: "find_capacity", Params: map[string]interface{}{"cpu": 4, "memory_gb": 8}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	capacity := result.(FindCapacityResult)
+	if capacity.Matched != capacityPoolSize+4 || capacity.Scored != capacityPoolSize {
+		t.Errorf("expected %d matched and %d scored, got %d and %d", capacityPoolSize+4, capacityPoolSize, capacity.Matched, capacity.Scored)
+	}
+	if len(capacity.Candidates) == 0 || capacity.Candidates[0].NodeID != 1000 {
+		t.Errorf("expected node 1000 to rank first, got %+v", capacity.Candidates)
+	}
+}
+
+func TestFindCapacityReasons(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alice := uint64(7)
+
+	result, err := executor.ExecuteTask(context.Background(), Task{
+		TaskName: "find_capacity",
+		Params:   map[string]interface{}{"cpu": 4, "memory_gb": 8, "ssd_gb": 100, "country": "Germany"},
+		Caller:   &Caller{TwinID: &alice},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	candidates := result.(FindCapacityResult).Candidates
+	if len(candidates) != 1 {
+		t.Fatalf("expected node 31, got %+v", candidates)
+	}
+	expected := []string{
+		"64 of 64 cores free for 4 requested",
+		"256 GB memory free for 8 GB requested",
+		"4096 GB SSD free for 100 GB requested",
+		"up for 1.0 days",
+		"certified node",
+		"Gold certified farm",
+		"located in Germany",
+	}
+	if !slices.Equal(candidates[0].Reasons, expected) {
+		t.Errorf("expected reasons %q, got %q", expected, candidates[0].Reasons)
+	}
+	if candidates[0].FarmName != "BerlinDedicated" {
+		t.Errorf("expected the farm name, got %q", candidates[0].FarmName)
+	}
+}
+
+func TestFindCapacityRequiresWorkload(t *testing.T) {
+	executor := &TaskExecutor{gridClient: &MockGridClient{}, network: "test"}
+
+	_, err := executor.ExecuteTask(context.Background(), Task{TaskName: "find_capacity", Params: map[string]interface{}{"cpu": 4}})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Errors[0].Field != "memory_gb" {
+		t.Errorf("expected a memory_gb validation error, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// tftUnit is the number of on-chain units in one TFT
+const tftUnit = 1e7
+
+// listContracts returns contracts filtered by twin, node, type and state.
+// Without a twin or node filter, an authenticated caller gets their own contracts.
+func (te *TaskExecutor) listContracts(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing listContracts task")
+
+	filter := types.ContractFilter{
+		Type:  optionalString(params, "type"),
+		State: stringSlice(params, "state"),
+	}
+
+	var err error
+	if filter.TwinID, err = optionalUint64(params, "twin_id"); err != nil {
+		return nil, err
+	}
+	if filter.NodeID, err = optionalUint64(params, "node_id"); err != nil {
+		return nil, err
+	}
+	if filter.TwinID == nil && filter.NodeID == nil {
+		if filter.TwinID, err = optionalUint64(params, callerTwinIDParam); err != nil {
+			return nil, err
+		}
+	}
+
+	opts, err := te.listOptionsFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call(s)
+	result, err := listPages(ctx, te, opts, func(ctx context.Context, limit types.Limit) ([]types.Contract, int, error) {
+		return te.gridClient.Contracts(ctx, filter, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contracts: %w", err)
+	}
+
+	return result, nil
+}
+
+// getContract returns details of a specific contract
+func (te *TaskExecutor) getContract(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing getContract task")
+
+	contractID, err := contractIDParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call
+	contract, err := te.gridClient.Contract(ctx, contractID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, notFoundError("contract with ID %d not found", contractID)
+		}
+		return nil, fmt.Errorf("failed to fetch contract: %w", err)
+	}
+
+	if contract.ContractID == 0 {
+		return nil, notFoundError("contract with ID %d not found", contractID)
+	}
+
+	return contract, nil
+}
+
+// ContractBillsResult is a page of a contract's bills with billed totals in TFT
+type ContractBillsResult struct {
+	ListResult[types.ContractBilling]
+	ContractID     uint32   `json:"contract_id"`
+	PageBilledTFT  float64  `json:"page_billed_tft"`            // Billed by the returned bills
+	TotalBilledTFT *float64 `json:"total_billed_tft,omitempty"` // Billed over the whole history
+	TotalsComplete *bool    `json:"totals_complete,omitempty"`  // False when the history walk hit its page cap
+}
+
+// contractBills returns a page of a contract's billing history with billed totals in TFT
+func (te *TaskExecutor) contractBills(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing contractBills task")
+
+	contractID, err := contractIDParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := te.listOptionsFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call(s)
+	bills, err := listPages(ctx, te, opts, te.fetchContractBills(contractID))
+	if err != nil {
+		if isNotFound(err) {
+			return nil, notFoundError("contract with ID %d not found", contractID)
+		}
+		return nil, fmt.Errorf("failed to fetch contract bills: %w", err)
+	}
+
+	response := ContractBillsResult{
+		ListResult:    bills,
+		ContractID:    contractID,
+		PageBilledTFT: billedTFT(bills.Items),
+	}
+
+	// Walking the full billing history takes up to maxWalkPages requests, so only on request
+	if includeTotals, _ := params["include_totals"].(bool); includeTotals {
+		total, complete, err := te.totalContractBilled(ctx, contractID)
+		if err != nil {
+			return nil, err
+		}
+		response.TotalBilledTFT = &total
+		response.TotalsComplete = &complete
+	}
+
+	return response, nil
+}
+
+// totalContractBilled sums every bill of a contract, stopping after maxWalkPages.
+// The returned flag is false when the cap was reached before the last page.
+func (te *TaskExecutor) totalContractBilled(ctx context.Context, contractID uint32) (float64, bool, error) {
+	var billed uint64
+	complete, err := walkPages(ctx, te.fetchContractBills(contractID), func(bill types.ContractBilling) {
+		billed += bill.AmountBilled
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to fetch contract bills: %w", err)
+	}
+
+	return float64(billed) / tftUnit, complete, nil
+}
+
+// fetchContractBills adapts ContractBills, which counts bills as a uint, to
+// the page fetchers used by listPages and walkPages
+func (te *TaskExecutor) fetchContractBills(contractID uint32) func(ctx context.Context, limit types.Limit) ([]types.ContractBilling, int, error) {
+	return func(ctx context.Context, limit types.Limit) ([]types.ContractBilling, int, error) {
+		bills, totalCount, err := te.gridClient.ContractBills(ctx, contractID, limit)
+		return bills, int(totalCount), err
+	}
+}
+
+// billedTFT sums the billed amounts of bills in TFT
+func billedTFT(bills []types.ContractBilling) float64 {
+	var total uint64
+	for _, bill := range bills {
+		total += bill.AmountBilled
+	}
+	return float64(total) / tftUnit
+}
+
+// contractIDParam extracts the required contract_id parameter
+func contractIDParam(params map[string]interface{}) (uint32, error) {
+	contractIDParam, ok := params["contract_id"]
+	if !ok {
+		return 0, paramError("contract_id", "contract_id parameter is required")
+	}
+
+	contractID, err := parseUint64(contractIDParam)
+	if err != nil {
+		return 0, paramError("contract_id", "invalid contract_id format: %v", err)
+	}
+	if contractID > math.MaxUint32 {
+		return 0, paramError("contract_id", "contract_id must be at most %d, got: %d", math.MaxUint32, contractID)
+	}
+
+	return uint32(contractID), nil
+}
@@ -0,0 +1,215 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func TestListContracts(t *testing.T) {
+	mockContracts := []types.Contract{
+		{ContractID: 1, TwinID: 42, Type: "node", State: "Created"},
+		{ContractID: 2, TwinID: 42, Type: "name", State: "Deleted"},
+		{ContractID: 3, TwinID: 7, Type: "rent", State: "Created"},
+	}
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		mockError     error
+		expectedIDs   []uint
+		expectedError bool
+	}{
+		{
+			name:        "list all contracts",
+			params:      map[string]interface{}{},
+			expectedIDs: []uint{1, 2, 3},
+		},
+		{
+			name:        "filter by twin",
+			params:      map[string]interface{}{"twin_id": float64(42)},
+			expectedIDs: []uint{1, 2},
+		},
+		{
+			name:        "filter by twin and state",
+			params:      map[string]interface{}{"twin_id": float64(42), "state": []interface{}{"Created"}},
+			expectedIDs: []uint{1},
+		},
+		{
+			name:        "caller's contracts by default",
+			params:      map[string]interface{}{"state": []interface{}{"Created"}, callerTwinIDParam: uint64(42)},
+			expectedIDs: []uint{1},
+		},
+		{
+			name:        "explicit twin over caller",
+			params:      map[string]interface{}{"twin_id": float64(7), callerTwinIDParam: uint64(42)},
+			expectedIDs: []uint{3},
+		},
+		{
+			name:        "node filter is not scoped to the caller",
+			params:      map[string]interface{}{"node_id": float64(11), callerTwinIDParam: uint64(42)},
+			expectedIDs: []uint{1, 2, 3},
+		},
+		{
+			name:        "filter by type",
+			params:      map[string]interface{}{"type": "rent"},
+			expectedIDs: []uint{3},
+		},
+		{
+			name:          "invalid twin_id",
+			params:        map[string]interface{}{"twin_id": "abc"},
+			expectedError: true,
+		},
+		{
+			name:          "API error",
+			params:        map[string]interface{}{},
+			mockError:     errors.New("API error"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &TaskExecutor{
+				gridClient: &MockGridClient{
+					contracts: mockContracts,
+					err:       tt.mockError,
+				},
+				network: "test",
+			}
+
+			result, err := executor.listContracts(context.Background(), tt.params)
+
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response, ok := result.(ListResult[types.Contract])
+			if !ok {
+				t.Fatalf("expected ListResult[types.Contract], got %T", result)
+			}
+			contracts := response.Items
+
+			if len(contracts) != len(tt.expectedIDs) {
+				t.Fatalf("expected %d contracts, got %d", len(tt.expectedIDs), len(contracts))
+			}
+			for i, id := range tt.expectedIDs {
+				if contracts[i].ContractID != id {
+					t.Errorf("expected contract %d at index %d, got %d", id, i, contracts[i].ContractID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetContract(t *testing.T) {
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{
+			contracts: []types.Contract{{ContractID: 5, TwinID: 42, Type: "node", State: "Created"}},
+		},
+		network: "test",
+	}
+
+	result, err := executor.getContract(context.Background(), map[string]interface{}{"contract_id": float64(5)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if contract, ok := result.(types.Contract); !ok || contract.ContractID != 5 {
+		t.Errorf("expected contract 5, got %v", result)
+	}
+
+	_, err = executor.getContract(context.Background(), map[string]interface{}{"contract_id": float64(6)})
+	if err == nil || err.Error() != "contract with ID 6 not found" {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	_, err = executor.getContract(context.Background(), map[string]interface{}{})
+	if err == nil || err.Error() != "contract_id parameter is required" {
+		t.Errorf("expected missing parameter error, got %v", err)
+	}
+
+	// IDs above 2^32-1 would otherwise wrap around to a different contract
+	_, err = executor.getContract(context.Background(), map[string]interface{}{"contract_id": float64(1<<32 + 5)})
+	if err == nil || err.Error() != "contract_id must be at most 4294967295, got: 4294967301" {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+}
+
+func TestContractBills(t *testing.T) {
+	// 7 bills of 2 TFT each: page 1 holds 5 of them
+	var bills []types.ContractBilling
+	for i := 0; i < 7; i++ {
+		bills = append(bills, types.ContractBilling{AmountBilled: 2 * tftUnit, Timestamp: uint64(1700000000 + i*3600)})
+	}
+
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{
+			bills: map[uint32][]types.ContractBilling{9: bills},
+		},
+		network: "test",
+	}
+
+	tests := []struct {
+		name            string
+		params          map[string]interface{}
+		expectedBills   int
+		expectedPageTFT float64
+		expectTotals    bool
+	}{
+		{
+			name:            "first page with totals",
+			params:          map[string]interface{}{"contract_id": float64(9), "include_totals": true},
+			expectedBills:   5,
+			expectedPageTFT: 10,
+			expectTotals:    true,
+		},
+		{
+			name:            "totals are opt-in",
+			params:          map[string]interface{}{"contract_id": float64(9), "page": float64(2)},
+			expectedBills:   2,
+			expectedPageTFT: 4,
+			expectTotals:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.contractBills(context.Background(), tt.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response := result.(ContractBillsResult)
+			pageBills := response.Items
+			if len(pageBills) != tt.expectedBills {
+				t.Errorf("expected %d bills, got %d", tt.expectedBills, len(pageBills))
+			}
+
+			if response.PageBilledTFT != tt.expectedPageTFT {
+				t.Errorf("expected page total %v, got %v", tt.expectedPageTFT, response.PageBilledTFT)
+			}
+
+			hasTotal := response.TotalBilledTFT != nil
+			if hasTotal != tt.expectTotals {
+				t.Fatalf("expected totals present=%v, got %v", tt.expectTotals, hasTotal)
+			}
+			if tt.expectTotals {
+				if *response.TotalBilledTFT != float64(14) {
+					t.Errorf("expected total billed 14 TFT, got %v", *response.TotalBilledTFT)
+				}
+				if response.TotalsComplete == nil || !*response.TotalsComplete {
+					t.Errorf("expected totals to be complete")
+				}
+			}
+		})
+	}
+}
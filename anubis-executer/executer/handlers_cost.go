@@ -0,0 +1,263 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+
+	"anubis-executer/schema"
+)
+
+// hoursPerMonth is the month length the grid bills by
+const hoursPerMonth = 24 * 30
+
+// usdUnit is the number of pricing policy units in one USD
+const usdUnit = 1e7
+
+// certifiedMarkup is the price factor of capacity on certified nodes
+const certifiedMarkup = 1.25
+
+// PricingPolicy holds the on-chain prices of a farm, in units of 1e-7 USD per hour
+type PricingPolicy struct {
+	ID                int     `json:"id"`
+	Name              string  `json:"name"`
+	CU                uint64  `json:"cu"`                 // Per compute unit
+	SU                uint64  `json:"su"`                 // Per storage unit
+	NU                uint64  `json:"nu"`                 // Per GB of public traffic
+	IPU               uint64  `json:"ipu"`                // Per public IPv4
+	UniqueName        uint64  `json:"unique_name"`        // Per name contract
+	DomainName        uint64  `json:"domain_name"`        // Per custom domain
+	DedicatedDiscount float64 `json:"dedicated_discount"` // Percent off capacity on rented nodes
+}
+
+// DiscountTier is a discount for holding enough TFT to pay for some months of a deployment
+type DiscountTier struct {
+	Name          string   `json:"name"`
+	Percent       float64  `json:"percent"`
+	MinMonths     float64  `json:"min_months"`                // Months of cost the balance must cover
+	MinBalanceTFT *float64 `json:"min_balance_tft,omitempty"` // Set when tft_price is given
+	MonthlyUSD    float64  `json:"monthly_usd"`
+	MonthlyTFT    *float64 `json:"monthly_tft,omitempty"`
+}
+
+// discountTiers are the grid's discount levels, smallest first
+var discountTiers = []DiscountTier{
+	{Name: "none", Percent: 0, MinMonths: 0},
+	{Name: "default", Percent: 20, MinMonths: 1.5},
+	{Name: "bronze", Percent: 30, MinMonths: 3},
+	{Name: "silver", Percent: 40, MinMonths: 6},
+	{Name: "gold", Percent: 60, MinMonths: 18},
+}
+
+// CostUnits are the billable units of a deployment
+type CostUnits struct {
+	CU            float64 `json:"cu"`
+	SU            float64 `json:"su"`
+	PublicIPs     uint64  `json:"public_ips"`
+	NameContracts uint64  `json:"name_contracts"`
+}
+
+// CostBreakdown is the monthly cost of a deployment in USD, before discounts
+type CostBreakdown struct {
+	Compute       float64 `json:"compute"`
+	Storage       float64 `json:"storage"`
+	PublicIPs     float64 `json:"public_ips"`
+	NameContracts float64 `json:"name_contracts"`
+	ExtraFee      float64 `json:"extra_fee"` // Set by the farmer on dedicated nodes
+	Total         float64 `json:"total"`
+}
+
+// CostEstimate is the monthly cost of a deployment under a farm's pricing policy
+type CostEstimate struct {
+	NodeID        int            `json:"node_id,omitempty"`
+	FarmID        int            `json:"farm_id"`
+	PricingPolicy PricingPolicy  `json:"pricing_policy"`
+	Certified     bool           `json:"certified"` // Capacity on certified nodes costs 25% more
+	Dedicated     bool           `json:"dedicated"` // The whole node is rented
+	Resources     WorkloadSpec   `json:"resources"` // Capacity that is billed
+	Units         CostUnits      `json:"units"`
+	MonthlyUSD    CostBreakdown  `json:"monthly_usd"`
+	TFTPrice      float64        `json:"tft_price,omitempty"`
+	MonthlyTFT    *float64       `json:"monthly_tft,omitempty"`
+	Discounts     []DiscountTier `json:"discounts"`
+	Discount      string         `json:"discount,omitempty"` // Tier reached by balance_tft
+	Notes         []string       `json:"notes,omitempty"`
+	Network       string         `json:"network"`
+}
+
+// estimateCost prices a deployment with the pricing policy of the farm it runs on
+func (te *TaskExecutor) estimateCost(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing estimateCost task")
+
+	var spec WorkloadSpec
+	for name, target := range map[string]*uint64{
+		"cpu":       &spec.CRU,
+		"memory_gb": &spec.MRUGB,
+		"ssd_gb":    &spec.SRUGB,
+		"hdd_gb":    &spec.HRUGB,
+	} {
+		value, err := optionalUint64(params, name)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			*target = *value
+		}
+	}
+
+	var units CostUnits
+	for name, target := range map[string]*uint64{
+		"public_ips":     &units.PublicIPs,
+		"name_contracts": &units.NameContracts,
+	} {
+		value, err := optionalUint64(params, name)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			*target = *value
+		}
+	}
+
+	nodeID, err := optionalUint64(params, "node_id")
+	if err != nil {
+		return nil, err
+	}
+	farmID, err := optionalUint64(params, "farm_id")
+	if err != nil {
+		return nil, err
+	}
+	dedicated := params["dedicated"] == true
+	if nodeID == nil && farmID == nil {
+		return nil, paramError("node_id", "node_id or farm_id is required")
+	}
+	if dedicated && nodeID == nil {
+		return nil, paramError("node_id", "node_id is required to price a dedicated node")
+	}
+
+	estimate := CostEstimate{Dedicated: dedicated, Network: te.network}
+
+	var node types.Node
+	if nodeID != nil {
+		nodes, _, err := te.gridClient.Nodes(ctx, types.NodeFilter{NodeID: nodeID}, types.Limit{Size: 1, Page: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch node: %w", err)
+		}
+		if len(nodes) == 0 {
+			return nil, notFoundError("node with ID %d not found", *nodeID)
+		}
+		node = nodes[0]
+		if farmID != nil && uint64(node.FarmID) != *farmID {
+			return nil, paramError("farm_id", "node %d is in farm %d, not farm %d", node.NodeID, node.FarmID, *farmID)
+		}
+		farm := uint64(node.FarmID)
+		farmID = &farm
+		estimate.NodeID = node.NodeID
+		estimate.Certified = node.CertificationType == "Certified"
+	} else {
+		estimate.Notes = append(estimate.Notes, "no node_id given: priced as an uncertified node, certified nodes cost 25% more")
+	}
+
+	farms, _, err := te.gridClient.Farms(ctx, types.FarmFilter{FarmID: farmID}, types.Limit{Size: 1, Page: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch farm: %w", err)
+	}
+	if len(farms) == 0 {
+		return nil, notFoundError("farm with ID %d not found", *farmID)
+	}
+	estimate.FarmID = farms[0].FarmID
+
+	if te.pricing == nil {
+		return nil, fmt.Errorf("no pricing policy source configured")
+	}
+	policy, err := te.pricing.PricingPolicy(ctx, farms[0].PricingPolicyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve pricing policy %d of farm %d: %w", farms[0].PricingPolicyID, estimate.FarmID, err)
+	}
+	estimate.PricingPolicy = policy
+
+	// Renting a node bills all of its capacity, at the dedication discount
+	capacityFactor := 1.0
+	if estimate.Certified {
+		capacityFactor = certifiedMarkup
+	}
+	if dedicated {
+		spec = WorkloadSpec{
+			CRU:   node.TotalResources.CRU,
+			MRUGB: uint64(node.TotalResources.MRU) / gigabyte,
+			SRUGB: uint64(node.TotalResources.SRU) / gigabyte,
+			HRUGB: uint64(node.TotalResources.HRU) / gigabyte,
+		}
+		capacityFactor *= 1 - policy.DedicatedDiscount/100
+		estimate.MonthlyUSD.ExtraFee = float64(node.ExtraFee) / 1000
+		if node.Rented {
+			estimate.Notes = append(estimate.Notes, fmt.Sprintf("node %d is already rented by twin %d", node.NodeID, node.RentedByTwinID))
+		}
+	}
+	estimate.Resources = spec
+
+	units.CU, units.SU = spec.cloudUnits()
+	estimate.Units = units
+
+	monthly := func(price uint64, quantity, factor float64) float64 {
+		return float64(price) * quantity * factor * hoursPerMonth / usdUnit
+	}
+	breakdown := &estimate.MonthlyUSD
+	breakdown.Compute = monthly(policy.CU, units.CU, capacityFactor)
+	breakdown.Storage = monthly(policy.SU, units.SU, capacityFactor)
+	breakdown.PublicIPs = monthly(policy.IPU, float64(units.PublicIPs), 1)
+	breakdown.NameContracts = monthly(policy.UniqueName, float64(units.NameContracts), 1)
+	breakdown.Total = breakdown.Compute + breakdown.Storage + breakdown.PublicIPs + breakdown.NameContracts + breakdown.ExtraFee
+
+	tftPrice, _ := schema.ToFloat64(params["tft_price"])
+	balanceTFT, hasBalance := schema.ToFloat64(params["balance_tft"])
+	if hasBalance && tftPrice == 0 {
+		return nil, paramError("tft_price", "tft_price is required to apply a balance_tft discount")
+	}
+	if tftPrice > 0 {
+		estimate.TFTPrice = tftPrice
+		total := roundCents(breakdown.Total / tftPrice)
+		estimate.MonthlyTFT = &total
+	} else {
+		estimate.Notes = append(estimate.Notes, "no tft_price given: costs are in USD only")
+	}
+	estimate.Notes = append(estimate.Notes, "public traffic is billed separately per GB")
+
+	estimate.Discounts = make([]DiscountTier, 0, len(discountTiers))
+	for _, tier := range discountTiers {
+		tier.MonthlyUSD = roundCents(breakdown.Total * (1 - tier.Percent/100))
+		if tftPrice > 0 {
+			monthlyTFT := roundCents(tier.MonthlyUSD / tftPrice)
+			minBalance := roundCents(breakdown.Total / tftPrice * tier.MinMonths)
+			tier.MonthlyTFT = &monthlyTFT
+			tier.MinBalanceTFT = &minBalance
+			if hasBalance && balanceTFT*tftPrice >= breakdown.Total*tier.MinMonths {
+				estimate.Discount = tier.Name
+			}
+		}
+		estimate.Discounts = append(estimate.Discounts, tier)
+	}
+
+	breakdown.Compute = roundCents(breakdown.Compute)
+	breakdown.Storage = roundCents(breakdown.Storage)
+	breakdown.PublicIPs = roundCents(breakdown.PublicIPs)
+	breakdown.NameContracts = roundCents(breakdown.NameContracts)
+	breakdown.Total = roundCents(breakdown.Total)
+	return estimate, nil
+}
+
+// cloudUnits converts the capacity to compute and storage units. A CU is the
+// cheapest of the three ways the grid sizes one; an SU is 1200 GB HDD or 200 GB SSD.
+func (spec WorkloadSpec) cloudUnits() (cu, su float64) {
+	cru, mru := float64(spec.CRU), float64(spec.MRUGB)
+	cu = math.Min(math.Max(mru/4, cru/2), math.Min(math.Max(mru/8, cru), math.Max(mru/2, cru/4)))
+	su = float64(spec.HRUGB)/1200 + float64(spec.SRUGB)/200
+	return math.Round(cu*1000) / 1000, math.Round(su*1000) / 1000
+}
+
+// roundCents rounds an amount to two decimals
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,220 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"anubis-executer/gridproxytest"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+)
+
+// testPricingPolicies holds the chain's default pricing policy
+var testPricingPolicies = PricingPolicies{
+	1: {
+		ID:                1,
+		Name:              "threefold_default_pricing_policy",
+		CU:                100000,
+		SU:                50000,
+		NU:                15000,
+		IPU:               40000,
+		UniqueName:        2500,
+		DomainName:        5000,
+		DedicatedDiscount: 50,
+	},
+}
+
+func TestEstimateCost(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithPricingPolicies(testPricingPolicies), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{
+		TaskName: "estimate_cost",
+		Params:   map[string]interface{}{"cpu": 4, "memory_gb": 8, "ssd_gb": 50, "public_ips": 1, "node_id": 11, "tft_price": 0.01, "balance_tft": 6000},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	estimate := result.(CostEstimate)
+	if estimate.FarmID != 1 || estimate.PricingPolicy.ID != 1 || estimate.Certified || estimate.Dedicated {
+		t.Errorf("expected an uncertified node in farm 1 under policy 1, got %+v", estimate)
+	}
+	if estimate.Units != (CostUnits{CU: 2, SU: 0.25, PublicIPs: 1}) {
+		t.Errorf("unexpected units %+v", estimate.Units)
+	}
+	expected := CostBreakdown{Compute: 14.4, Storage: 0.9, PublicIPs: 2.88, Total: 18.18}
+	if estimate.MonthlyUSD != expected {
+		t.Errorf("expected %+v, got %+v", expected, estimate.MonthlyUSD)
+	}
+	if estimate.MonthlyTFT == nil || *estimate.MonthlyTFT != 1818 {
+		t.Errorf("expected 1818 TFT a month, got %v", estimate.MonthlyTFT)
+	}
+
+	if len(estimate.Discounts) != len(discountTiers) {
+		t.Fatalf("expected every discount tier, got %+v", estimate.Discounts)
+	}
+	gold := estimate.Discounts[len(estimate.Discounts)-1]
+	if gold.Name != "gold" || gold.MonthlyUSD != 7.27 || *gold.MonthlyTFT != 727 || *gold.MinBalanceTFT != 32724 {
+		t.Errorf("unexpected gold tier %+v", gold)
+	}
+	if estimate.Discount != "bronze" {
+		t.Errorf("expected 6000 TFT to reach bronze, got %q", estimate.Discount)
+	}
+}
+
+func TestEstimateCostDedicatedNode(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithPricingPolicies(testPricingPolicies), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{
+		TaskName: "estimate_cost",
+		Params:   map[string]interface{}{"node_id": 31, "dedicated": true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The whole certified node is billed at half price, plus the farmer's extra fee
+	estimate := result.(CostEstimate)
+	expected := CostBreakdown{Compute: 288, Storage: 76.08, ExtraFee: 20, Total: 384.08}
+	if !estimate.Certified || estimate.Resources.CRU != 64 || estimate.MonthlyUSD != expected {
+		t.Errorf("expected %+v for all of node 31, got %+v", expected, estimate)
+	}
+	if estimate.MonthlyTFT != nil || estimate.Discount != "" {
+		t.Errorf("expected USD only without tft_price, got %+v", estimate)
+	}
+}
+
+func TestEstimateCostErrors(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithPricingPolicies(testPricingPolicies), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		expectedCode  ErrorCode
+		expectedField string
+	}{
+		{"no node or farm", map[string]interface{}{"cpu": 2, "memory_gb": 4}, CodeInvalidParams, "node_id"},
+		{"dedicated farm", map[string]interface{}{"farm_id": 3, "dedicated": true}, CodeInvalidParams, "node_id"},
+		{"node in another farm", map[string]interface{}{"cpu": 2, "node_id": 11, "farm_id": 2}, CodeInvalidParams, "farm_id"},
+		{"balance without price", map[string]interface{}{"cpu": 2, "farm_id": 1, "balance_tft": 100}, CodeInvalidParams, "tft_price"},
+		{"unknown node", map[string]interface{}{"cpu": 2, "node_id": 99}, CodeNotFound, ""},
+		{"unknown farm", map[string]interface{}{"cpu": 2, "farm_id": 99}, CodeNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executor.ExecuteTask(context.Background(), Task{TaskName: "estimate_cost", Params: tt.params})
+
+			var taskErr *TaskError
+			if !errors.As(err, &taskErr) || taskErr.Code != tt.expectedCode || taskErr.Field != tt.expectedField {
+				t.Errorf("expected a %s error on %q, got %v", tt.expectedCode, tt.expectedField, err)
+			}
+		})
+	}
+}
+
+func TestEstimateCostUnknownPricingPolicy(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithPricingPolicies(PricingPolicies{}), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = executor.ExecuteTask(context.Background(), Task{TaskName: "estimate_cost", Params: map[string]interface{}{"cpu": 2, "farm_id": 1}})
+	if ClassifyError(err).Code != CodeNotFound || err.Error() != "failed to resolve pricing policy 1 of farm 1: pricing policy 1 not found" {
+		t.Errorf("expected the unknown policy to be reported, got %v", err)
+	}
+}
+
+func TestGraphQLPricingPolicies(t *testing.T) {
+	var requests int
+	indexer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var request struct {
+			Variables struct {
+				ID int `json:"id"`
+			} `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		switch request.Variables.ID {
+		case 1:
+			w.Write([]byte(`{"data": {"pricingPolicies": [{"pricingPolicyID": 1, "name": "threefold_default_pricing_policy",
+				"cu": {"value": 100000}, "su": {"value": 50000}, "nu": {"value": 15000}, "ipu": {"value": 40000},
+				"uniqueName": {"value": 2500}, "domainName": {"value": "5000"}, "dedicatedNodeDiscount": 50}]}}`))
+		case 2:
+			w.Write([]byte(`{"data": {"pricingPolicies": []}}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer indexer.Close()
+
+	source := NewGraphQLPricingPolicies(indexer.URL, nil)
+	for i := 0; i < 2; i++ {
+		policy, err := source.PricingPolicy(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if policy != testPricingPolicies[1] {
+			t.Errorf("expected %+v, got %+v", testPricingPolicies[1], policy)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the policy to be cached, the indexer saw %d requests", requests)
+	}
+
+	if _, err := source.PricingPolicy(context.Background(), 2); ClassifyError(err).Code != CodeNotFound {
+		t.Errorf("expected a missing policy to be not found, got %v", err)
+	}
+
+	_, err := source.PricingPolicy(context.Background(), 3)
+	details := ClassifyError(err)
+	if details.Code != CodeUpstreamUnavailable || !details.Retryable || details.Upstream == nil || details.Upstream.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected a retryable upstream failure, got %+v", details)
+	}
+}
+
+func TestCloudUnits(t *testing.T) {
+	tests := []struct {
+		spec       WorkloadSpec
+		expectedCU float64
+		expectedSU float64
+	}{
+		{WorkloadSpec{CRU: 1, MRUGB: 2}, 0.5, 0},
+		{WorkloadSpec{CRU: 4, MRUGB: 8, SRUGB: 50}, 2, 0.25},
+		{WorkloadSpec{CRU: 1, MRUGB: 32}, 4, 0},
+		{WorkloadSpec{CRU: 16, MRUGB: 4, HRUGB: 2400, SRUGB: 200}, 4, 3},
+	}
+
+	for _, tt := range tests {
+		cu, su := tt.spec.cloudUnits()
+		if cu != tt.expectedCU || su != tt.expectedSU {
+			t.Errorf("%+v: expected %v CU and %v SU, got %v and %v", tt.spec, tt.expectedCU, tt.expectedSU, cu, su)
+		}
+	}
+}
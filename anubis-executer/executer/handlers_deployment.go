@@ -0,0 +1,313 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// SSHKeyPlaceholder stands in for the user's SSH key in a deployment plan
+// that was made without one; the signing step must replace it
+const SSHKeyPlaceholder = "<SSH_PUBLIC_KEY>"
+
+// Private network of a planned VM: the network's range, the node's subnet in
+// it and the VM's address in the subnet
+const (
+	planIPRange = "10.20.0.0/16"
+	planSubnet  = "10.20.2.0/24"
+	planVMIP    = "10.20.2.2"
+)
+
+// vmImage is an official VM image and how to boot it
+type vmImage struct {
+	flist      string
+	entrypoint string
+}
+
+// vmImages are the images plan_vm_deployment accepts by name
+var vmImages = map[string]vmImage{
+	"ubuntu-22.04": {"https://hub.grid.tf/tf-official-apps/threefoldtech-ubuntu-22.04.flist", "/sbin/zinit init"},
+	"alpine":       {"https://hub.grid.tf/tf-official-apps/base:latest.flist", "/sbin/zinit init"},
+	"nixos":        {"https://hub.grid.tf/tf-official-vms/nixos-micro-latest.flist", "/sbin/zinit init"},
+}
+
+// vmName matches the names the grid accepts for VMs and disks
+var vmName = regexp.MustCompile(`^[a-zA-Z0-9_]{1,50}$`)
+
+// DeploymentPlan describes a VM deployment for a later signing step.
+// Nothing is submitted to the chain or the node while planning.
+type DeploymentPlan struct {
+	Network    string            `json:"network"`
+	Node       CapacityCandidate `json:"node"`
+	Deployment VMDeployment      `json:"deployment"`
+	Warnings   []string          `json:"warnings,omitempty"`
+	Submitted  bool              `json:"submitted"` // Always false
+}
+
+// VMDeployment is everything a signer needs to create the VM's contract and deployment
+type VMDeployment struct {
+	Name      string         `json:"name"`
+	NodeID    int            `json:"node_id"`
+	NodeTwin  int            `json:"node_twin_id"`
+	Network   NetworkSpec    `json:"network"`
+	VM        VMSpec         `json:"vm"`
+	Disks     []DiskSpec     `json:"disks"`
+	Contracts []ContractSpec `json:"contracts"`
+}
+
+// NetworkSpec is the private network the VM joins
+type NetworkSpec struct {
+	Name               string `json:"name"`
+	IPRange            string `json:"ip_range"`
+	Subnet             string `json:"subnet"` // The node's part of IPRange
+	AddWireguardAccess bool   `json:"add_wireguard_access"`
+	Mycelium           bool   `json:"mycelium"`
+}
+
+// VMSpec is the VM workload
+type VMSpec struct {
+	Name         string            `json:"name"`
+	Flist        string            `json:"flist"`
+	Entrypoint   string            `json:"entrypoint"`
+	CPU          uint64            `json:"cpu"`
+	MemoryMB     uint64            `json:"memory_mb"`
+	RootfsSizeMB uint64            `json:"rootfs_size_mb"`
+	IP           string            `json:"ip"` // Address in the network's subnet
+	PublicIP4    bool              `json:"public_ip4"`
+	PublicIP6    bool              `json:"public_ip6"`
+	Mycelium     bool              `json:"mycelium"`
+	EnvVars      map[string]string `json:"env_vars"`
+	Mounts       []MountSpec       `json:"mounts"`
+}
+
+// DiskSpec is a data disk workload
+type DiskSpec struct {
+	Name   string `json:"name"`
+	SizeGB uint64 `json:"size_gb"`
+}
+
+// MountSpec mounts a disk into the VM
+type MountSpec struct {
+	Disk       string `json:"disk"`
+	MountPoint string `json:"mount_point"`
+}
+
+// ContractSpec is a contract the signer creates for the deployment
+type ContractSpec struct {
+	Type      string `json:"type"`
+	NodeID    int    `json:"node_id"`
+	PublicIPs int    `json:"public_ips"` // Reserved from the farm's free IPs
+}
+
+// planVMDeployment picks a node for a VM, through a capacity search or the
+// given node_id, and returns the deployment a signer would submit
+func (te *TaskExecutor) planVMDeployment(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing planVMDeployment task")
+
+	name, _ := params["name"].(string)
+	if !vmName.MatchString(name) {
+		return nil, paramError("name", "name must be 1 to 50 letters, digits or underscores, got: %q", name)
+	}
+
+	image, err := vmImageFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := workloadSpecFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	rootfsGB, _ := optionalUint64(params, "rootfs_gb")
+	diskGB, _ := optionalUint64(params, "disk_gb")
+	if rootfsGB != nil {
+		spec.SRUGB += *rootfsGB
+	}
+	if diskGB != nil {
+		spec.SRUGB += *diskGB
+	}
+
+	var warnings []string
+	sshKey, _ := params["ssh_key"].(string)
+	switch {
+	case sshKey == "":
+		sshKey = SSHKeyPlaceholder
+		warnings = append(warnings, fmt.Sprintf("no ssh_key given: replace %s in env_vars.SSH_KEY before signing", SSHKeyPlaceholder))
+	case !strings.HasPrefix(sshKey, "ssh-") && !strings.HasPrefix(sshKey, "ecdsa-"):
+		return nil, paramError("ssh_key", "ssh_key must be an OpenSSH public key such as 'ssh-ed25519 AAAA...'")
+	}
+
+	var callerTwinID *uint64
+	if twinID, err := optionalUint64(params, callerTwinIDParam); err == nil {
+		callerTwinID = twinID
+	}
+
+	node, err := te.pickNode(ctx, spec, params, callerTwinID)
+	if err != nil {
+		return nil, err
+	}
+
+	mycelium := params["mycelium"] != false
+	deployment := VMDeployment{
+		Name:     name,
+		NodeID:   node.NodeID,
+		NodeTwin: node.TwinID,
+		Network: NetworkSpec{
+			Name:     name + "_net",
+			IPRange:  planIPRange,
+			Subnet:   planSubnet,
+			Mycelium: mycelium,
+		},
+		VM: VMSpec{
+			Name:       name,
+			Flist:      image.flist,
+			Entrypoint: image.entrypoint,
+			CPU:        spec.CRU,
+			MemoryMB:   spec.MRUGB * 1024,
+			IP:         planVMIP,
+			PublicIP4:  spec.PublicIP,
+			PublicIP6:  params["public_ipv6"] == true,
+			Mycelium:   mycelium,
+			EnvVars:    map[string]string{"SSH_KEY": sshKey},
+			Mounts:     []MountSpec{},
+		},
+		Disks:     []DiskSpec{},
+		Contracts: []ContractSpec{{Type: "node", NodeID: node.NodeID}},
+	}
+	if rootfsGB != nil {
+		deployment.VM.RootfsSizeMB = *rootfsGB * 1024
+	}
+	if diskGB != nil && *diskGB > 0 {
+		disk := name + "_data"
+		deployment.Disks = append(deployment.Disks, DiskSpec{Name: disk, SizeGB: *diskGB})
+		deployment.VM.Mounts = append(deployment.VM.Mounts, MountSpec{Disk: disk, MountPoint: "/data"})
+	}
+	if spec.PublicIP {
+		deployment.Contracts[0].PublicIPs = 1
+		warnings = append(warnings, fmt.Sprintf("a public IPv4 is reserved from farm %d when the contract is created", node.FarmID))
+	}
+
+	return DeploymentPlan{
+		Network:    te.network,
+		Node:       node,
+		Deployment: deployment,
+		Warnings:   warnings,
+	}, nil
+}
+
+// vmImageFromParams returns the named image, or the custom flist and entrypoint
+func vmImageFromParams(params map[string]interface{}) (vmImage, error) {
+	if flist := optionalString(params, "flist"); flist != nil {
+		if !strings.HasPrefix(*flist, "https://") || !strings.HasSuffix(*flist, ".flist") {
+			return vmImage{}, paramError("flist", "flist must be an https URL of a .flist file, got: %s", *flist)
+		}
+		image := vmImage{flist: *flist, entrypoint: "/sbin/zinit init"}
+		if entrypoint := optionalString(params, "entrypoint"); entrypoint != nil {
+			image.entrypoint = *entrypoint
+		}
+		return image, nil
+	}
+
+	name, _ := params["image"].(string)
+	image, ok := vmImages[name]
+	if !ok {
+		return vmImage{}, paramError("image", "unknown image: %s", name)
+	}
+	return image, nil
+}
+
+// pickNode returns the best node for spec, or checks the node named by node_id
+// and explains why it cannot host the workload
+func (te *TaskExecutor) pickNode(ctx context.Context, spec WorkloadSpec, params map[string]interface{}, callerTwinID *uint64) (CapacityCandidate, error) {
+	filter := spec.nodeFilter()
+
+	nodeID, err := optionalUint64(params, "node_id")
+	if err != nil {
+		return CapacityCandidate{}, err
+	}
+	if nodeID != nil {
+		filter.NodeID = nodeID
+	}
+
+	candidates, _, err := te.rankCandidates(ctx, spec, filter, callerTwinID)
+	if err != nil {
+		return CapacityCandidate{}, err
+	}
+	if len(candidates) > 0 {
+		return candidates[0], nil
+	}
+
+	if nodeID == nil {
+		return CapacityCandidate{}, notFoundError("no node can host a VM with %d cores, %d GB memory and %d GB SSD that matches the filters", spec.CRU, spec.MRUGB, spec.SRUGB)
+	}
+
+	// Explain why the requested node was filtered out
+	nodes, _, err := te.gridClient.Nodes(ctx, types.NodeFilter{NodeID: nodeID}, types.Limit{Size: 1, Page: 1})
+	if err != nil {
+		return CapacityCandidate{}, fmt.Errorf("failed to fetch node: %w", err)
+	}
+	if len(nodes) == 0 {
+		return CapacityCandidate{}, notFoundError("node with ID %d not found", *nodeID)
+	}
+	farms, err := te.fetchFarms(ctx, nodes)
+	if err != nil {
+		return CapacityCandidate{}, err
+	}
+
+	problems := spec.misfits(nodes[0], farms[nodes[0].FarmID], callerTwinID)
+	if len(problems) == 0 {
+		problems = []string{"it does not match the requested filters"}
+	}
+	return CapacityCandidate{}, paramError("node_id", "node %d cannot host the VM: %s", *nodeID, strings.Join(problems, "; "))
+}
+
+// misfits lists the reasons a node cannot host the workload
+func (spec WorkloadSpec) misfits(node types.Node, farm types.Farm, callerTwinID *uint64) []string {
+	free := func(total, used types.Unit) uint64 {
+		if used > total {
+			return 0
+		}
+		return uint64(total-used) / gigabyte
+	}
+
+	var problems []string
+	if node.Status != "up" {
+		problems = append(problems, fmt.Sprintf("it is %s", node.Status))
+	}
+	if node.Rented && (callerTwinID == nil || uint64(node.RentedByTwinID) != *callerTwinID) {
+		problems = append(problems, fmt.Sprintf("it is rented by twin %d", node.RentedByTwinID))
+	}
+	if node.TotalResources.CRU < spec.CRU {
+		problems = append(problems, fmt.Sprintf("it has %d cores, %d needed", node.TotalResources.CRU, spec.CRU))
+	}
+	if freeMRU := free(node.TotalResources.MRU, node.UsedResources.MRU); freeMRU < spec.MRUGB {
+		problems = append(problems, fmt.Sprintf("%d GB memory free, %d GB needed", freeMRU, spec.MRUGB))
+	}
+	if freeSRU := free(node.TotalResources.SRU, node.UsedResources.SRU); freeSRU < spec.SRUGB {
+		problems = append(problems, fmt.Sprintf("%d GB SSD free, %d GB needed", freeSRU, spec.SRUGB))
+	}
+	if spec.GPU && node.NumGPU == 0 {
+		problems = append(problems, "it has no GPU")
+	}
+	if spec.PublicIP {
+		freeIPs := 0
+		for _, ip := range farm.PublicIps {
+			if ip.ContractID == 0 {
+				freeIPs++
+			}
+		}
+		if freeIPs == 0 {
+			problems = append(problems, fmt.Sprintf("farm %d has no free public IPv4", node.FarmID))
+		}
+	}
+	if spec.Certified && node.CertificationType != "Certified" {
+		problems = append(problems, "it is not certified")
+	}
+	if spec.Country != "" && !strings.EqualFold(node.Country, spec.Country) {
+		problems = append(problems, fmt.Sprintf("it is in %s", node.Country))
+	}
+	return problems
+}
@@ -0,0 +1,125 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"anubis-executer/gridproxytest"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+)
+
+func TestPlanVMDeployment(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{
+		TaskName: "plan_vm_deployment",
+		Params: map[string]interface{}{
+			"name":      "web",
+			"cpu":       4,
+			"memory_gb": 8,
+			"disk_gb":   50,
+			"region":    "Europe",
+			"ssh_key":   "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5 alice@laptop",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plan := result.(DeploymentPlan)
+	deployment := plan.Deployment
+	if plan.Submitted || plan.Node.NodeID != 12 || deployment.NodeID != 12 || deployment.NodeTwin != 102 {
+		t.Errorf("expected an unsubmitted plan on node 12, got %+v", plan)
+	}
+	if deployment.VM.Flist != vmImages["ubuntu-22.04"].flist || deployment.VM.CPU != 4 || deployment.VM.MemoryMB != 8192 || deployment.VM.RootfsSizeMB != 2048 {
+		t.Errorf("unexpected VM %+v", deployment.VM)
+	}
+	if deployment.VM.EnvVars["SSH_KEY"] != "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5 alice@laptop" || !deployment.VM.Mycelium || deployment.Network.Name != "web_net" {
+		t.Errorf("unexpected VM access %+v on %+v", deployment.VM, deployment.Network)
+	}
+	if len(deployment.Disks) != 1 || deployment.Disks[0] != (DiskSpec{Name: "web_data", SizeGB: 50}) ||
+		len(deployment.VM.Mounts) != 1 || deployment.VM.Mounts[0] != (MountSpec{Disk: "web_data", MountPoint: "/data"}) {
+		t.Errorf("expected web_data mounted at /data, got %+v and %+v", deployment.Disks, deployment.VM.Mounts)
+	}
+	if len(deployment.Contracts) != 1 || deployment.Contracts[0] != (ContractSpec{Type: "node", NodeID: 12}) {
+		t.Errorf("expected a node contract on node 12, got %+v", deployment.Contracts)
+	}
+	if len(plan.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %q", plan.Warnings)
+	}
+}
+
+func TestPlanVMDeploymentPublicIP(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{
+		TaskName: "plan_vm_deployment",
+		Params:   map[string]interface{}{"name": "gateway", "cpu": 2, "memory_gb": 4, "public_ipv4": true, "image": "alpine", "mycelium": false},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plan := result.(DeploymentPlan)
+	if plan.Deployment.Contracts[0].PublicIPs != 1 || !plan.Deployment.VM.PublicIP4 || plan.Deployment.VM.Mycelium {
+		t.Errorf("expected a public IPv4 without Mycelium, got %+v", plan.Deployment)
+	}
+	if plan.Deployment.VM.EnvVars["SSH_KEY"] != SSHKeyPlaceholder || len(plan.Warnings) != 2 {
+		t.Errorf("expected the SSH key placeholder and two warnings, got %+v and %q", plan.Deployment.VM.EnvVars, plan.Warnings)
+	}
+}
+
+func TestPlanVMDeploymentErrors(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	executor, err := NewTaskExecutor("test", WithClient(client.NewClient(gridProxy.URL)), WithoutCache())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		expectedCode  ErrorCode
+		expectedField string
+		expectedText  string
+	}{
+		{"invalid name", map[string]interface{}{"name": "my vm", "cpu": 2, "memory_gb": 4}, CodeInvalidParams, "name", "letters, digits or underscores"},
+		{"plain http flist", map[string]interface{}{"name": "vm", "cpu": 2, "memory_gb": 4, "flist": "http://example.com/app.flist"}, CodeInvalidParams, "flist", "https URL"},
+		{"invalid ssh key", map[string]interface{}{"name": "vm", "cpu": 2, "memory_gb": 4, "ssh_key": "hunter2"}, CodeInvalidParams, "ssh_key", "OpenSSH public key"},
+		{"node that is down", map[string]interface{}{"name": "vm", "cpu": 2, "memory_gb": 4, "node_id": 22}, CodeInvalidParams, "node_id", "node 22 cannot host the VM: it is down"},
+		{"node without memory", map[string]interface{}{"name": "vm", "cpu": 2, "memory_gb": 64, "node_id": 11}, CodeInvalidParams, "node_id", "24 GB memory free, 64 GB needed"},
+		{"unknown node", map[string]interface{}{"name": "vm", "cpu": 2, "memory_gb": 4, "node_id": 99}, CodeNotFound, "", "node with ID 99 not found"},
+		{"nothing fits", map[string]interface{}{"name": "vm", "cpu": 32, "memory_gb": 256}, CodeNotFound, "", "no node can host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executor.ExecuteTask(context.Background(), Task{TaskName: "plan_vm_deployment", Params: tt.params})
+
+			var taskErr *TaskError
+			if !errors.As(err, &taskErr) || taskErr.Code != tt.expectedCode || taskErr.Field != tt.expectedField {
+				t.Fatalf("expected a %s error on %q, got %v", tt.expectedCode, tt.expectedField, err)
+			}
+			if !strings.Contains(err.Error(), tt.expectedText) {
+				t.Errorf("expected %q in %q", tt.expectedText, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// gigabyte is the number of bytes in a GB as used by GridProxy capacity fields
+const gigabyte = 1024 * 1024 * 1024
+
+// listNodes returns ThreeFold nodes matching status, location, capacity and feature filters
+func (te *TaskExecutor) listNodes(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing listNodes task")
+
+	filter, err := nodeFilterFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := te.listOptionsFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call(s)
+	result, err := listPages(ctx, te, opts, func(ctx context.Context, limit types.Limit) ([]types.Node, int, error) {
+		return te.gridClient.Nodes(ctx, filter, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch nodes: %w", err)
+	}
+
+	return result, nil
+}
+
+// nodeFilterFromParams translates list_nodes parameters into a GridProxy node filter.
+// Free memory and storage are given in GB and converted to bytes.
+func nodeFilterFromParams(params map[string]interface{}) (types.NodeFilter, error) {
+	filter := types.NodeFilter{
+		Status:           stringSlice(params, "status"),
+		Country:          optionalString(params, "country"),
+		City:             optionalString(params, "city"),
+		FarmNameContains: optionalString(params, "farm_name"),
+		Dedicated:        optionalBool(params, "dedicated"),
+		Rentable:         optionalBool(params, "rentable"),
+		Rented:           optionalBool(params, "rented"),
+		HasGPU:           optionalBool(params, "has_gpu"),
+		IPv4:             optionalBool(params, "ipv4"),
+		Domain:           optionalBool(params, "domain"),
+	}
+
+	farmIDs, err := uint64Slice(params, "farm_ids")
+	if err != nil {
+		return filter, err
+	}
+	filter.FarmIDs = farmIDs
+
+	// CPU is shared between workloads, so cores are matched against the node total
+	if filter.TotalCRU, err = optionalUint64(params, "free_cru"); err != nil {
+		return filter, err
+	}
+
+	for name, target := range map[string]**uint64{
+		"free_mru_gb": &filter.FreeMRU,
+		"free_sru_gb": &filter.FreeSRU,
+		"free_hru_gb": &filter.FreeHRU,
+	} {
+		gb, err := optionalUint64(params, name)
+		if err != nil {
+			return filter, err
+		}
+		if gb != nil {
+			bytes := *gb * gigabyte
+			*target = &bytes
+		}
+	}
+
+	return filter, nil
+}
+
+// NodeStatusResult reports whether a node is currently online
+type NodeStatusResult struct {
+	NodeID uint32 `json:"node_id"`
+	Status string `json:"status"`
+	Online bool   `json:"online"`
+}
+
+// getNode returns details of a specific node including total vs. used capacity
+func (te *TaskExecutor) getNode(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing getNode task")
+
+	nodeID, err := nodeIDParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call
+	node, err := te.gridClient.Node(ctx, nodeID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, notFoundError("node with ID %d not found", nodeID)
+		}
+		return nil, fmt.Errorf("failed to fetch node: %w", err)
+	}
+
+	if node.NodeID == 0 {
+		return nil, notFoundError("node with ID %d not found", nodeID)
+	}
+
+	return node, nil
+}
+
+// nodeStatus returns the online status of a specific node
+func (te *TaskExecutor) nodeStatus(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing nodeStatus task")
+
+	nodeID, err := nodeIDParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call
+	status, err := te.gridClient.NodeStatus(ctx, nodeID)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, notFoundError("node with ID %d not found", nodeID)
+		}
+		return nil, fmt.Errorf("failed to fetch node status: %w", err)
+	}
+
+	return NodeStatusResult{
+		NodeID: nodeID,
+		Status: status.Status,
+		Online: status.Status == "up",
+	}, nil
+}
+
+// nodeIDParam extracts the required node_id parameter
+func nodeIDParam(params map[string]interface{}) (uint32, error) {
+	nodeIDParam, ok := params["node_id"]
+	if !ok {
+		return 0, paramError("node_id", "node_id parameter is required")
+	}
+
+	nodeID, err := parseUint64(nodeIDParam)
+	if err != nil {
+		return 0, paramError("node_id", "invalid node_id format: %v", err)
+	}
+	if nodeID > math.MaxUint32 {
+		return 0, paramError("node_id", "node_id must be at most %d, got: %d", math.MaxUint32, nodeID)
+	}
+
+	return uint32(nodeID), nil
+}
@@ -0,0 +1,312 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func TestListNodes(t *testing.T) {
+	mockNodes := []types.Node{
+		{NodeID: 1, FarmID: 1, Country: "Belgium", Status: "up",
+			TotalResources: types.Capacity{MRU: 16 * gigabyte}, UsedResources: types.Capacity{MRU: 4 * gigabyte}},
+		{NodeID: 2, FarmID: 1, Country: "Belgium", Status: "down",
+			TotalResources: types.Capacity{MRU: 16 * gigabyte}},
+		{NodeID: 3, FarmID: 2, Country: "Egypt", Status: "up",
+			TotalResources: types.Capacity{MRU: 8 * gigabyte}, UsedResources: types.Capacity{MRU: 6 * gigabyte}},
+	}
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		mockError     error
+		expectedIDs   []int
+		expectedError bool
+	}{
+		{
+			name:        "list all nodes",
+			params:      map[string]interface{}{},
+			expectedIDs: []int{1, 2, 3},
+		},
+		{
+			name:        "filter by status",
+			params:      map[string]interface{}{"status": []interface{}{"up"}},
+			expectedIDs: []int{1, 3},
+		},
+		{
+			name:        "filter by country and farm",
+			params:      map[string]interface{}{"country": "Belgium", "farm_ids": []interface{}{float64(1)}},
+			expectedIDs: []int{1, 2},
+		},
+		{
+			name:        "filter by free memory in GB",
+			params:      map[string]interface{}{"free_mru_gb": float64(10)},
+			expectedIDs: []int{1, 2},
+		},
+		{
+			name:          "invalid farm id",
+			params:        map[string]interface{}{"farm_ids": []interface{}{"abc"}},
+			expectedError: true,
+		},
+		{
+			name:          "API error",
+			params:        map[string]interface{}{},
+			mockError:     errors.New("API error"),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &TaskExecutor{
+				gridClient: &MockGridClient{
+					nodes: mockNodes,
+					err:   tt.mockError,
+				},
+				network: "test",
+			}
+
+			result, err := executor.listNodes(context.Background(), tt.params)
+
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response, ok := result.(ListResult[types.Node])
+			if !ok {
+				t.Fatalf("expected ListResult[types.Node], got %T", result)
+			}
+
+			nodes := response.Items
+
+			if len(nodes) != len(tt.expectedIDs) {
+				t.Fatalf("expected %d nodes, got %d", len(tt.expectedIDs), len(nodes))
+			}
+
+			for i, id := range tt.expectedIDs {
+				if nodes[i].NodeID != id {
+					t.Errorf("expected node %d at index %d, got %d", id, i, nodes[i].NodeID)
+				}
+			}
+
+			if response.TotalCount != len(tt.expectedIDs) {
+				t.Errorf("expected total_count %d, got %v", len(tt.expectedIDs), response.TotalCount)
+			}
+		})
+	}
+}
+
+func TestNodeFilterFromParams(t *testing.T) {
+	params, err := DefaultRegistry.tasks["list_nodes"].Params.Validate(map[string]interface{}{
+		"status":      "up",
+		"farm_ids":    []interface{}{float64(1), "2"},
+		"free_cru":    float64(4),
+		"free_sru_gb": float64(100),
+		"free_hru_gb": float64(1000),
+		"ipv4":        true,
+		"has_gpu":     "false",
+		"city":        "Ghent",
+	})
+	if err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	filter, err := nodeFilterFromParams(params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(filter.Status) != 1 || filter.Status[0] != "up" {
+		t.Errorf("expected status [up], got %v", filter.Status)
+	}
+	if len(filter.FarmIDs) != 2 || filter.FarmIDs[1] != 2 {
+		t.Errorf("expected farm IDs [1 2], got %v", filter.FarmIDs)
+	}
+	if filter.TotalCRU == nil || *filter.TotalCRU != 4 {
+		t.Errorf("expected total CRU 4, got %v", filter.TotalCRU)
+	}
+	if filter.FreeSRU == nil || *filter.FreeSRU != 100*gigabyte {
+		t.Errorf("expected free SRU of 100 GB in bytes, got %v", filter.FreeSRU)
+	}
+	if filter.FreeHRU == nil || *filter.FreeHRU != 1000*gigabyte {
+		t.Errorf("expected free HRU of 1000 GB in bytes, got %v", filter.FreeHRU)
+	}
+	if filter.FreeMRU != nil {
+		t.Errorf("expected free MRU to be unset, got %v", *filter.FreeMRU)
+	}
+	if filter.IPv4 == nil || !*filter.IPv4 {
+		t.Errorf("expected ipv4 filter to be true")
+	}
+	if filter.HasGPU == nil || *filter.HasGPU {
+		t.Errorf("expected has_gpu filter to be false")
+	}
+	if filter.City == nil || *filter.City != "Ghent" {
+		t.Errorf("expected city Ghent, got %v", filter.City)
+	}
+	if filter.Rented != nil {
+		t.Errorf("expected rented filter to be unset")
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	mockNodes := []types.Node{
+		{NodeID: 11, FarmID: 1, Status: "up", Uptime: 3600, CertificationType: "Certified",
+			TotalResources: types.Capacity{CRU: 8, MRU: 32 * gigabyte},
+			UsedResources:  types.Capacity{CRU: 2, MRU: 8 * gigabyte},
+			PublicConfig:   types.PublicConfig{Ipv4: "185.69.166.10/24"}},
+	}
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		mockError     error
+		expectedError bool
+		errorMessage  string
+	}{
+		{
+			name:   "valid node ID",
+			params: map[string]interface{}{"node_id": float64(11)},
+		},
+		{
+			name:          "missing node_id",
+			params:        map[string]interface{}{},
+			expectedError: true,
+			errorMessage:  "node_id parameter is required",
+		},
+		{
+			name:          "node ID out of range",
+			params:        map[string]interface{}{"node_id": float64(1<<32 + 1)},
+			expectedError: true,
+			errorMessage:  "node_id must be at most 4294967295, got: 4294967297",
+		},
+		{
+			name:          "node not found",
+			params:        map[string]interface{}{"node_id": float64(999)},
+			expectedError: true,
+			errorMessage:  "node with ID 999 not found",
+		},
+		{
+			name:          "API error",
+			params:        map[string]interface{}{"node_id": float64(11)},
+			mockError:     errors.New("API error"),
+			expectedError: true,
+			errorMessage:  "failed to fetch node: API error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &TaskExecutor{
+				gridClient: &MockGridClient{
+					nodes: mockNodes,
+					err:   tt.mockError,
+				},
+				network: "test",
+			}
+
+			result, err := executor.getNode(context.Background(), tt.params)
+
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				} else if err.Error() != tt.errorMessage {
+					t.Errorf("expected error message %q, got %q", tt.errorMessage, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			node, ok := result.(types.NodeWithNestedCapacity)
+			if !ok {
+				t.Fatalf("expected types.NodeWithNestedCapacity, got %T", result)
+			}
+
+			if node.NodeID != 11 {
+				t.Errorf("expected node ID 11, got %d", node.NodeID)
+			}
+			if node.Capacity.Total.CRU != 8 || node.Capacity.Used.CRU != 2 {
+				t.Errorf("expected total/used CRU 8/2, got %d/%d", node.Capacity.Total.CRU, node.Capacity.Used.CRU)
+			}
+			if node.PublicConfig.Ipv4 == "" {
+				t.Errorf("expected public config to be populated")
+			}
+		})
+	}
+}
+
+func TestNodeStatus(t *testing.T) {
+	mockNodes := []types.Node{
+		{NodeID: 1, Status: "up"},
+		{NodeID: 2, Status: "standby"},
+	}
+
+	tests := []struct {
+		name           string
+		params         map[string]interface{}
+		expectedStatus string
+		expectedOnline bool
+		expectedError  string
+	}{
+		{
+			name:           "online node",
+			params:         map[string]interface{}{"node_id": float64(1)},
+			expectedStatus: "up",
+			expectedOnline: true,
+		},
+		{
+			name:           "standby node",
+			params:         map[string]interface{}{"node_id": float64(2)},
+			expectedStatus: "standby",
+			expectedOnline: false,
+		},
+		{
+			name:          "node not found",
+			params:        map[string]interface{}{"node_id": float64(3)},
+			expectedError: "node with ID 3 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &TaskExecutor{
+				gridClient: &MockGridClient{nodes: mockNodes},
+				network:    "test",
+			}
+
+			result, err := executor.nodeStatus(context.Background(), tt.params)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			status, ok := result.(NodeStatusResult)
+			if !ok {
+				t.Fatalf("expected NodeStatusResult, got %T", result)
+			}
+
+			if status.Status != tt.expectedStatus || status.Online != tt.expectedOnline {
+				t.Errorf("expected status %q online=%v, got %q online=%v",
+					tt.expectedStatus, tt.expectedOnline, status.Status, status.Online)
+			}
+		})
+	}
+}
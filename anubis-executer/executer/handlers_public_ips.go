@@ -0,0 +1,41 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// listPublicIPs searches public IPs across farms by farm, free/used state, IP and gateway
+func (te *TaskExecutor) listPublicIPs(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing listPublicIPs task")
+
+	filter := types.PublicIpFilter{
+		Free:    optionalBool(params, "free"),
+		Ip:      optionalString(params, "ip"),
+		Gateway: optionalString(params, "gateway"),
+	}
+
+	farmIDs, err := uint64Slice(params, "farm_ids")
+	if err != nil {
+		return nil, err
+	}
+	filter.FarmIDs = farmIDs
+
+	opts, err := te.listOptionsFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call(s)
+	result, err := listPages(ctx, te, opts, func(ctx context.Context, limit types.Limit) ([]types.PublicIP, int, error) {
+		publicIPs, totalCount, err := te.gridClient.PublicIps(ctx, filter, limit)
+		return publicIPs, int(totalCount), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch public IPs: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func TestListPublicIPs(t *testing.T) {
+	mockIPs := []types.PublicIP{
+		{ID: "a", IP: "185.69.167.209/24", FarmID: "1", ContractID: 1230264, Gateway: "185.69.167.1"},
+		{ID: "b", IP: "185.69.167.210/24", FarmID: "1", Gateway: "185.69.167.1"},
+		{ID: "c", IP: "5.78.1.10/24", FarmID: "2", Gateway: "5.78.1.1"},
+	}
+
+	tests := []struct {
+		name          string
+		params        map[string]interface{}
+		mockError     error
+		expectedIDs   []string
+		expectedError bool
+	}{
+		{"list all IPs", map[string]interface{}{}, nil, []string{"a", "b", "c"}, false},
+		{"free IPs only", map[string]interface{}{"free": true}, nil, []string{"b", "c"}, false},
+		{"used IPs of farm 1", map[string]interface{}{"free": false, "farm_ids": []interface{}{float64(1)}}, nil, []string{"a"}, false},
+		{"filter by gateway", map[string]interface{}{"gateway": "5.78.1.1"}, nil, []string{"c"}, false},
+		{"filter by IP", map[string]interface{}{"ip": "185.69.167.210/24"}, nil, []string{"b"}, false},
+		{"invalid farm_ids", map[string]interface{}{"farm_ids": []interface{}{"abc"}}, nil, nil, true},
+		{"API error", map[string]interface{}{}, errors.New("API error"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &TaskExecutor{
+				gridClient: &MockGridClient{publicIPs: mockIPs, err: tt.mockError},
+				network:    "test",
+			}
+
+			result, err := executor.listPublicIPs(context.Background(), tt.params)
+
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response := result.(ListResult[types.PublicIP])
+			ips := response.Items
+			if len(ips) != len(tt.expectedIDs) {
+				t.Fatalf("expected %d IPs, got %d", len(tt.expectedIDs), len(ips))
+			}
+			for i, id := range tt.expectedIDs {
+				if ips[i].ID != id {
+					t.Errorf("expected IP %s at index %d, got %s", id, i, ips[i].ID)
+				}
+			}
+			if response.TotalCount != len(tt.expectedIDs) {
+				t.Errorf("expected total_count %d, got %v", len(tt.expectedIDs), response.TotalCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// maxWalkPages caps how many pages grid_stats and aggregate walk
+const maxWalkPages = 50
+
+// walkPageSize is the page size used while walking nodes or farms
+const walkPageSize = 100
+
+// CapacitySummary is an amount of grid capacity: CPU cores and storage/memory in GB
+type CapacitySummary struct {
+	CRU   uint64 `json:"cru"`
+	MRUGB uint64 `json:"mru_gb"`
+	SRUGB uint64 `json:"sru_gb"`
+	HRUGB uint64 `json:"hru_gb"`
+}
+
+// GridStatsResult summarizes the size of the grid
+type GridStatsResult struct {
+	Network        string           `json:"network"`
+	Status         []string         `json:"status,omitempty"` // Node statuses the stats were limited to
+	Nodes          int64            `json:"nodes"`
+	Farms          int64            `json:"farms"`
+	Twins          int64            `json:"twins"`
+	Contracts      int64            `json:"contracts"`
+	Countries      int64            `json:"countries"`
+	PublicIPs      int64            `json:"public_ips"`
+	AccessNodes    int64            `json:"access_nodes"`
+	Gateways       int64            `json:"gateways"`
+	DedicatedNodes int64            `json:"dedicated_nodes"`
+	GPUs           int64            `json:"gpus"`
+	Workloads      uint32           `json:"workloads"`
+	TotalCapacity  CapacitySummary  `json:"total_capacity"`
+	UsedCapacity   *CapacitySummary `json:"used_capacity,omitempty"`
+	UsedComplete   *bool            `json:"used_complete,omitempty"` // False when the node walk hit its page cap
+	NodesByCountry map[string]int64 `json:"nodes_by_country,omitempty"`
+}
+
+// gridStats returns grid-wide counts, total capacity and a per-country node breakdown.
+// GridProxy stats carry no used capacity, so include_used walks the nodes to sum it.
+func (te *TaskExecutor) gridStats(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing gridStats task")
+
+	filter := types.StatsFilter{
+		Status: stringSlice(params, "status"),
+	}
+
+	// Make the API call
+	stats, err := te.gridClient.Stats(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch grid stats: %w", err)
+	}
+
+	result := GridStatsResult{
+		Network:        te.network,
+		Status:         filter.Status,
+		Nodes:          stats.Nodes,
+		Farms:          stats.Farms,
+		Twins:          stats.Twins,
+		Contracts:      stats.Contracts,
+		Countries:      stats.Countries,
+		PublicIPs:      stats.PublicIPs,
+		AccessNodes:    stats.AccessNodes,
+		Gateways:       stats.Gateways,
+		DedicatedNodes: stats.DedicatedNodes,
+		GPUs:           stats.GPUs,
+		Workloads:      stats.WorkloadsNumber,
+		TotalCapacity: CapacitySummary{
+			CRU:   uint64(stats.TotalCRU),
+			MRUGB: uint64(stats.TotalMRU) / gigabyte,
+			SRUGB: uint64(stats.TotalSRU) / gigabyte,
+			HRUGB: uint64(stats.TotalHRU) / gigabyte,
+		},
+		NodesByCountry: stats.NodesDistribution,
+	}
+
+	if includeUsed, ok := params["include_used"].(bool); ok && includeUsed {
+		used, complete, err := te.usedCapacity(ctx, filter.Status)
+		if err != nil {
+			return nil, err
+		}
+		result.UsedCapacity = &used
+		result.UsedComplete = &complete
+	}
+
+	return result, nil
+}
+
+// usedCapacity sums the used capacity of nodes with the given statuses,
+// stopping after maxWalkPages. The returned flag is false when the cap was
+// reached before the last page.
+func (te *TaskExecutor) usedCapacity(ctx context.Context, status []string) (CapacitySummary, bool, error) {
+	filter := types.NodeFilter{Status: status}
+
+	var cru, mru, sru, hru uint64
+	complete, err := walkPages(ctx, func(ctx context.Context, limit types.Limit) ([]types.Node, int, error) {
+		return te.gridClient.Nodes(ctx, filter, limit)
+	}, func(node types.Node) {
+		cru += uint64(node.UsedResources.CRU)
+		mru += uint64(node.UsedResources.MRU)
+		sru += uint64(node.UsedResources.SRU)
+		hru += uint64(node.UsedResources.HRU)
+	})
+	if err != nil {
+		return CapacitySummary{}, false, fmt.Errorf("failed to fetch nodes: %w", err)
+	}
+
+	return CapacitySummary{CRU: cru, MRUGB: mru / gigabyte, SRUGB: sru / gigabyte, HRUGB: hru / gigabyte}, complete, nil
+}
+
+// walkPages calls visit for every item fetch returns, page by page, stopping
+// after maxWalkPages. The returned flag is false when the cap was reached
+// before the last page.
+func walkPages[T any](ctx context.Context, fetch func(ctx context.Context, limit types.Limit) ([]T, int, error), visit func(T)) (bool, error) {
+	limit := types.Limit{Size: walkPageSize, Page: 1, RetCount: true}
+
+	for limit.Page <= maxWalkPages {
+		items, totalCount, err := fetch(ctx, limit)
+		if err != nil {
+			return false, err
+		}
+
+		for _, item := range items {
+			visit(item)
+		}
+
+		if len(items) == 0 || limit.Page*limit.Size >= uint64(totalCount) {
+			return true, nil
+		}
+		limit.Page++
+	}
+
+	return false, nil
+}
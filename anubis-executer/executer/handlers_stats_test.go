@@ -0,0 +1,81 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func TestGridStats(t *testing.T) {
+	stats := types.Stats{
+		Nodes:             2,
+		Farms:             1,
+		Twins:             10,
+		Contracts:         4,
+		Countries:         2,
+		TotalCRU:          16,
+		TotalMRU:          64 * gigabyte,
+		TotalSRU:          1024 * gigabyte,
+		NodesDistribution: map[string]int64{"Belgium": 1, "Egypt": 1},
+	}
+
+	nodes := []types.Node{
+		{NodeID: 1, Status: "up", UsedResources: types.Capacity{CRU: 2, MRU: 4 * gigabyte}},
+		{NodeID: 2, Status: "up", UsedResources: types.Capacity{CRU: 1, MRU: 2 * gigabyte, SRU: 50 * gigabyte}},
+		{NodeID: 3, Status: "down", UsedResources: types.Capacity{CRU: 8}},
+	}
+
+	t.Run("counts and capacity", func(t *testing.T) {
+		client := &MockGridClient{stats: stats, nodes: nodes}
+		executor := &TaskExecutor{gridClient: client, network: "test"}
+
+		result, err := executor.gridStats(context.Background(), map[string]interface{}{"status": []interface{}{"up"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		summary := result.(GridStatsResult)
+		if summary.Nodes != 2 || summary.Twins != 10 || summary.Contracts != 4 {
+			t.Errorf("unexpected counts: %+v", summary)
+		}
+		if summary.TotalCapacity != (CapacitySummary{CRU: 16, MRUGB: 64, SRUGB: 1024}) {
+			t.Errorf("unexpected total capacity: %+v", summary.TotalCapacity)
+		}
+		if summary.NodesByCountry["Belgium"] != 1 {
+			t.Errorf("expected per-country breakdown, got %v", summary.NodesByCountry)
+		}
+		if summary.UsedCapacity != nil {
+			t.Errorf("expected no used capacity unless requested")
+		}
+		if len(client.lastStatsFilter.Status) != 1 || client.lastStatsFilter.Status[0] != "up" {
+			t.Errorf("expected status filter to be passed through, got %v", client.lastStatsFilter.Status)
+		}
+	})
+
+	t.Run("used capacity walks matching nodes", func(t *testing.T) {
+		executor := &TaskExecutor{gridClient: &MockGridClient{stats: stats, nodes: nodes}, network: "test"}
+
+		result, err := executor.gridStats(context.Background(), map[string]interface{}{"status": []interface{}{"up"}, "include_used": true})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		summary := result.(GridStatsResult)
+		if summary.UsedCapacity == nil || *summary.UsedCapacity != (CapacitySummary{CRU: 3, MRUGB: 6, SRUGB: 50}) {
+			t.Errorf("unexpected used capacity: %+v", summary.UsedCapacity)
+		}
+		if summary.UsedComplete == nil || !*summary.UsedComplete {
+			t.Errorf("expected used capacity to be complete")
+		}
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		executor := &TaskExecutor{gridClient: &MockGridClient{err: errors.New("API error")}, network: "test"}
+
+		if _, err := executor.gridStats(context.Background(), map[string]interface{}{}); err == nil {
+			t.Errorf("expected error but got none")
+		}
+	})
+}
@@ -3,6 +3,7 @@ package executer
 import (
 	"context"
 	"errors"
+	"strconv"
 	"testing"
 
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
@@ -11,8 +12,35 @@ import (
 // MockGridClient implements the client.Client interface for testing
 type MockGridClient struct {
 	farms      []types.Farm
+	nodes      []types.Node
+	contracts  []types.Contract
+	twins      []types.Twin
+	stats      types.Stats
+	publicIPs  []types.PublicIP
+	bills      map[uint32][]types.ContractBilling
 	totalCount int
 	err        error
+
+	// lastNodeFilter records the filter passed to the most recent Nodes call
+	lastNodeFilter types.NodeFilter
+	// lastStatsFilter records the filter passed to the most recent Stats call
+	lastStatsFilter types.StatsFilter
+}
+
+// paginate returns the page of items selected by limit
+func paginate[T any](items []T, limit types.Limit) []T {
+	start := int((limit.Page - 1) * limit.Size)
+	end := start + int(limit.Size)
+
+	if start >= len(items) {
+		return []T{}
+	}
+
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[start:end]
 }
 
 func (m *MockGridClient) Ping() error {
@@ -61,39 +89,195 @@ func (m *MockGridClient) Farms(ctx context.Context, filter types.FarmFilter, lim
 
 // Implement other required methods (not used in our tests)
 func (m *MockGridClient) Nodes(ctx context.Context, filter types.NodeFilter, limit types.Limit) ([]types.Node, int, error) {
-	return nil, 0, errors.New("not implemented")
+	m.lastNodeFilter = filter
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+
+	var filteredNodes []types.Node
+	for _, node := range m.nodes {
+		if len(filter.Status) > 0 && !containsString(filter.Status, node.Status) {
+			continue
+		}
+		if filter.Country != nil && node.Country != *filter.Country {
+			continue
+		}
+		if len(filter.FarmIDs) > 0 && !containsUint64(filter.FarmIDs, uint64(node.FarmID)) {
+			continue
+		}
+		if filter.FreeMRU != nil && uint64(node.TotalResources.MRU-node.UsedResources.MRU) < *filter.FreeMRU {
+			continue
+		}
+		filteredNodes = append(filteredNodes, node)
+	}
+
+	return paginate(filteredNodes, limit), len(filteredNodes), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsUint64(values []uint64, value uint64) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *MockGridClient) Contracts(ctx context.Context, filter types.ContractFilter, limit types.Limit) ([]types.Contract, int, error) {
-	return nil, 0, errors.New("not implemented")
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+
+	var filteredContracts []types.Contract
+	for _, contract := range m.contracts {
+		if filter.TwinID != nil && uint64(contract.TwinID) != *filter.TwinID {
+			continue
+		}
+		if filter.Type != nil && contract.Type != *filter.Type {
+			continue
+		}
+		if len(filter.State) > 0 && !containsString(filter.State, contract.State) {
+			continue
+		}
+		filteredContracts = append(filteredContracts, contract)
+	}
+
+	return paginate(filteredContracts, limit), len(filteredContracts), nil
 }
 
 func (m *MockGridClient) Contract(ctx context.Context, contractID uint32) (types.Contract, error) {
-	return types.Contract{}, errors.New("not implemented")
+	if m.err != nil {
+		return types.Contract{}, m.err
+	}
+
+	for _, contract := range m.contracts {
+		if contract.ContractID == uint(contractID) {
+			return contract, nil
+		}
+	}
+
+	return types.Contract{}, errors.New("contract not found")
 }
 
 func (m *MockGridClient) ContractBills(ctx context.Context, contractID uint32, limit types.Limit) ([]types.ContractBilling, uint, error) {
-	return nil, 0, errors.New("not implemented")
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+
+	bills := m.bills[contractID]
+	return paginate(bills, limit), uint(len(bills)), nil
 }
 
 func (m *MockGridClient) Twins(ctx context.Context, filter types.TwinFilter, limit types.Limit) ([]types.Twin, int, error) {
-	return nil, 0, errors.New("not implemented")
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+
+	var matched []types.Twin
+	for _, twin := range m.twins {
+		if filter.TwinID != nil && uint64(twin.TwinID) != *filter.TwinID {
+			continue
+		}
+		if filter.AccountID != nil && twin.AccountID != *filter.AccountID {
+			continue
+		}
+		if filter.Relay != nil && twin.Relay != *filter.Relay {
+			continue
+		}
+		matched = append(matched, twin)
+	}
+
+	return paginate(matched, limit), len(matched), nil
 }
 
 func (m *MockGridClient) Node(ctx context.Context, nodeID uint32) (types.NodeWithNestedCapacity, error) {
-	return types.NodeWithNestedCapacity{}, errors.New("not implemented")
+	if m.err != nil {
+		return types.NodeWithNestedCapacity{}, m.err
+	}
+
+	for _, node := range m.nodes {
+		if node.NodeID == int(nodeID) {
+			return types.NodeWithNestedCapacity{
+				NodeID:            node.NodeID,
+				FarmID:            node.FarmID,
+				Uptime:            node.Uptime,
+				Status:            node.Status,
+				CertificationType: node.CertificationType,
+				PublicConfig:      node.PublicConfig,
+				Capacity: types.CapacityResult{
+					Total: node.TotalResources,
+					Used:  node.UsedResources,
+				},
+			}, nil
+		}
+	}
+
+	return types.NodeWithNestedCapacity{}, errors.New("node not found")
 }
 
 func (m *MockGridClient) NodeStatus(ctx context.Context, nodeID uint32) (types.NodeStatus, error) {
-	return types.NodeStatus{}, errors.New("not implemented")
+	if m.err != nil {
+		return types.NodeStatus{}, m.err
+	}
+
+	for _, node := range m.nodes {
+		if node.NodeID == int(nodeID) {
+			return types.NodeStatus{Status: node.Status}, nil
+		}
+	}
+
+	return types.NodeStatus{}, errors.New("node not found")
 }
 
 func (m *MockGridClient) Stats(ctx context.Context, filter types.StatsFilter) (types.Stats, error) {
-	return types.Stats{}, errors.New("not implemented")
+	m.lastStatsFilter = filter
+	if m.err != nil {
+		return types.Stats{}, m.err
+	}
+	return m.stats, nil
 }
 
 func (m *MockGridClient) PublicIps(ctx context.Context, filter types.PublicIpFilter, limit types.Limit) ([]types.PublicIP, uint, error) {
-	return nil, 0, errors.New("not implemented")
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+
+	var matched []types.PublicIP
+	for _, ip := range m.publicIPs {
+		if filter.Free != nil && (ip.ContractID == 0) != *filter.Free {
+			continue
+		}
+		if filter.Ip != nil && ip.IP != *filter.Ip {
+			continue
+		}
+		if filter.Gateway != nil && ip.Gateway != *filter.Gateway {
+			continue
+		}
+		if len(filter.FarmIDs) > 0 && !containsString(farmIDStrings(filter.FarmIDs), ip.FarmID) {
+			continue
+		}
+		matched = append(matched, ip)
+	}
+
+	return paginate(matched, limit), uint(len(matched)), nil
+}
+
+// farmIDStrings formats farm IDs the way GridProxy reports them on public IPs
+func farmIDStrings(ids []uint64) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = strconv.FormatUint(id, 10)
+	}
+	return result
 }
 
 func TestParseUint64(t *testing.T) {
@@ -174,6 +358,13 @@ func TestListFarms(t *testing.T) {
 			expectedCount: 0,
 			expectedError: true,
 		},
+		{
+			name:          "invalid farm_id",
+			params:        map[string]interface{}{"farm_id": "abc"},
+			mockFarms:     mockFarms,
+			expectedCount: 0,
+			expectedError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -187,7 +378,7 @@ func TestListFarms(t *testing.T) {
 				network: "test",
 			}
 
-			result, err := executor.listFarms(tt.params)
+			result, err := executor.listFarms(context.Background(), tt.params)
 
 			if tt.expectedError {
 				if err == nil {
@@ -202,18 +393,13 @@ func TestListFarms(t *testing.T) {
 			}
 
 			// Check result structure
-			response, ok := result.(map[string]interface{})
-			if !ok {
-				t.Errorf("expected map[string]interface{}, got %T", result)
-				return
-			}
-
-			farms, ok := response["farms"].([]types.Farm)
+			response, ok := result.(ListResult[types.Farm])
 			if !ok {
-				t.Errorf("expected farms to be []types.Farm, got %T", response["farms"])
+				t.Errorf("expected ListResult[types.Farm], got %T", result)
 				return
 			}
 
+			farms := response.Items
 			if len(farms) != tt.expectedCount {
 				t.Errorf("expected %d farms, got %d", tt.expectedCount, len(farms))
 			}
@@ -283,7 +469,7 @@ func TestGetFarm(t *testing.T) {
 				network: "test",
 			}
 
-			result, err := executor.getFarm(tt.params)
+			result, err := executor.getFarm(context.Background(), tt.params)
 
 			if tt.expectedError {
 				if err == nil {
@@ -0,0 +1,86 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// listTwins returns twins filtered by twin ID, account ID and relay
+func (te *TaskExecutor) listTwins(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing listTwins task")
+
+	filter := types.TwinFilter{
+		AccountID: optionalString(params, "account_id"),
+		Relay:     optionalString(params, "relay"),
+	}
+
+	var err error
+	if filter.TwinID, err = optionalUint64(params, "twin_id"); err != nil {
+		return nil, err
+	}
+
+	opts, err := te.listOptionsFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call(s)
+	result, err := listPages(ctx, te, opts, func(ctx context.Context, limit types.Limit) ([]types.Twin, int, error) {
+		return te.gridClient.Twins(ctx, filter, limit)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch twins: %w", err)
+	}
+
+	return result, nil
+}
+
+// getTwin returns a single twin looked up by twin ID or account ID.
+// Without either parameter it returns the caller's own twin.
+func (te *TaskExecutor) getTwin(ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	te.logf("Executing getTwin task")
+
+	filter, lookup, err := twinLookupFilter(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the API call
+	twins, _, err := te.gridClient.Twins(ctx, filter, types.Limit{Size: 1, Page: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch twin: %w", err)
+	}
+
+	if len(twins) == 0 {
+		return nil, notFoundError("twin with %s not found", lookup)
+	}
+
+	return twins[0], nil
+}
+
+// twinLookupFilter resolves the twin get_twin should return, preferring explicit
+// parameters over the caller's identity. The description is used in errors.
+func twinLookupFilter(params map[string]interface{}) (types.TwinFilter, string, error) {
+	var filter types.TwinFilter
+
+	// Explicit twin_id and account_id come first, then the caller's twin and wallet
+	for _, names := range [][2]string{{"twin_id", "account_id"}, {callerTwinIDParam, callerWalletAddressParam}} {
+		twinID, err := optionalUint64(params, names[0])
+		if err != nil {
+			return filter, "", err
+		}
+		if twinID != nil {
+			filter.TwinID = twinID
+			return filter, fmt.Sprintf("ID %d", *twinID), nil
+		}
+
+		if accountID := optionalString(params, names[1]); accountID != nil {
+			filter.AccountID = accountID
+			return filter, fmt.Sprintf("account ID %s", *accountID), nil
+		}
+	}
+
+	return filter, "", paramError("twin_id", "twin_id or account_id parameter is required")
+}
@@ -0,0 +1,128 @@
+package executer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+var mockTwins = []types.Twin{
+	{TwinID: 7, AccountID: "5Alice", Relay: "relay.grid.tf"},
+	{TwinID: 42, AccountID: "5Bob", Relay: "relay.grid.tf"},
+	{TwinID: 43, AccountID: "5Carol", Relay: "relay.dev.grid.tf"},
+}
+
+func TestListTwins(t *testing.T) {
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{twins: mockTwins},
+		network:    "test",
+	}
+
+	tests := []struct {
+		name        string
+		params      map[string]interface{}
+		expectedIDs []uint
+	}{
+		{"list all twins", map[string]interface{}{}, []uint{7, 42, 43}},
+		{"filter by relay", map[string]interface{}{"relay": "relay.grid.tf"}, []uint{7, 42}},
+		{"filter by account", map[string]interface{}{"account_id": "5Carol"}, []uint{43}},
+		{"filter by twin", map[string]interface{}{"twin_id": float64(42)}, []uint{42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.listTwins(context.Background(), tt.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			twins := result.(ListResult[types.Twin]).Items
+			if len(twins) != len(tt.expectedIDs) {
+				t.Fatalf("expected %d twins, got %d", len(tt.expectedIDs), len(twins))
+			}
+			for i, id := range tt.expectedIDs {
+				if twins[i].TwinID != id {
+					t.Errorf("expected twin %d at index %d, got %d", id, i, twins[i].TwinID)
+				}
+			}
+		})
+	}
+}
+
+func TestGetTwin(t *testing.T) {
+	callerTwin := uint64(42)
+
+	tests := []struct {
+		name          string
+		task          Task
+		expectedID    uint
+		expectedError string
+	}{
+		{
+			name:       "by twin ID",
+			task:       Task{TaskName: "get_twin", Params: map[string]interface{}{"twin_id": 7}},
+			expectedID: 7,
+		},
+		{
+			name:       "by account ID",
+			task:       Task{TaskName: "get_twin", Params: map[string]interface{}{"account_id": "5Carol"}},
+			expectedID: 43,
+		},
+		{
+			name:       "defaults to caller twin",
+			task:       Task{TaskName: "get_twin", Caller: &Caller{TwinID: &callerTwin}},
+			expectedID: 42,
+		},
+		{
+			name:       "defaults to caller wallet",
+			task:       Task{TaskName: "get_twin", Caller: &Caller{WalletAddress: "5Alice"}},
+			expectedID: 7,
+		},
+		{
+			name:       "explicit twin wins over caller",
+			task:       Task{TaskName: "get_twin", Params: map[string]interface{}{"twin_id": 43}, Caller: &Caller{TwinID: &callerTwin}},
+			expectedID: 43,
+		},
+		{
+			name:       "explicit account ID wins over caller twin",
+			task:       Task{TaskName: "get_twin", Params: map[string]interface{}{"account_id": "5Carol"}, Caller: &Caller{TwinID: &callerTwin}},
+			expectedID: 43,
+		},
+		{
+			name:          "caller parameters cannot be supplied directly",
+			task:          Task{TaskName: "get_twin", Params: map[string]interface{}{"caller_twin_id": 42}},
+			expectedError: "twin_id or account_id parameter is required",
+		},
+		{
+			name:          "unknown twin",
+			task:          Task{TaskName: "get_twin", Params: map[string]interface{}{"twin_id": 99}},
+			expectedError: "twin with ID 99 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &TaskExecutor{
+				gridClient: &MockGridClient{twins: mockTwins},
+				network:    "test",
+			}
+
+			result, err := executor.ExecuteTask(context.Background(), tt.task)
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Errorf("expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if twin := result.(types.Twin); twin.TwinID != tt.expectedID {
+				t.Errorf("expected twin %d, got %d", tt.expectedID, twin.TwinID)
+			}
+		})
+	}
+}
@@ -1,21 +1,65 @@
 package executer
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
 
 // Integration tests that run against the real GridProxy API
-// These tests are skipped by default and can be enabled with the INTEGRATION_TESTS environment variable
+// These tests are skipped by default and can be enabled with the INTEGRATION_TESTS environment variable.
+// With GRIDPROXY_CASSETTE=record they also save the grid's responses to
+// testdata/cassettes, and with GRIDPROXY_CASSETTE=replay they run offline from those cassettes.
 
-func TestIntegrationListFarms(t *testing.T) {
-	if os.Getenv("INTEGRATION_TESTS") == "" {
-		t.Skip("Skipping integration test. Set INTEGRATION_TESTS=1 to run.")
+// integrationExecutor returns the executor of an integration test for the
+// cassette mode in GRIDPROXY_CASSETTE, skipping the test when it can run
+// neither live nor from a cassette
+func integrationExecutor(t *testing.T, network string) *TaskExecutor {
+	t.Helper()
+
+	mode, err := CassetteMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("testdata", "cassettes", strings.ReplaceAll(t.Name(), "/", "_")+".json")
+
+	// Cassettes see every call, so the response cache is left out
+	var opts []Option
+	switch mode {
+	case CassetteReplay:
+		replaying, err := LoadReplayingClient(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			t.Skipf("Skipping integration test. No cassette recorded at %s; record it with %s=%s.", path, CassetteEnv, CassetteRecord)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts = []Option{WithClient(replaying), WithoutCache()}
+	case CassetteRecord:
+		live := NewFailoverClient(defaultEndpoints(network), DefaultRetryPolicy())
+		opts = []Option{WithClient(NewRecordingClient(live, path)), WithoutCache()}
+	default:
+		if os.Getenv("INTEGRATION_TESTS") == "" {
+			t.Skip("Skipping integration test. Set INTEGRATION_TESTS=1 to run.")
+		}
 	}
 
-	executor := NewTaskExecutor("main")
+	executor, err := NewTaskExecutor(network, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return executor
+}
+
+func TestIntegrationListFarms(t *testing.T) {
+	executor := integrationExecutor(t, "main")
 
 	// Test basic list farms
 	task := Task{
@@ -23,26 +67,19 @@ func TestIntegrationListFarms(t *testing.T) {
 		Params:   map[string]interface{}{},
 	}
 
-	result, err := executor.ExecuteTask(task)
+	result, err := executor.ExecuteTask(context.Background(), task)
 	if err != nil {
 		t.Fatalf("failed to list farms: %v", err)
 	}
 
-	response, ok := result.(map[string]interface{})
+	response, ok := result.(ListResult[Farm])
 	if !ok {
-		t.Fatalf("expected map response, got %T", result)
+		t.Fatalf("expected list response, got %T", result)
 	}
 
 	// Check response structure
-	farms, ok := response["farms"]
-	if !ok {
-		t.Errorf("expected farms field in response")
-	}
-
-	totalCount, ok := response["total_count"].(int)
-	if !ok {
-		t.Errorf("expected total_count to be int, got %T", response["total_count"])
-	}
+	farms := response.Items
+	totalCount := response.TotalCount
 
 	if totalCount <= 0 {
 		t.Errorf("expected positive total_count, got %d", totalCount)
@@ -53,11 +90,7 @@ func TestIntegrationListFarms(t *testing.T) {
 }
 
 func TestIntegrationGetFarm(t *testing.T) {
-	if os.Getenv("INTEGRATION_TESTS") == "" {
-		t.Skip("Skipping integration test. Set INTEGRATION_TESTS=1 to run.")
-	}
-
-	executor := NewTaskExecutor("main")
+	executor := integrationExecutor(t, "main")
 
 	// Test get specific farm (farm ID 1 should exist)
 	task := Task{
@@ -65,7 +98,7 @@ func TestIntegrationGetFarm(t *testing.T) {
 		Params:   map[string]interface{}{"farm_id": float64(1)},
 	}
 
-	result, err := executor.ExecuteTask(task)
+	result, err := executor.ExecuteTask(context.Background(), task)
 	if err != nil {
 		t.Fatalf("failed to get farm: %v", err)
 	}
@@ -87,16 +120,12 @@ func TestIntegrationGetFarm(t *testing.T) {
 }
 
 func TestIntegrationJSONInterface(t *testing.T) {
-	if os.Getenv("INTEGRATION_TESTS") == "" {
-		t.Skip("Skipping integration test. Set INTEGRATION_TESTS=1 to run.")
-	}
-
-	executor := NewTaskExecutor("main")
+	executor := integrationExecutor(t, "main")
 
 	// Test JSON interface
 	taskJSON := `{"task_name": "list_farms", "params": {"page": 1}}`
 
-	responseJSON, err := executor.ExecuteTaskJSON([]byte(taskJSON))
+	responseJSON, err := executor.ExecuteTaskJSON(context.Background(), []byte(taskJSON))
 	if err != nil {
 		t.Fatalf("failed to execute task JSON: %v", err)
 	}
@@ -118,16 +147,12 @@ func TestIntegrationJSONInterface(t *testing.T) {
 }
 
 func TestIntegrationErrorHandling(t *testing.T) {
-	if os.Getenv("INTEGRATION_TESTS") == "" {
-		t.Skip("Skipping integration test. Set INTEGRATION_TESTS=1 to run.")
-	}
-
-	executor := NewTaskExecutor("main")
+	executor := integrationExecutor(t, "main")
 
 	// Test error case - missing farm_id
 	taskJSON := `{"task_name": "get_farm", "params": {}}`
 
-	responseJSON, err := executor.ExecuteTaskJSON([]byte(taskJSON))
+	responseJSON, err := executor.ExecuteTaskJSON(context.Background(), []byte(taskJSON))
 	if err != nil {
 		t.Fatalf("ExecuteTaskJSON should not return error: %v", err)
 	}
@@ -154,11 +179,7 @@ func TestIntegrationErrorHandling(t *testing.T) {
 }
 
 func TestIntegrationPagination(t *testing.T) {
-	if os.Getenv("INTEGRATION_TESTS") == "" {
-		t.Skip("Skipping integration test. Set INTEGRATION_TESTS=1 to run.")
-	}
-
-	executor := NewTaskExecutor("main")
+	executor := integrationExecutor(t, "main")
 
 	// Test pagination
 	tests := []struct {
@@ -175,30 +196,24 @@ func TestIntegrationPagination(t *testing.T) {
 			Params:   map[string]interface{}{"page": float64(tt.page)},
 		}
 
-		result, err := executor.ExecuteTask(task)
+		result, err := executor.ExecuteTask(context.Background(), task)
 		if err != nil {
 			t.Fatalf("failed to list farms page %d: %v", tt.page, err)
 		}
 
-		response, ok := result.(map[string]interface{})
+		response, ok := result.(ListResult[Farm])
 		if !ok {
-			t.Fatalf("expected map response, got %T", result)
+			t.Fatalf("expected list response, got %T", result)
 		}
 
-		farms, ok := response["farms"].([]Farm)
-		if !ok {
-			t.Fatalf("expected farms to be []Farm, got %T", response["farms"])
-		}
+		farms := response.Items
 
 		if len(farms) != tt.expected {
 			t.Errorf("page %d: expected %d farms, got %d", tt.page, tt.expected, len(farms))
 		}
 
-		page, ok := response["page"].(uint64)
-		if !ok {
-			t.Errorf("expected page to be uint64, got %T", response["page"])
-		} else if int(page) != tt.page {
-			t.Errorf("expected page %d, got %d", tt.page, int(page))
+		if int(response.Page) != tt.page {
+			t.Errorf("expected page %d, got %d", tt.page, int(response.Page))
 		}
 
 		t.Logf("Page %d: retrieved %d farms", tt.page, len(farms))
@@ -206,16 +221,12 @@ func TestIntegrationPagination(t *testing.T) {
 }
 
 func TestIntegrationNetworkConfiguration(t *testing.T) {
-	if os.Getenv("INTEGRATION_TESTS") == "" {
-		t.Skip("Skipping integration test. Set INTEGRATION_TESTS=1 to run.")
-	}
-
 	// Test different networks (main should work, others might not have data)
 	networks := []string{"main", "test"}
 
 	for _, network := range networks {
 		t.Run(network, func(t *testing.T) {
-			executor := NewTaskExecutor(network)
+			executor := integrationExecutor(t, network)
 
 			if executor.network != network {
 				t.Errorf("expected network %s, got %s", network, executor.network)
@@ -234,11 +245,7 @@ func TestIntegrationNetworkConfiguration(t *testing.T) {
 }
 
 func TestIntegrationPerformance(t *testing.T) {
-	if os.Getenv("INTEGRATION_TESTS") == "" {
-		t.Skip("Skipping integration test. Set INTEGRATION_TESTS=1 to run.")
-	}
-
-	executor := NewTaskExecutor("main")
+	executor := integrationExecutor(t, "main")
 
 	// Test response time
 	start := time.Now()
@@ -248,7 +255,7 @@ func TestIntegrationPerformance(t *testing.T) {
 		Params:   map[string]interface{}{},
 	}
 
-	_, err := executor.ExecuteTask(task)
+	_, err := executor.ExecuteTask(context.Background(), task)
 	if err != nil {
 		t.Fatalf("failed to list farms: %v", err)
 	}
@@ -0,0 +1,123 @@
+package executer
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+)
+
+// Networks lists the GridProxy networks NewTaskExecutor accepts
+var Networks = []string{"dev", "test", "qa", "main"}
+
+// Option configures a TaskExecutor created with NewTaskExecutor
+type Option func(*options)
+
+// options collects the Option values before the executor is built
+type options struct {
+	endpoints   []string
+	httpClient  *http.Client
+	gridClient  client.Client
+	pricing     PricingPolicySource
+	cache       *CacheConfig // nil means DefaultCacheConfig
+	noCache     bool
+	timeout     time.Duration
+	logger      *log.Logger
+	maxPageSize int
+}
+
+// WithEndpoints talks to the given GridProxy endpoints, in order of
+// preference, instead of the network's defaults
+func WithEndpoints(endpoints ...string) Option {
+	return func(o *options) {
+		o.endpoints = append([]string{}, endpoints...)
+	}
+}
+
+// WithHTTPClient sends the requests to every GridProxy endpoint with
+// httpClient instead of http.DefaultClient, e.g. for a proxy or custom TLS
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithClient uses gridClient for GridProxy calls instead of a failover client
+// over the network's endpoints. It is still put behind the response cache
+// unless WithoutCache is given.
+func WithClient(gridClient client.Client) Option {
+	return func(o *options) {
+		o.gridClient = gridClient
+	}
+}
+
+// WithPricingPolicies looks up farms' pricing policies in source instead of
+// the network's TFChain GraphQL indexer
+func WithPricingPolicies(source PricingPolicySource) Option {
+	return func(o *options) {
+		o.pricing = source
+	}
+}
+
+// WithCache caches GridProxy responses with config instead of DefaultCacheConfig
+func WithCache(config CacheConfig) Option {
+	return func(o *options) {
+		o.cache = &config
+		o.noCache = false
+	}
+}
+
+// WithoutCache sends every call to GridProxy
+func WithoutCache() Option {
+	return func(o *options) {
+		o.cache = nil
+		o.noCache = true
+	}
+}
+
+// WithTimeout bounds tasks that set no timeout in their request or
+// registration, instead of DefaultTaskTimeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithLogger logs task execution to logger instead of the standard logger
+func WithLogger(logger *log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMaxPageSize lowers the largest page size list tasks use; larger
+// page_size parameters are capped. Values of zero or above MaxPageSize keep
+// MaxPageSize.
+func WithMaxPageSize(size int) Option {
+	return func(o *options) {
+		o.maxPageSize = size
+	}
+}
+
+// validate checks the collected options
+func (o options) validate() error {
+	if o.gridClient != nil && o.endpoints != nil {
+		return errors.New("WithClient and WithEndpoints cannot be combined")
+	}
+	if o.gridClient != nil && o.httpClient != nil {
+		return errors.New("WithClient and WithHTTPClient cannot be combined")
+	}
+	if o.endpoints != nil && len(o.endpoints) == 0 {
+		return errors.New("WithEndpoints requires at least one endpoint")
+	}
+	if o.timeout < 0 || o.timeout > MaxTaskTimeout {
+		return fmt.Errorf("timeout must be between 0s and %s, got: %s", MaxTaskTimeout, o.timeout)
+	}
+	if o.maxPageSize < 0 {
+		return fmt.Errorf("max page size must not be negative, got: %d", o.maxPageSize)
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package executer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"anubis-executer/shape"
+)
+
+// Output formats accepted by RenderResponse
+const (
+	OutputJSON   = "json"   // The response JSON as returned by ExecuteTaskJSON
+	OutputPretty = "pretty" // Indented JSON
+	OutputTable  = "table"  // Aligned columns of the response data
+	OutputYAML   = "yaml"
+)
+
+// OutputFormats lists the formats accepted by RenderResponse
+var OutputFormats = []string{OutputJSON, OutputPretty, OutputTable, OutputYAML}
+
+// RenderResponse writes a response produced by ExecuteTaskJSON in the given format.
+// Tables show the scalar fields of the response data; nested values are left
+// out, so use pretty or yaml to see everything.
+func RenderResponse(w io.Writer, responseJSON []byte, format string) error {
+	switch format {
+	case OutputJSON:
+		_, err := fmt.Fprintln(w, string(bytes.TrimSpace(responseJSON)))
+		return err
+	case OutputPretty:
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, responseJSON, "", "  "); err != nil {
+			return fmt.Errorf("invalid response JSON: %v", err)
+		}
+		_, err := fmt.Fprintln(w, indented.String())
+		return err
+	}
+
+	// Numbers are kept as written so IDs and amounts are not reformatted
+	decoder := json.NewDecoder(bytes.NewReader(responseJSON))
+	decoder.UseNumber()
+	var response interface{}
+	if err := decoder.Decode(&response); err != nil {
+		return fmt.Errorf("invalid response JSON: %v", err)
+	}
+
+	switch format {
+	case OutputYAML:
+		_, err := fmt.Fprintln(w, strings.Join(yamlLines(response), "\n"))
+		return err
+	case OutputTable:
+		responseMap, ok := response.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a response object, got %T", response)
+		}
+		return renderTable(w, responseMap)
+	default:
+		return fmt.Errorf("output format must be one of [%s], got: %s", strings.Join(OutputFormats, ", "), format)
+	}
+}
+
+// renderTable writes a response as tables: the data of a task, or a
+// section per step of a plan
+func renderTable(w io.Writer, response map[string]interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if steps, ok := response["steps"].([]interface{}); ok {
+		for i, item := range steps {
+			step, _ := item.(map[string]interface{})
+			if i > 0 {
+				fmt.Fprintln(tw)
+			}
+
+			status := "ok"
+			switch {
+			case step["skipped"] == true:
+				status = "skipped"
+			case step["success"] != true:
+				status = "failed"
+			}
+			fmt.Fprintf(tw, "== step %v: %v (%s) ==\n", step["id"], step["task_name"], status)
+			renderTableBody(tw, step)
+		}
+		if message, ok := response["error"].(string); ok && message != "" {
+			fmt.Fprintf(tw, "\nError: %s\n", message)
+		}
+		return tw.Flush()
+	}
+
+	renderTableBody(tw, response)
+	return tw.Flush()
+}
+
+// renderTableBody writes the error of a failed response or step, or its data
+func renderTableBody(w io.Writer, response map[string]interface{}) {
+	if response["success"] != true {
+		if message, ok := response["error"].(string); ok && message != "" {
+			fmt.Fprintf(w, "Error: %s\n", message)
+		}
+		if fieldErrors, ok := response["errors"].([]interface{}); ok {
+			for _, item := range fieldErrors {
+				fieldError, _ := item.(map[string]interface{})
+				fmt.Fprintf(w, "  %v:\t%v\n", fieldError["field"], fieldError["message"])
+			}
+		}
+		return
+	}
+
+	switch data := response["data"].(type) {
+	case map[string]interface{}:
+		if items, ok := data["items"].([]interface{}); ok {
+			renderRows(w, items)
+
+			// The rest of a list envelope is a one-line summary
+			var summary []string
+			for _, key := range shape.SortedKeys(data) {
+				if key != "items" && !shape.IsNested(data[key]) {
+					summary = append(summary, fmt.Sprintf("%s: %s", key, shape.TableCell(data[key])))
+				}
+			}
+			fmt.Fprintf(w, "\n%s\n", strings.Join(summary, "  "))
+			return
+		}
+		for _, key := range shape.SortedKeys(data) {
+			if !shape.IsNested(data[key]) {
+				fmt.Fprintf(w, "%s\t%s\n", key, shape.TableCell(data[key]))
+			}
+		}
+	case []interface{}:
+		renderRows(w, data)
+	case nil:
+	default:
+		fmt.Fprintln(w, shape.TableCell(data))
+	}
+}
+
+// renderRows writes items as a table with a column per scalar field, or a
+// single column when the items are themselves scalars
+func renderRows(w io.Writer, items []interface{}) {
+	if len(items) == 0 {
+		fmt.Fprintln(w, "(no items)")
+		return
+	}
+
+	var columns []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range shape.SortedKeys(row) {
+			if !seen[key] && !shape.IsNested(row[key]) {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+
+	if len(columns) == 0 {
+		for _, item := range items {
+			fmt.Fprintln(w, shape.TableCell(item))
+		}
+		return
+	}
+
+	fmt.Fprintln(w, strings.Join(columns, "\t"))
+	for _, item := range items {
+		row, _ := item.(map[string]interface{})
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			if value, ok := row[column]; ok {
+				cells[i] = shape.TableCell(value)
+			}
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+}
+
+// yamlPlain matches strings that can be written in YAML without quotes
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./@-]*( [A-Za-z0-9_./@()-]+)*$`)
+
+// yamlLines renders a decoded JSON value as YAML lines without indentation
+func yamlLines(value interface{}) []string {
+	var lines []string
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return []string{"{}"}
+		}
+		for _, key := range shape.SortedKeys(v) {
+			if !isYAMLBlock(v[key]) {
+				lines = append(lines, yamlScalar(key)+": "+yamlScalar(v[key]))
+				continue
+			}
+			lines = append(lines, yamlScalar(key)+":")
+			for _, line := range yamlLines(v[key]) {
+				lines = append(lines, "  "+line)
+			}
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return []string{"[]"}
+		}
+		for _, item := range v {
+			itemLines := yamlLines(item)
+			lines = append(lines, "- "+itemLines[0])
+			for _, line := range itemLines[1:] {
+				lines = append(lines, "  "+line)
+			}
+		}
+	default:
+		lines = append(lines, yamlScalar(v))
+	}
+	return lines
+}
+
+// isYAMLBlock reports whether value is a non-empty object or array
+func isYAMLBlock(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return len(v) > 0
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return false
+	}
+}
+
+// yamlScalar formats a scalar, or an empty object or array, as YAML
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return fmt.Sprint(v)
+	case json.Number:
+		return v.String()
+	case string:
+		switch strings.ToLower(v) {
+		case "true", "false", "null", "yes", "no", "on", "off":
+			return fmt.Sprintf("%q", v)
+		}
+		if yamlPlain.MatchString(v) {
+			return v
+		}
+		return fmt.Sprintf("%q", v)
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	default:
+		return fmt.Sprint(v)
+	}
+}
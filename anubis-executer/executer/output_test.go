@@ -0,0 +1,102 @@
+package executer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderResponse(t *testing.T) {
+	listResponse := `{"success":true,"data":{"items":[{"farmId":1,"name":"Freefarm","publicIps":[]},{"farmId":2,"name":"My Farm"}],"total_count":2,"page":1,"page_size":5,"has_more":false,"network":"main"}}`
+	failedResponse := `{"success":false,"error":"farm_id parameter is required","errors":[{"field":"farm_id","message":"parameter is required"}]}`
+	planResponse := `{"success":false,"error":"1 of 2 steps failed","steps":[{"id":"0","task_name":"get_farm","success":true,"data":{"farmId":1,"name":"Freefarm"}},{"id":"1","task_name":"get_node","success":false,"error":"node with ID 9 not found"}]}`
+
+	tests := []struct {
+		name          string
+		response      string
+		format        string
+		expected      string
+		expectedError bool
+	}{
+		{"json is written as is", listResponse, OutputJSON, listResponse + "\n", false},
+		{"pretty indents", `{"success":true,"data":{"farmId":1}}`, OutputPretty, "{\n  \"success\": true,\n  \"data\": {\n    \"farmId\": 1\n  }\n}\n", false},
+		{
+			"yaml", listResponse, OutputYAML,
+			"data:\n" +
+				"  has_more: false\n" +
+				"  items:\n" +
+				"    - farmId: 1\n" +
+				"      name: Freefarm\n" +
+				"      publicIps: []\n" +
+				"    - farmId: 2\n" +
+				"      name: My Farm\n" +
+				"  network: main\n" +
+				"  page: 1\n" +
+				"  page_size: 5\n" +
+				"  total_count: 2\n" +
+				"success: true\n",
+			false,
+		},
+		{"yaml quotes ambiguous strings", `{"a":"yes","b":"185.69.167.1","c":"key: value","d":""}`, OutputYAML, "a: \"yes\"\nb: \"185.69.167.1\"\nc: \"key: value\"\nd: \"\"\n", false},
+		{
+			"table of list items", listResponse, OutputTable,
+			"farmId  name\n" +
+				"1       Freefarm\n" +
+				"2       My Farm\n" +
+				"\n" +
+				"has_more: false  network: main  page: 1  page_size: 5  total_count: 2\n",
+			false,
+		},
+		{
+			"table of a failed task", failedResponse, OutputTable,
+			"Error: farm_id parameter is required\n" +
+				"  farm_id:  parameter is required\n",
+			false,
+		},
+		{
+			"table of a plan", planResponse, OutputTable,
+			"== step 0: get_farm (ok) ==\n" +
+				"farmId  1\n" +
+				"name    Freefarm\n" +
+				"\n" +
+				"== step 1: get_node (failed) ==\n" +
+				"Error: node with ID 9 not found\n" +
+				"\n" +
+				"Error: 1 of 2 steps failed\n",
+			false,
+		},
+		{"unknown format", listResponse, "xml", "", true},
+		{"invalid JSON", `{"success":`, OutputYAML, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := RenderResponse(&out, []byte(tt.response), tt.format)
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if out.String() != tt.expected {
+				t.Errorf("unexpected output:\n%s\nexpected:\n%s", out.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderResponseEmptyList(t *testing.T) {
+	var out bytes.Buffer
+	if err := RenderResponse(&out, []byte(`{"success":true,"data":{"items":[],"total_count":0}}`), OutputTable); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(out.String(), "(no items)\n") {
+		t.Errorf("expected an empty list marker, got %q", out.String())
+	}
+}
@@ -0,0 +1,138 @@
+package executer
+
+import (
+	"context"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// defaultPageSize is the number of items returned per page by list tasks
+const defaultPageSize = 5
+
+// MaxPageSize is the largest page_size a list task accepts
+const MaxPageSize = 100
+
+// MaxListItems caps how many items a list task collects when walking pages
+// with all or max_items, so a single request cannot pull the whole grid
+const MaxListItems = 1000
+
+// ListResult is the envelope returned by every list task
+type ListResult[T any] struct {
+	Items      []T    `json:"items"`
+	TotalCount int    `json:"total_count"`
+	Page       uint64 `json:"page"`      // Requested page; items start at its first item
+	PageSize   uint64 `json:"page_size"` // Page size used to fetch items
+	HasMore    bool   `json:"has_more"`  // Whether more items exist past the returned ones
+	Network    string `json:"network"`
+}
+
+// listOptions controls how a list task paginates
+type listOptions struct {
+	page     uint64
+	pageSize uint64
+	maxItems int // Zero fetches a single page
+}
+
+// listOptionsFromParams reads page, page_size, all and max_items.
+// The page size is capped at the executor's maximum page size.
+func (te *TaskExecutor) listOptionsFromParams(params map[string]interface{}) (listOptions, error) {
+	opts := listOptions{page: 1, pageSize: defaultPageSize}
+
+	if pageParam, ok := params["page"]; ok {
+		page, err := parseUint64(pageParam)
+		if err != nil {
+			return opts, paramError("page", "invalid page format: %v", err)
+		}
+		opts.page = page
+	}
+
+	if sizeParam, ok := params["page_size"]; ok {
+		size, err := parseUint64(sizeParam)
+		if err != nil {
+			return opts, paramError("page_size", "invalid page_size format: %v", err)
+		}
+		opts.pageSize = size
+	}
+	if maxSize := te.pageSizeLimit(); opts.pageSize > maxSize {
+		opts.pageSize = maxSize
+	}
+	if opts.pageSize == 0 {
+		opts.pageSize = defaultPageSize
+	}
+
+	if maxParam, ok := params["max_items"]; ok {
+		maxItems, err := parseUint64(maxParam)
+		if err != nil {
+			return opts, paramError("max_items", "invalid max_items format: %v", err)
+		}
+		opts.maxItems = int(maxItems)
+	}
+	if all, ok := params["all"].(bool); ok && all && opts.maxItems == 0 {
+		opts.maxItems = MaxListItems
+	}
+	if opts.maxItems > MaxListItems {
+		opts.maxItems = MaxListItems
+	}
+
+	return opts, nil
+}
+
+// pageSizeLimit returns the executor's maximum page size
+func (te *TaskExecutor) pageSizeLimit() uint64 {
+	if te.maxPageSize > 0 && te.maxPageSize < MaxPageSize {
+		return uint64(te.maxPageSize)
+	}
+	return MaxPageSize
+}
+
+// listPages fetches one page, or walks pages until maxItems items are
+// collected or the upstream total is reached, and wraps them in a ListResult.
+// Walks start at the requested page but fetch the largest pages allowed, so
+// they take as few upstream calls as possible.
+func listPages[T any](ctx context.Context, te *TaskExecutor, opts listOptions, fetch func(ctx context.Context, limit types.Limit) ([]T, int, error)) (ListResult[T], error) {
+	result := ListResult[T]{
+		Items:    []T{},
+		Page:     opts.page,
+		PageSize: opts.pageSize,
+		Network:  te.network,
+	}
+
+	limit := types.Limit{Size: opts.pageSize, Page: opts.page, RetCount: true}
+	skip := 0 // Items of the first fetched page before the requested one
+	if opts.maxItems > 0 {
+		offset := (opts.page - 1) * opts.pageSize
+		limit.Size = te.pageSizeLimit()
+		limit.Page = offset/limit.Size + 1
+		skip = int(offset % limit.Size)
+		result.PageSize = limit.Size
+	}
+
+	for {
+		items, totalCount, err := fetch(ctx, limit)
+		if err != nil {
+			return result, err
+		}
+
+		seen := (limit.Page-1)*limit.Size + uint64(len(items))
+		if skip > 0 {
+			items = items[min(skip, len(items)):]
+			skip = 0
+		}
+
+		result.Items = append(result.Items, items...)
+		result.TotalCount = totalCount
+		result.HasMore = seen < uint64(totalCount)
+
+		if opts.maxItems == 0 || !result.HasMore || len(items) == 0 || len(result.Items) >= opts.maxItems {
+			break
+		}
+		limit.Page++
+	}
+
+	if opts.maxItems > 0 && len(result.Items) > opts.maxItems {
+		result.Items = result.Items[:opts.maxItems]
+		result.HasMore = true
+	}
+
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package executer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func newPaginationTestExecutor(farmCount int, maxPageSize int) *TaskExecutor {
+	farms := make([]types.Farm, farmCount)
+	for i := range farms {
+		farms[i] = types.Farm{FarmID: i + 1, Name: fmt.Sprintf("Farm%d", i+1)}
+	}
+
+	return &TaskExecutor{
+		gridClient:  &MockGridClient{farms: farms},
+		network:     "test",
+		maxPageSize: maxPageSize,
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	tests := []struct {
+		name             string
+		farmCount        int
+		maxPageSize      int
+		params           map[string]interface{}
+		expectedCount    int
+		expectedFirstID  int
+		expectedPage     uint64
+		expectedPageSize uint64
+		expectedHasMore  bool
+		expectedError    bool
+	}{
+		{"default page size", 12, 0, map[string]interface{}{}, 5, 1, 1, defaultPageSize, true, false},
+		{"custom page size", 12, 0, map[string]interface{}{"page_size": float64(10)}, 10, 1, 1, 10, true, false},
+		{"last page", 12, 0, map[string]interface{}{"page": float64(2), "page_size": float64(10)}, 2, 11, 2, 10, false, false},
+		{"page size capped at executor maximum", 30, 20, map[string]interface{}{"page_size": float64(50)}, 20, 1, 1, 20, true, false},
+		{"page size capped at MaxPageSize", 150, 0, map[string]interface{}{"page_size": float64(500)}, MaxPageSize, 1, 1, MaxPageSize, true, false},
+		{"all walks every page", 12, 0, map[string]interface{}{"all": true}, 12, 1, 1, MaxPageSize, false, false},
+		{"all starts at the requested page", 12, 0, map[string]interface{}{"all": true, "page": float64(2)}, 7, 6, 2, MaxPageSize, false, false},
+		{"all past the first large page", 150, 0, map[string]interface{}{"all": true, "page": float64(3), "page_size": float64(40)}, 70, 81, 3, MaxPageSize, false, false},
+		{"all fetches at the executor maximum", 30, 20, map[string]interface{}{"all": true}, 30, 1, 1, 20, false, false},
+		{"max_items stops mid page", 12, 0, map[string]interface{}{"max_items": float64(7)}, 7, 1, 1, MaxPageSize, true, false},
+		{"max_items beyond total", 12, 0, map[string]interface{}{"max_items": float64(50)}, 12, 1, 1, MaxPageSize, false, false},
+		{"all capped at MaxListItems", MaxListItems + 10, 0, map[string]interface{}{"all": true, "page_size": float64(100)}, MaxListItems, 1, 1, 100, true, false},
+		{"invalid page_size", 12, 0, map[string]interface{}{"page_size": "many"}, 0, 0, 0, 0, false, true},
+		{"invalid max_items", 12, 0, map[string]interface{}{"max_items": "lots"}, 0, 0, 0, 0, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := newPaginationTestExecutor(tt.farmCount, tt.maxPageSize)
+
+			result, err := executor.listFarms(context.Background(), tt.params)
+			if tt.expectedError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response := result.(ListResult[types.Farm])
+			if len(response.Items) != tt.expectedCount {
+				t.Fatalf("expected %d farms, got %d", tt.expectedCount, len(response.Items))
+			}
+			if response.Items[0].FarmID != tt.expectedFirstID {
+				t.Errorf("expected first farm %d, got %d", tt.expectedFirstID, response.Items[0].FarmID)
+			}
+			if response.TotalCount != tt.farmCount {
+				t.Errorf("expected total_count %d, got %d", tt.farmCount, response.TotalCount)
+			}
+			if response.Page != tt.expectedPage {
+				t.Errorf("expected page %d, got %d", tt.expectedPage, response.Page)
+			}
+			if response.PageSize != tt.expectedPageSize {
+				t.Errorf("expected page_size %d, got %d", tt.expectedPageSize, response.PageSize)
+			}
+			if response.HasMore != tt.expectedHasMore {
+				t.Errorf("expected has_more %v, got %v", tt.expectedHasMore, response.HasMore)
+			}
+		})
+	}
+}
+
+func TestListAllUsesLargestPages(t *testing.T) {
+	upstream := newCountingClient()
+	upstream.farms = make([]types.Farm, 250)
+	for i := range upstream.farms {
+		upstream.farms[i] = types.Farm{FarmID: i + 1}
+	}
+	executor := &TaskExecutor{gridClient: upstream, network: "test"}
+
+	result, err := executor.listFarms(context.Background(), map[string]interface{}{"all": true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	response := result.(ListResult[types.Farm])
+	if len(response.Items) != 250 || response.HasMore {
+		t.Errorf("expected every farm, got %d (has_more %v)", len(response.Items), response.HasMore)
+	}
+	if upstream.farmCalls != 3 {
+		t.Errorf("expected 3 upstream calls of %d farms, got %d", MaxPageSize, upstream.farmCalls)
+	}
+}
@@ -0,0 +1,387 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Failure policies for a Plan
+const (
+	OnFailureStop     = "stop"     // Skip every step after the first failure (default)
+	OnFailureContinue = "continue" // Keep running steps that do not depend on a failed step
+)
+
+// MaxPlanSteps limits the number of steps in a single plan
+const MaxPlanSteps = 20
+
+// Plan is an ordered list of tasks, optionally forming a DAG through
+// depends_on and output references. A step's params may reference the
+// output of an earlier step with {{steps.<id>.<path>}}, for example
+// {{steps.0.items[0].farmId}}; a reference that makes up a whole string
+// keeps the referenced value's type.
+type Plan struct {
+	Steps     []PlanStep `json:"steps"`
+	OnFailure string     `json:"on_failure,omitempty"` // stop or continue
+	Caller    *Caller    `json:"caller,omitempty"`     // Applied to every step
+}
+
+// PlanStep is a single task in a plan
+type PlanStep struct {
+	ID        string                 `json:"id,omitempty"` // Defaults to the step's index
+	TaskName  string                 `json:"task_name"`
+	Params    map[string]interface{} `json:"params"`
+	Timeout   string                 `json:"timeout,omitempty"`
+	Cache     string                 `json:"cache,omitempty"`
+	DependsOn []string               `json:"depends_on,omitempty"`
+}
+
+// StepResult is the outcome of a single plan step
+type StepResult struct {
+	ID       string       `json:"id"`
+	TaskName string       `json:"task_name"`
+	Success  bool         `json:"success"`
+	Skipped  bool         `json:"skipped,omitempty"`
+	Data     interface{}  `json:"data,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	ErrorDetails
+}
+
+// PlanResult holds the per-step results of a plan, in execution order
+type PlanResult struct {
+	Success bool         `json:"success"`
+	Steps   []StepResult `json:"steps"`
+}
+
+// stepReference matches {{steps.<id><path>}} where path is a sequence of .field and [index]
+var stepReference = regexp.MustCompile(`\{\{\s*steps\.([A-Za-z0-9_-]+)((?:\.[A-Za-z0-9_]+|\[[0-9]+\])*)\s*\}\}`)
+
+// pathSegment matches a single .field or [index] of a reference path
+var pathSegment = regexp.MustCompile(`\.([A-Za-z0-9_]+)|\[([0-9]+)\]`)
+
+// ParsePlan parses JSON bytes into a Plan struct
+func ParsePlan(data []byte) (*Plan, error) {
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// isPlanJSON reports whether data is a plan (an object with a "steps" key) rather than a single task
+func isPlanJSON(data []byte) bool {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return false
+	}
+	_, ok := probe["steps"]
+	return ok
+}
+
+// ExecutePlan runs the plan's steps in dependency order. Steps whose
+// dependencies failed are skipped; with the stop policy every step after
+// the first failure is skipped. An error is returned only for invalid plans.
+func (te *TaskExecutor) ExecutePlan(ctx context.Context, plan Plan) (*PlanResult, error) {
+	// Default step IDs are assigned on a copy so the caller's plan is untouched
+	plan.Steps = append([]PlanStep(nil), plan.Steps...)
+	order, deps, err := plan.schedule()
+	if err != nil {
+		return nil, err
+	}
+
+	te.logf("Executing plan with %d steps", len(plan.Steps))
+
+	result := &PlanResult{Success: true}
+	outputs := make(map[string]interface{}, len(plan.Steps))
+	failed := make(map[string]bool, len(plan.Steps))
+	stopped := false
+
+	for _, index := range order {
+		step := plan.Steps[index]
+		stepResult := StepResult{ID: step.ID, TaskName: step.TaskName}
+
+		if blocker := firstFailed(deps[step.ID], failed); stopped || blocker != "" {
+			stepResult.Skipped = true
+			if blocker != "" {
+				stepResult.Error = fmt.Sprintf("skipped: step %s did not succeed", blocker)
+			} else {
+				stepResult.Error = "skipped: an earlier step failed"
+			}
+			failed[step.ID] = true
+			result.Steps = append(result.Steps, stepResult)
+			continue
+		}
+
+		data, err := te.executeStep(ctx, plan, step, outputs)
+		if err != nil {
+			stepResult.Error = err.Error()
+			stepResult.ErrorDetails = ClassifyError(err)
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				stepResult.Errors = validationErr.Errors
+			}
+
+			failed[step.ID] = true
+			result.Success = false
+			stopped = plan.OnFailure != OnFailureContinue
+		} else {
+			stepResult.Success = true
+			stepResult.Data = data
+			outputs[step.ID] = data
+		}
+		result.Steps = append(result.Steps, stepResult)
+	}
+
+	return result, nil
+}
+
+// executeStep resolves the step's output references and executes its task
+func (te *TaskExecutor) executeStep(ctx context.Context, plan Plan, step PlanStep, outputs map[string]interface{}) (interface{}, error) {
+	params, err := resolveReferences(step.Params, outputs)
+	if err != nil {
+		return nil, &TaskError{ErrorDetails: ErrorDetails{Code: CodeInvalidParams}, Message: err.Error(), Err: err}
+	}
+
+	var resolved map[string]interface{}
+	if params != nil {
+		resolved = params.(map[string]interface{})
+	}
+
+	return te.ExecuteTask(ctx, Task{
+		TaskName: step.TaskName,
+		Params:   resolved,
+		Caller:   plan.Caller,
+		Timeout:  step.Timeout,
+		Cache:    step.Cache,
+	})
+}
+
+// firstFailed returns the first of ids that failed or was skipped, or ""
+func firstFailed(ids []string, failed map[string]bool) string {
+	for _, id := range ids {
+		if failed[id] {
+			return id
+		}
+	}
+	return ""
+}
+
+// schedule validates the plan, assigns default step IDs and returns the
+// execution order along with each step's dependencies (explicit depends_on
+// plus every step its params reference). Ties keep the declared order.
+func (p *Plan) schedule() ([]int, map[string][]string, error) {
+	if len(p.Steps) == 0 {
+		return nil, nil, fmt.Errorf("plan has no steps")
+	}
+	if len(p.Steps) > MaxPlanSteps {
+		return nil, nil, fmt.Errorf("plan has %d steps, maximum is %d", len(p.Steps), MaxPlanSteps)
+	}
+
+	switch p.OnFailure {
+	case "", OnFailureStop, OnFailureContinue:
+	default:
+		return nil, nil, fmt.Errorf("on_failure must be one of [%s %s], got: %s", OnFailureStop, OnFailureContinue, p.OnFailure)
+	}
+
+	indexByID := make(map[string]int, len(p.Steps))
+	for i := range p.Steps {
+		if p.Steps[i].ID == "" {
+			p.Steps[i].ID = strconv.Itoa(i)
+		}
+		if _, exists := indexByID[p.Steps[i].ID]; exists {
+			return nil, nil, fmt.Errorf("duplicate step id: %s", p.Steps[i].ID)
+		}
+		indexByID[p.Steps[i].ID] = i
+	}
+
+	deps := make(map[string][]string, len(p.Steps))
+	for _, step := range p.Steps {
+		seen := make(map[string]bool)
+		for _, id := range append(append([]string{}, step.DependsOn...), referencedSteps(step.Params)...) {
+			if _, ok := indexByID[id]; !ok {
+				return nil, nil, fmt.Errorf("step %s depends on unknown step %s", step.ID, id)
+			}
+			if id == step.ID {
+				return nil, nil, fmt.Errorf("step %s depends on itself", step.ID)
+			}
+			if !seen[id] {
+				seen[id] = true
+				deps[step.ID] = append(deps[step.ID], id)
+			}
+		}
+	}
+
+	// Repeatedly pick the earliest declared step whose dependencies are done
+	done := make(map[string]bool, len(p.Steps))
+	order := make([]int, 0, len(p.Steps))
+	for len(order) < len(p.Steps) {
+		next := -1
+		for i, step := range p.Steps {
+			if done[step.ID] {
+				continue
+			}
+			ready := true
+			for _, id := range deps[step.ID] {
+				if !done[id] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			return nil, nil, fmt.Errorf("plan steps have a dependency cycle")
+		}
+		done[p.Steps[next].ID] = true
+		order = append(order, next)
+	}
+
+	return order, deps, nil
+}
+
+// referencedSteps returns the IDs of all steps referenced anywhere in value
+func referencedSteps(value interface{}) []string {
+	var ids []string
+	switch v := value.(type) {
+	case string:
+		for _, match := range stepReference.FindAllStringSubmatch(v, -1) {
+			ids = append(ids, match[1])
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys) // deterministic dependency order
+		for _, key := range keys {
+			ids = append(ids, referencedSteps(v[key])...)
+		}
+	case []interface{}:
+		for _, item := range v {
+			ids = append(ids, referencedSteps(item)...)
+		}
+	}
+	return ids
+}
+
+// resolveReferences returns a copy of value with every step reference replaced by its output
+func resolveReferences(value interface{}, outputs map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return resolveString(v, outputs)
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			r, err := resolveReferences(item, outputs)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = r
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := resolveReferences(item, outputs)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = r
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveString substitutes references in s. A string that is exactly one
+// reference resolves to the referenced value itself, keeping its type.
+func resolveString(s string, outputs map[string]interface{}) (interface{}, error) {
+	if match := stepReference.FindStringSubmatchIndex(s); match != nil && match[0] == 0 && match[1] == len(s) {
+		return lookupReference(s, outputs)
+	}
+
+	var resolveErr error
+	resolved := stepReference.ReplaceAllStringFunc(s, func(ref string) string {
+		value, err := lookupReference(ref, outputs)
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = err
+			}
+			return ref
+		}
+		return fmt.Sprint(value)
+	})
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+	return resolved, nil
+}
+
+// lookupReference returns the value a single {{steps...}} reference points to.
+// Outputs are addressed by their JSON field names, e.g. items[0].farmId.
+func lookupReference(ref string, outputs map[string]interface{}) (interface{}, error) {
+	match := stepReference.FindStringSubmatch(ref)
+	id, path := match[1], match[2]
+
+	output, ok := outputs[id]
+	if !ok {
+		return nil, fmt.Errorf("reference %s: step %s has no output", ref, id)
+	}
+
+	// Round-trip through JSON so struct outputs are addressed by JSON names
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil, fmt.Errorf("reference %s: %v", ref, err)
+	}
+	var current interface{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, fmt.Errorf("reference %s: %v", ref, err)
+	}
+
+	walked := "steps." + id
+	for _, segment := range pathSegment.FindAllStringSubmatch(path, -1) {
+		if field := segment[1]; field != "" {
+			object, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("reference %s: %s is not an object", ref, walked)
+			}
+			if current, ok = object[field]; !ok {
+				return nil, fmt.Errorf("reference %s: %s has no field %s", ref, walked, field)
+			}
+			walked += "." + field
+			continue
+		}
+
+		index, _ := strconv.Atoi(segment[2])
+		list, ok := current.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("reference %s: %s is not a list", ref, walked)
+		}
+		if index >= len(list) {
+			return nil, fmt.Errorf("reference %s: index %d out of range, %s has %d items", ref, index, walked, len(list))
+		}
+		current = list[index]
+		walked += fmt.Sprintf("[%d]", index)
+	}
+
+	return current, nil
+}
+
+// planSummary describes the first failed step of a plan result
+func planSummary(result *PlanResult) string {
+	for _, step := range result.Steps {
+		if !step.Success && !step.Skipped {
+			return fmt.Sprintf("step %s (%s) failed: %s", step.ID, step.TaskName, step.Error)
+		}
+	}
+	return ""
+}
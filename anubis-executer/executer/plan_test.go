@@ -0,0 +1,214 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func newPlanTestExecutor() *TaskExecutor {
+	return &TaskExecutor{
+		gridClient: &MockGridClient{
+			farms: []types.Farm{
+				{FarmID: 4, Name: "BelgianFarm", TwinID: 42},
+				{FarmID: 5, Name: "OtherFarm", TwinID: 7},
+			},
+			nodes: []types.Node{
+				{NodeID: 11, FarmID: 4, Status: "up"},
+				{NodeID: 12, FarmID: 5, Status: "up"},
+			},
+		},
+		network: "test",
+	}
+}
+
+func TestExecutePlanReferences(t *testing.T) {
+	executor := newPlanTestExecutor()
+
+	plan := Plan{Steps: []PlanStep{
+		{TaskName: "list_farms", Params: map[string]interface{}{"name": "BelgianFarm"}},
+		{TaskName: "list_nodes", Params: map[string]interface{}{
+			"farm_ids": []interface{}{"{{steps.0.items[0].farmId}}"},
+		}},
+		{ID: "farm", TaskName: "get_farm", Params: map[string]interface{}{"farm_id": "{{ steps.1.items[0].farmId }}"}},
+	}}
+
+	result, err := executor.ExecutePlan(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !result.Success || len(result.Steps) != 3 {
+		t.Fatalf("expected 3 successful steps, got %+v", result)
+	}
+
+	nodes := result.Steps[1].Data.(ListResult[types.Node]).Items
+	if len(nodes) != 1 || nodes[0].NodeID != 11 {
+		t.Errorf("expected node 11 of farm 4, got %v", nodes)
+	}
+
+	if farm := result.Steps[2].Data.(types.Farm); farm.FarmID != 4 || result.Steps[2].ID != "farm" {
+		t.Errorf("expected step farm to return farm 4, got %+v", result.Steps[2])
+	}
+
+	if plan.Steps[0].ID != "" {
+		t.Errorf("expected caller's plan to be left untouched")
+	}
+}
+
+func TestExecutePlanFailurePolicy(t *testing.T) {
+	steps := []PlanStep{
+		{ID: "missing", TaskName: "get_farm", Params: map[string]interface{}{"farm_id": 99}},
+		{ID: "dependent", TaskName: "get_farm", Params: map[string]interface{}{"farm_id": "{{steps.missing.farmId}}"}},
+		{ID: "independent", TaskName: "list_farms", Params: map[string]interface{}{}},
+	}
+
+	tests := []struct {
+		name      string
+		onFailure string
+		expected  map[string]string // step ID -> success, failed or skipped
+	}{
+		{
+			name:     "stop by default",
+			expected: map[string]string{"missing": "failed", "dependent": "skipped", "independent": "skipped"},
+		},
+		{
+			name:      "continue",
+			onFailure: OnFailureContinue,
+			expected:  map[string]string{"missing": "failed", "dependent": "skipped", "independent": "success"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := newPlanTestExecutor().ExecutePlan(context.Background(), Plan{Steps: steps, OnFailure: tt.onFailure})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Success {
+				t.Errorf("expected plan to fail")
+			}
+
+			for _, step := range result.Steps {
+				outcome := "failed"
+				if step.Success {
+					outcome = "success"
+				} else if step.Skipped {
+					outcome = "skipped"
+				}
+				if outcome != tt.expected[step.ID] {
+					t.Errorf("step %s: expected %s, got %s (%s)", step.ID, tt.expected[step.ID], outcome, step.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestExecutePlanInvalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		plan          Plan
+		expectedError string
+	}{
+		{"no steps", Plan{}, "plan has no steps"},
+		{
+			"unknown reference",
+			Plan{Steps: []PlanStep{{TaskName: "get_farm", Params: map[string]interface{}{"farm_id": "{{steps.7.farmId}}"}}}},
+			"step 0 depends on unknown step 7",
+		},
+		{
+			"duplicate id",
+			Plan{Steps: []PlanStep{{ID: "a", TaskName: "list_farms"}, {ID: "a", TaskName: "list_farms"}}},
+			"duplicate step id: a",
+		},
+		{
+			"cycle",
+			Plan{Steps: []PlanStep{
+				{ID: "a", TaskName: "list_farms", DependsOn: []string{"b"}},
+				{ID: "b", TaskName: "list_farms", DependsOn: []string{"a"}},
+			}},
+			"plan steps have a dependency cycle",
+		},
+		{
+			"bad policy",
+			Plan{Steps: []PlanStep{{TaskName: "list_farms"}}, OnFailure: "retry"},
+			"on_failure must be one of [stop continue], got: retry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newPlanTestExecutor().ExecutePlan(context.Background(), tt.plan)
+			if err == nil || err.Error() != tt.expectedError {
+				t.Errorf("expected error %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestResolveReferences(t *testing.T) {
+	outputs := map[string]interface{}{
+		"0": map[string]interface{}{"farms": []types.Farm{{FarmID: 4, Name: "BelgianFarm"}}},
+	}
+
+	tests := []struct {
+		name          string
+		value         interface{}
+		expected      interface{}
+		expectedError string
+	}{
+		{"whole string keeps type", "{{steps.0.farms[0].farmId}}", float64(4), ""},
+		{"embedded reference", "farm {{steps.0.farms[0].name}}", "farm BelgianFarm", ""},
+		{"no reference", "plain", "plain", ""},
+		{"index out of range", "{{steps.0.farms[3]}}", nil, "index 3 out of range, steps.0.farms has 1 items"},
+		{"missing field", "{{steps.0.nodes}}", nil, "steps.0 has no field nodes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := resolveReferences(tt.value, outputs)
+			if tt.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+					t.Errorf("expected error containing %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resolved != tt.expected {
+				t.Errorf("expected %v (%T), got %v (%T)", tt.expected, tt.expected, resolved, resolved)
+			}
+		})
+	}
+}
+
+func TestExecuteTaskJSONPlan(t *testing.T) {
+	planJSON := `{
+		"on_failure": "continue",
+		"steps": [
+			{"task_name": "list_farms", "params": {"name": "BelgianFarm"}},
+			{"task_name": "get_farm", "params": {"farm_id": "{{steps.0.items[0].farmId}}"}}
+		]
+	}`
+
+	responseJSON, err := newPlanTestExecutor().ExecuteTaskJSON(context.Background(), []byte(planJSON))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var response TaskResponse
+	if err := json.Unmarshal(responseJSON, &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if !response.Success || len(response.Steps) != 2 {
+		t.Fatalf("expected 2 successful steps, got %s", responseJSON)
+	}
+	if farm := response.Steps[1].Data.(map[string]interface{}); farm["farmId"] != float64(4) {
+		t.Errorf("expected get_farm to resolve farm 4, got %v", farm)
+	}
+}
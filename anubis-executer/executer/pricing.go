@@ -0,0 +1,192 @@
+package executer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// pricingPolicyTTL is how long a pricing policy fetched from the chain is reused
+const pricingPolicyTTL = time.Hour
+
+// PricingPolicySource looks up the pricing policies farms use by ID.
+// GridProxy only serves a farm's pricingPolicyId, not the policy itself.
+type PricingPolicySource interface {
+	PricingPolicy(ctx context.Context, id int) (PricingPolicy, error)
+}
+
+// PricingPolicies is a fixed PricingPolicySource, e.g. for tests or a
+// network without a GraphQL indexer
+type PricingPolicies map[int]PricingPolicy
+
+// PricingPolicy implements PricingPolicySource
+func (p PricingPolicies) PricingPolicy(ctx context.Context, id int) (PricingPolicy, error) {
+	policy, ok := p[id]
+	if !ok {
+		return PricingPolicy{}, notFoundError("pricing policy %d not found", id)
+	}
+	return policy, nil
+}
+
+// pricingPolicyQuery selects a pricing policy from the TFChain GraphQL indexer
+const pricingPolicyQuery = `query PricingPolicy($id: Int!) {
+  pricingPolicies(where: {pricingPolicyID_eq: $id}, limit: 1) {
+    pricingPolicyID
+    name
+    cu { value }
+    su { value }
+    nu { value }
+    ipu { value }
+    uniqueName { value }
+    domainName { value }
+    dedicatedNodeDiscount
+  }
+}`
+
+// GraphQLPricingPolicies is a PricingPolicySource that reads the pricing
+// policies from the TFChain GraphQL indexer and caches them for an hour
+type GraphQLPricingPolicies struct {
+	url  string
+	http *http.Client
+	now  func() time.Time
+
+	mu       sync.Mutex
+	policies map[int]cachedPricingPolicy
+}
+
+// cachedPricingPolicy is a fetched pricing policy and when it expires
+type cachedPricingPolicy struct {
+	policy  PricingPolicy
+	expires time.Time
+}
+
+// NewGraphQLPricingPolicies reads pricing policies from the GraphQL indexer
+// at url; a nil httpClient means http.DefaultClient
+func NewGraphQLPricingPolicies(url string, httpClient *http.Client) *GraphQLPricingPolicies {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &GraphQLPricingPolicies{
+		url:      url,
+		http:     httpClient,
+		now:      time.Now,
+		policies: make(map[int]cachedPricingPolicy),
+	}
+}
+
+// policyPrice is a price of a pricing policy as the indexer returns it
+type policyPrice struct {
+	Value json.Number `json:"value"` // Numbers above 2^53 may come as strings
+}
+
+// uint64 returns the price, or an error when it is not a whole number
+func (p policyPrice) uint64() (uint64, error) {
+	return strconv.ParseUint(p.Value.String(), 10, 64)
+}
+
+// PricingPolicy implements PricingPolicySource
+func (g *GraphQLPricingPolicies) PricingPolicy(ctx context.Context, id int) (PricingPolicy, error) {
+	g.mu.Lock()
+	cached, ok := g.policies[id]
+	g.mu.Unlock()
+	if ok && g.now().Before(cached.expires) {
+		return cached.policy, nil
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"query":     pricingPolicyQuery,
+		"variables": map[string]interface{}{"id": id},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url, bytes.NewReader(body))
+	if err != nil {
+		return PricingPolicy{}, fmt.Errorf("failed to create pricing policy request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return PricingPolicy{}, fmt.Errorf("failed to fetch pricing policy %d: %w", id, ctx.Err())
+		}
+		return PricingPolicy{}, g.unavailable(id, 0, err.Error())
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return PricingPolicy{}, g.unavailable(id, 0, err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return PricingPolicy{}, g.unavailable(id, resp.StatusCode, fmt.Sprintf("request failed with status code %d", resp.StatusCode))
+	}
+
+	var response struct {
+		Data struct {
+			PricingPolicies []struct {
+				PricingPolicyID       int         `json:"pricingPolicyID"`
+				Name                  string      `json:"name"`
+				CU                    policyPrice `json:"cu"`
+				SU                    policyPrice `json:"su"`
+				NU                    policyPrice `json:"nu"`
+				IPU                   policyPrice `json:"ipu"`
+				UniqueName            policyPrice `json:"uniqueName"`
+				DomainName            policyPrice `json:"domainName"`
+				DedicatedNodeDiscount float64     `json:"dedicatedNodeDiscount"`
+			} `json:"pricingPolicies"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return PricingPolicy{}, g.unavailable(id, 0, fmt.Sprintf("invalid response: %v", err))
+	}
+	if len(response.Errors) > 0 {
+		return PricingPolicy{}, g.unavailable(id, 0, response.Errors[0].Message)
+	}
+	if len(response.Data.PricingPolicies) == 0 {
+		return PricingPolicy{}, notFoundError("pricing policy %d not found on the chain", id)
+	}
+
+	item := response.Data.PricingPolicies[0]
+	policy := PricingPolicy{ID: item.PricingPolicyID, Name: item.Name, DedicatedDiscount: item.DedicatedNodeDiscount}
+	for _, price := range []struct {
+		name   string
+		value  policyPrice
+		target *uint64
+	}{
+		{"cu", item.CU, &policy.CU},
+		{"su", item.SU, &policy.SU},
+		{"nu", item.NU, &policy.NU},
+		{"ipu", item.IPU, &policy.IPU},
+		{"uniqueName", item.UniqueName, &policy.UniqueName},
+		{"domainName", item.DomainName, &policy.DomainName},
+	} {
+		if *price.target, err = price.value.uint64(); err != nil {
+			return PricingPolicy{}, g.unavailable(id, 0, fmt.Sprintf("invalid %s price %q", price.name, price.value.Value))
+		}
+	}
+
+	g.mu.Lock()
+	g.policies[id] = cachedPricingPolicy{policy: policy, expires: g.now().Add(pricingPolicyTTL)}
+	g.mu.Unlock()
+	return policy, nil
+}
+
+// unavailable reports a pricing policy the indexer could not be asked for
+func (g *GraphQLPricingPolicies) unavailable(id, status int, message string) error {
+	return &TaskError{
+		ErrorDetails: ErrorDetails{
+			Code:      CodeUpstreamUnavailable,
+			Retryable: true,
+			Upstream:  &UpstreamStatus{Endpoint: g.url, StatusCode: status, Message: message},
+		},
+		Message: fmt.Sprintf("failed to fetch pricing policy %d: %s", id, message),
+	}
+}
@@ -0,0 +1,198 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// maxErrorBodySize bounds how much of a failed GridProxy response is read
+const maxErrorBodySize = 4096
+
+// HTTPStatusError is a GridProxy response with a status other than 200 OK
+type HTTPStatusError struct {
+	StatusCode int
+	Message    string // Error GridProxy reported, if any
+}
+
+// Error implements the error interface
+func (e *HTTPStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("request failed with status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("request failed with status code %d: %s", e.StatusCode, e.Message)
+}
+
+// proxyClient is a client.Client for a single GridProxy endpoint. Requests
+// go through the given http.Client, and failed responses are returned as
+// *HTTPStatusError so failover can tell server errors from definite answers.
+type proxyClient struct {
+	endpoint string
+	http     *http.Client
+}
+
+// newProxyClient creates a client for endpoint; a nil httpClient means http.DefaultClient
+func newProxyClient(endpoint string, httpClient *http.Client) *proxyClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &proxyClient{endpoint: strings.TrimSuffix(endpoint, "/"), http: httpClient}
+}
+
+// proxyClients creates a client for each endpoint, all sending their requests with httpClient
+func proxyClients(endpoints []string, httpClient *http.Client) []client.Client {
+	clients := make([]client.Client, len(endpoints))
+	for i, endpoint := range endpoints {
+		clients[i] = newProxyClient(endpoint, httpClient)
+	}
+	return clients
+}
+
+// get fetches path with the query built from args and decodes the JSON
+// response into out. It returns the count header GridProxy sets for lists
+// requested with ret_count.
+func (c *proxyClient) get(ctx context.Context, path string, out interface{}, args ...interface{}) (int, error) {
+	target := c.endpoint + "/" + path
+	if query := queryValues(args...); len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		var failure struct {
+			Error string `json:"error"`
+		}
+		message := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &failure) == nil && failure.Error != "" {
+			message = failure.Error
+		}
+		return 0, &HTTPStatusError{StatusCode: resp.StatusCode, Message: message}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("failed to decode GridProxy response: %w", err)
+	}
+	count, _ := strconv.Atoi(resp.Header.Get("count"))
+	return count, nil
+}
+
+// queryValues encodes filters and limits by their schema tags, the way
+// GridProxy decodes them. Nil pointers and zero values are left out.
+func queryValues(args ...interface{}) url.Values {
+	query := url.Values{}
+	for _, arg := range args {
+		value := reflect.ValueOf(arg)
+		for i := 0; i < value.NumField(); i++ {
+			name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("schema"), ",")
+			if name == "" || name == "-" {
+				continue
+			}
+
+			field := value.Field(i)
+			switch {
+			case field.Kind() == reflect.Pointer:
+				if !field.IsNil() {
+					query.Set(name, fmt.Sprint(field.Elem().Interface()))
+				}
+			case field.Kind() == reflect.Slice:
+				for j := 0; j < field.Len(); j++ {
+					query.Add(name, fmt.Sprint(field.Index(j).Interface()))
+				}
+			case !field.IsZero():
+				query.Set(name, fmt.Sprint(field.Interface()))
+			}
+		}
+	}
+	return query
+}
+
+// PingContext checks that the endpoint answers
+func (c *proxyClient) PingContext(ctx context.Context) error {
+	var reply interface{}
+	_, err := c.get(ctx, "ping", &reply)
+	return err
+}
+
+// Ping implements client.Client
+func (c *proxyClient) Ping() error {
+	return c.PingContext(context.Background())
+}
+
+// Farms implements client.Client
+func (c *proxyClient) Farms(ctx context.Context, filter types.FarmFilter, limit types.Limit) (farms []types.Farm, count int, err error) {
+	count, err = c.get(ctx, "farms", &farms, filter, limit)
+	return farms, count, err
+}
+
+// Nodes implements client.Client
+func (c *proxyClient) Nodes(ctx context.Context, filter types.NodeFilter, limit types.Limit) (nodes []types.Node, count int, err error) {
+	count, err = c.get(ctx, "nodes", &nodes, filter, limit)
+	return nodes, count, err
+}
+
+// Contracts implements client.Client
+func (c *proxyClient) Contracts(ctx context.Context, filter types.ContractFilter, limit types.Limit) (contracts []types.Contract, count int, err error) {
+	count, err = c.get(ctx, "contracts", &contracts, filter, limit)
+	return contracts, count, err
+}
+
+// Contract implements client.Client
+func (c *proxyClient) Contract(ctx context.Context, contractID uint32) (contract types.Contract, err error) {
+	_, err = c.get(ctx, fmt.Sprintf("contracts/%d", contractID), &contract)
+	return contract, err
+}
+
+// ContractBills implements client.Client
+func (c *proxyClient) ContractBills(ctx context.Context, contractID uint32, limit types.Limit) (bills []types.ContractBilling, count uint, err error) {
+	n, err := c.get(ctx, fmt.Sprintf("contracts/%d/bills", contractID), &bills, limit)
+	return bills, uint(n), err
+}
+
+// Twins implements client.Client
+func (c *proxyClient) Twins(ctx context.Context, filter types.TwinFilter, limit types.Limit) (twins []types.Twin, count int, err error) {
+	count, err = c.get(ctx, "twins", &twins, filter, limit)
+	return twins, count, err
+}
+
+// Node implements client.Client
+func (c *proxyClient) Node(ctx context.Context, nodeID uint32) (node types.NodeWithNestedCapacity, err error) {
+	_, err = c.get(ctx, fmt.Sprintf("nodes/%d", nodeID), &node)
+	return node, err
+}
+
+// NodeStatus implements client.Client
+func (c *proxyClient) NodeStatus(ctx context.Context, nodeID uint32) (status types.NodeStatus, err error) {
+	_, err = c.get(ctx, fmt.Sprintf("nodes/%d/status", nodeID), &status)
+	return status, err
+}
+
+// Stats implements client.Client
+func (c *proxyClient) Stats(ctx context.Context, filter types.StatsFilter) (stats types.Stats, err error) {
+	_, err = c.get(ctx, "stats", &stats, filter)
+	return stats, err
+}
+
+// PublicIps implements client.Client
+func (c *proxyClient) PublicIps(ctx context.Context, filter types.PublicIpFilter, limit types.Limit) (ips []types.PublicIP, count uint, err error) {
+	n, err := c.get(ctx, "public_ips", &ips, filter, limit)
+	return ips, uint(n), err
+}
@@ -0,0 +1,147 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TaskHandler executes a single task on behalf of a TaskExecutor.
+// Handlers must pass ctx to every GridProxy call so the task can be
+// cancelled and bounded by its timeout.
+type TaskHandler interface {
+	Handle(ctx context.Context, te *TaskExecutor, params map[string]interface{}) (interface{}, error)
+}
+
+// TaskHandlerFunc adapts an ordinary function, or a TaskExecutor method
+// expression such as (*TaskExecutor).listFarms, to the TaskHandler interface
+type TaskHandlerFunc func(te *TaskExecutor, ctx context.Context, params map[string]interface{}) (interface{}, error)
+
+// Handle calls f(te, ctx, params)
+func (f TaskHandlerFunc) Handle(ctx context.Context, te *TaskExecutor, params map[string]interface{}) (interface{}, error) {
+	return f(te, ctx, params)
+}
+
+// TaskDefinition describes a supported task and the handler that executes it
+type TaskDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Category    string                 `json:"category"`
+	Version     string                 `json:"version"`
+	Params      *ParamSchema           `json:"parameters,omitempty"`
+	Example     map[string]interface{} `json:"example,omitempty"`
+	Timeout     time.Duration          `json:"-"` // Zero means the executor's default (DefaultTaskTimeout)
+	Handler     TaskHandler            `json:"-"`
+}
+
+// DefaultTaskTimeout bounds tasks whose definition does not set a Timeout
+const DefaultTaskTimeout = 30 * time.Second
+
+// MaxTaskTimeout is the longest timeout a task request may ask for
+const MaxTaskTimeout = 5 * time.Minute
+
+// timeout returns the definition's timeout, falling back to fallback
+func (def TaskDefinition) timeout(fallback time.Duration) time.Duration {
+	if def.Timeout > 0 {
+		return def.Timeout
+	}
+	return fallback
+}
+
+// shapesResults reports whether the task takes the result shaping
+// parameters of listParams
+func (def TaskDefinition) shapesResults() bool {
+	return def.Params != nil && def.Params.Properties["format"] != nil
+}
+
+// Registry holds task definitions keyed by name, preserving registration order
+type Registry struct {
+	mu    sync.RWMutex
+	tasks map[string]TaskDefinition
+	order []string
+}
+
+// NewRegistry creates an empty task registry
+func NewRegistry() *Registry {
+	return &Registry{
+		tasks: make(map[string]TaskDefinition),
+	}
+}
+
+// DefaultRegistry holds the built-in tasks and is used by every executor
+// that was not given a registry of its own
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	registerBuiltinTasks(DefaultRegistry)
+}
+
+// Register adds a task definition to the registry
+func (r *Registry) Register(def TaskDefinition) error {
+	if def.Name == "" {
+		return fmt.Errorf("task name is required")
+	}
+	if def.Handler == nil {
+		return fmt.Errorf("task %s has no handler", def.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tasks[def.Name]; exists {
+		return fmt.Errorf("task %s is already registered", def.Name)
+	}
+
+	r.tasks[def.Name] = def
+	r.order = append(r.order, def.Name)
+	return nil
+}
+
+// MustRegister is like Register but panics on error, for use during package initialization
+func (r *Registry) MustRegister(def TaskDefinition) {
+	if err := r.Register(def); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the definition registered under name
+func (r *Registry) Lookup(name string) (TaskDefinition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, ok := r.tasks[name]
+	return def, ok
+}
+
+// Definitions returns all registered task definitions in registration order
+func (r *Registry) Definitions() []TaskDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]TaskDefinition, 0, len(r.order))
+	for _, name := range r.order {
+		defs = append(defs, r.tasks[name])
+	}
+	return defs
+}
+
+// Names returns the names of all registered tasks in registration order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, len(r.order))
+	copy(names, r.order)
+	return names
+}
+
+// Catalog returns the registered task definitions, including parameter
+// schemas, as the JSON document the backend embeds
+func (r *Registry) Catalog() ([]byte, error) {
+	catalog := map[string]interface{}{
+		"tasks": r.Definitions(),
+	}
+	return json.MarshalIndent(catalog, "", "  ")
+}
@@ -0,0 +1,142 @@
+package executer
+
+import (
+	"context"
+	"testing"
+)
+
+func noopHandler(te *TaskExecutor, ctx context.Context, params map[string]interface{}) (interface{}, error) {
+	return params, nil
+}
+
+func TestRegistryRegister(t *testing.T) {
+	tests := []struct {
+		name         string
+		def          TaskDefinition
+		expectError  bool
+		errorMessage string
+	}{
+		{
+			name:        "valid definition",
+			def:         TaskDefinition{Name: "noop", Handler: TaskHandlerFunc(noopHandler)},
+			expectError: false,
+		},
+		{
+			name:         "missing name",
+			def:          TaskDefinition{Handler: TaskHandlerFunc(noopHandler)},
+			expectError:  true,
+			errorMessage: "task name is required",
+		},
+		{
+			name:         "missing handler",
+			def:          TaskDefinition{Name: "noop"},
+			expectError:  true,
+			errorMessage: "task noop has no handler",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewRegistry().Register(tt.def)
+
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("expected error but got none")
+				} else if err.Error() != tt.errorMessage {
+					t.Errorf("expected error message %q, got %q", tt.errorMessage, err.Error())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRegistryDuplicate(t *testing.T) {
+	registry := NewRegistry()
+	def := TaskDefinition{Name: "noop", Handler: TaskHandlerFunc(noopHandler)}
+
+	if err := registry.Register(def); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := registry.Register(def)
+	if err == nil {
+		t.Fatalf("expected error for duplicate registration")
+	}
+
+	if err.Error() != "task noop is already registered" {
+		t.Errorf("unexpected error message: %q", err.Error())
+	}
+}
+
+func TestRegistryOrderAndLookup(t *testing.T) {
+	registry := NewRegistry()
+	names := []string{"c_task", "a_task", "b_task"}
+	for _, name := range names {
+		registry.MustRegister(TaskDefinition{Name: name, Category: "test", Handler: TaskHandlerFunc(noopHandler)})
+	}
+
+	got := registry.Names()
+	if len(got) != len(names) {
+		t.Fatalf("expected %d names, got %d", len(names), len(got))
+	}
+	for i, name := range names {
+		if got[i] != name {
+			t.Errorf("expected %q at index %d, got %q", name, i, got[i])
+		}
+	}
+
+	def, ok := registry.Lookup("a_task")
+	if !ok {
+		t.Fatalf("expected a_task to be registered")
+	}
+	if def.Category != "test" {
+		t.Errorf("expected category test, got %q", def.Category)
+	}
+
+	if _, ok := registry.Lookup("missing"); ok {
+		t.Errorf("expected lookup of unregistered task to fail")
+	}
+}
+
+func TestExecutorUsesCustomRegistry(t *testing.T) {
+	registry := NewRegistry()
+	registry.MustRegister(TaskDefinition{Name: "noop", Handler: TaskHandlerFunc(noopHandler)})
+
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{},
+		network:    "test",
+		registry:   registry,
+	}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{TaskName: "noop", Params: map[string]interface{}{"key": "value"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	params, ok := result.(map[string]interface{})
+	if !ok || params["key"] != "value" {
+		t.Errorf("expected handler to receive params, got %v", result)
+	}
+
+	if _, err := executor.ExecuteTask(context.Background(), Task{TaskName: "list_farms"}); err == nil {
+		t.Errorf("expected list_farms to be unknown in custom registry")
+	}
+
+	tasks := executor.GetSupportedTasks()
+	if len(tasks) != 1 || tasks[0] != "noop" {
+		t.Errorf("expected [noop], got %v", tasks)
+	}
+}
+
+func TestDefaultRegistryDefinitions(t *testing.T) {
+	for _, def := range DefaultRegistry.Definitions() {
+		if def.Description == "" || def.Category == "" || def.Version == "" {
+			t.Errorf("task %s is missing description, category or version", def.Name)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package executer
+
+import "anubis-executer/schema"
+
+// Parameter schemas are defined in the schema package, which the Anubis API
+// shares; these aliases keep task definitions in this package short.
+type (
+	ParamType       = schema.ParamType
+	ParamSpec       = schema.ParamSpec
+	ParamSchema     = schema.ParamSchema
+	FieldError      = schema.FieldError
+	ValidationError = schema.ValidationError
+)
+
+// Supported parameter types
+const (
+	TypeString  = schema.TypeString
+	TypeInteger = schema.TypeInteger
+	TypeNumber  = schema.TypeNumber
+	TypeBoolean = schema.TypeBoolean
+	TypeArray   = schema.TypeArray
+)
+
+// Parameter spec constructors
+var (
+	Params       = schema.Params
+	StringParam  = schema.StringParam
+	IntegerParam = schema.IntegerParam
+	NumberParam  = schema.NumberParam
+	BooleanParam = schema.BooleanParam
+	ArrayParam   = schema.ArrayParam
+)
@@ -0,0 +1,31 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestExecuteTaskJSONFieldErrors(t *testing.T) {
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{},
+		network:    "test",
+	}
+
+	responseJSON, err := executor.ExecuteTaskJSON(context.Background(), []byte(`{"task_name": "list_farms", "params": {"farm_id": "abc"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var response TaskResponse
+	if err := json.Unmarshal(responseJSON, &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	if response.Success {
+		t.Fatalf("expected success=false for invalid farm_id")
+	}
+	if len(response.Errors) != 1 || response.Errors[0].Field != "farm_id" {
+		t.Errorf("expected a single farm_id field error, got %v", response.Errors)
+	}
+}
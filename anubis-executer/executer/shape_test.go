@@ -0,0 +1,101 @@
+package executer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func TestShapeListResults(t *testing.T) {
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{
+			nodes: []types.Node{
+				{NodeID: 11, FarmID: 1, Country: "Belgium", Status: "up", TotalResources: types.Capacity{CRU: 8}},
+				{NodeID: 12, FarmID: 1, Country: "Belgium", Status: "up", TotalResources: types.Capacity{CRU: 32}},
+				{NodeID: 21, FarmID: 2, Country: "Egypt, Cairo", Status: "up", TotalResources: types.Capacity{CRU: 16}},
+			},
+		},
+		network: "test",
+	}
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected string
+	}{
+		{
+			"fields and sorting",
+			map[string]interface{}{"fields": []interface{}{"nodeId", "total_resources.cru"}, "sort_by": "total_resources.cru", "order": "desc"},
+			`{"has_more":false,"items":[{"nodeId":12,"total_resources":{"cru":32}},{"nodeId":21,"total_resources":{"cru":16}},{"nodeId":11,"total_resources":{"cru":8}}],"network":"test","page":1,"page_size":5,"total_count":3}`,
+		},
+		{
+			"limit and field names ignoring case",
+			map[string]interface{}{"fields": []interface{}{"node_id"}, "sort_by": "node_id", "order": "desc", "limit": 1},
+			`{"has_more":true,"items":[{"nodeId":21}],"network":"test","page":1,"page_size":5,"total_count":3}`,
+		},
+		{
+			"compact",
+			map[string]interface{}{"fields": []interface{}{"nodeId", "country", "total_resources.cru"}, "format": "compact"},
+			`{"has_more":false,"network":"test","page":1,"page_size":5,"text":"nodeId,country,total_resources.cru\n11,Belgium,8\n12,Belgium,32\n21,\"Egypt, Cairo\",16","total_count":3}`,
+		},
+		{
+			"markdown",
+			map[string]interface{}{"fields": []interface{}{"nodeId", "status"}, "limit": 2, "format": "markdown"},
+			`{"has_more":true,"network":"test","page":1,"page_size":5,"text":"- **nodeId**: 11, **status**: up\n- **nodeId**: 12, **status**: up","total_count":3}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.ExecuteTask(context.Background(), Task{TaskName: "list_nodes", Params: tt.params})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := json.Marshal(result)
+			if err != nil {
+				t.Fatalf("failed to encode result: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestShapeListResultsUnchanged(t *testing.T) {
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{farms: []types.Farm{{FarmID: 1, Name: "Freefarm"}}},
+		network:    "test",
+	}
+
+	result, err := executor.ExecuteTask(context.Background(), Task{TaskName: "list_farms"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := result.(ListResult[types.Farm]); !ok {
+		t.Errorf("expected an unshaped ListResult, got %T", result)
+	}
+}
+
+func TestShapeUnknownField(t *testing.T) {
+	executor := &TaskExecutor{
+		gridClient: &MockGridClient{farms: []types.Farm{{FarmID: 1, Name: "Freefarm"}}},
+		network:    "test",
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"fields": []interface{}{"colour"}},
+		{"sort_by": "colour"},
+	} {
+		_, err := executor.ExecuteTask(context.Background(), Task{TaskName: "list_farms", Params: params})
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || ClassifyError(err).Code != CodeInvalidParams {
+			t.Errorf("%v: expected an invalid_params error, got %v", params, err)
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package executer
 
 import (
+	"context"
 	"encoding/json"
+	"time"
 
 	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
 )
@@ -10,18 +12,103 @@ import (
 type Task struct {
 	TaskName string                 `json:"task_name"`
 	Params   map[string]interface{} `json:"params"`
+	Caller   *Caller                `json:"caller,omitempty"`  // Authenticated user, set by the API
+	Timeout  string                 `json:"timeout,omitempty"` // Overrides the task's default timeout, e.g. "10s"
+	Cache    string                 `json:"cache,omitempty"`   // "bypass" skips the response cache
+}
+
+// timeout returns the timeout requested for the task, or else the definition's,
+// or else fallback
+func (t Task) timeout(def TaskDefinition, fallback time.Duration) (time.Duration, error) {
+	if t.Timeout == "" {
+		return def.timeout(fallback), nil
+	}
+
+	timeout, err := time.ParseDuration(t.Timeout)
+	if err != nil {
+		return 0, paramError("timeout", "invalid timeout: %v", err)
+	}
+	if timeout <= 0 || timeout > MaxTaskTimeout {
+		return 0, paramError("timeout", "timeout must be between 0s and %s, got: %s", MaxTaskTimeout, timeout)
+	}
+	return timeout, nil
+}
+
+// withCacheMode applies the task's cache mode to ctx
+func (t Task) withCacheMode(ctx context.Context) (context.Context, error) {
+	switch t.Cache {
+	case CacheDefault:
+		return ctx, nil
+	case CacheBypass:
+		return withCacheBypass(ctx), nil
+	default:
+		return ctx, paramError("cache", "cache must be one of [%s], got: %s", CacheBypass, t.Cache)
+	}
+}
+
+// Caller identifies the authenticated user a task runs on behalf of.
+// Handlers read it through implicit parameters so tasks such as get_twin
+// can answer "my twin" questions without the user pasting IDs.
+type Caller struct {
+	TwinID        *uint64 `json:"twin_id,omitempty"`
+	WalletAddress string  `json:"wallet_address,omitempty"`
+}
+
+// Implicit parameter names filled from the task's Caller
+const (
+	callerTwinIDParam        = "caller_twin_id"
+	callerWalletAddressParam = "caller_wallet_address"
+)
+
+// applyTo returns params with the caller's identity set as implicit parameters.
+// Values supplied in params under those names are dropped so they cannot be spoofed.
+func (c *Caller) applyTo(params map[string]interface{}) map[string]interface{} {
+	delete(params, callerTwinIDParam)
+	delete(params, callerWalletAddressParam)
+
+	if c == nil {
+		return params
+	}
+	if c.TwinID != nil {
+		params[callerTwinIDParam] = *c.TwinID
+	}
+	if c.WalletAddress != "" {
+		params[callerWalletAddressParam] = c.WalletAddress
+	}
+	return params
 }
 
 // TaskResponse represents the response from executing a task
 type TaskResponse struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Success      bool          `json:"success"`
+	Data         interface{}   `json:"data,omitempty"`
+	Error        string        `json:"error,omitempty"`
+	Errors       []FieldError  `json:"errors,omitempty"` // Field-level parameter errors
+	ErrorDetails               // Code, retryable flag, field and upstream status of a failure
+	Steps        []StepResult  `json:"steps,omitempty"`    // Per-step results when a plan was executed
+	Metadata     *TaskMetadata `json:"metadata,omitempty"` // How the response was produced
+}
+
+// TaskMetadata describes how a task response was produced
+type TaskMetadata struct {
+	Cache *CacheMetadata `json:"cache,omitempty"`
+}
+
+// newTaskMetadata builds response metadata from the recorded cache usage, or nil when there is none
+func newTaskMetadata(recorder *cacheRecorder, bypass bool) *TaskMetadata {
+	cache := recorder.metadata(bypass)
+	if cache == nil {
+		return nil
+	}
+	return &TaskMetadata{Cache: cache}
 }
 
 // Farm represents a ThreeFold farm (using the real GridProxy types.Farm)
 type Farm = types.Farm
 
+// Node represents a ThreeFold node (using the real GridProxy types.Node)
+type Node = types.Node
+
 // ParseTask parses JSON bytes into a Task struct
 func ParseTask(data []byte) (*Task, error) {
 	var task Task
@@ -2,7 +2,7 @@ module anubis-executer
 
 go 1.24.5
 
-require github.com/threefoldtech/tfgrid-sdk-go/grid-proxy v0.0.0-20240101000000-000000000000
+require github.com/threefoldtech/tfgrid-sdk-go/grid-proxy v0.16.1
 
 require (
 	github.com/ChainSafe/go-schnorrkel v1.1.0 // indirect
@@ -23,5 +23,3 @@ require (
 	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 )
-
-replace github.com/threefoldtech/tfgrid-sdk-go/grid-proxy => ../anubis-dataset/tfgrid-sdk-go/grid-proxy
@@ -0,0 +1,174 @@
+package gridproxytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+const gigabyte = 1024 * 1024 * 1024
+
+// Dataset is the grid served by a Handler. Public IPs are served from the
+// farms' PublicIps; stats are computed from the dataset on each request.
+type Dataset struct {
+	Farms     []types.Farm                       `json:"farms"`
+	Nodes     []types.Node                       `json:"nodes"`
+	Twins     []types.Twin                       `json:"twins"`
+	Contracts []types.Contract                   `json:"contracts"`
+	Bills     map[uint32][]types.ContractBilling `json:"bills"`             // Keyed by contract ID
+	Regions   map[string]string                  `json:"regions,omitempty"` // Region of each country, for the region filter
+}
+
+// LoadDataset reads a Dataset from a JSON file
+func LoadDataset(path string) (Dataset, error) {
+	var dataset Dataset
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return dataset, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return dataset, fmt.Errorf("failed to parse dataset %s: %w", path, err)
+	}
+	return dataset, nil
+}
+
+// clone returns a deep copy of the dataset, so a caller's slices are never
+// shared with the handler
+func (d Dataset) clone() Dataset {
+	data, err := json.Marshal(d)
+	if err != nil {
+		panic(fmt.Sprintf("gridproxytest: dataset is not serializable: %v", err))
+	}
+	var copied Dataset
+	if err := json.Unmarshal(data, &copied); err != nil {
+		panic(fmt.Sprintf("gridproxytest: dataset is not serializable: %v", err))
+	}
+	return copied
+}
+
+// DefaultDataset returns a small grid covering the cases the tasks care
+// about: three farms in three countries, nodes that are up, on standby and
+// down, a dedicated rented node, a GPU node, gateways, public IPs in use and
+// free, and node, name and rent contracts with bills.
+func DefaultDataset() Dataset {
+	node := func(nodeID, farmID, twinID int, country, city, status string, cru, mruGB, sruGB, hruGB uint64) types.Node {
+		return types.Node{
+			ID:                fmt.Sprintf("node-%d", nodeID),
+			NodeID:            nodeID,
+			FarmID:            farmID,
+			TwinID:            twinID,
+			Country:           country,
+			City:              city,
+			GridVersion:       3,
+			Uptime:            86400,
+			Created:           1672531200,
+			UpdatedAt:         1704067200,
+			TotalResources:    types.Capacity{CRU: cru, MRU: types.Unit(mruGB * gigabyte), SRU: types.Unit(sruGB * gigabyte), HRU: types.Unit(hruGB * gigabyte)},
+			Location:          types.Location{Country: country, City: city},
+			Status:            status,
+			CertificationType: "Diy",
+			Power:             types.NodePower{State: "Up", Target: "Up"},
+			Healthy:           status == "up",
+			Rentable:          status == "up",
+		}
+	}
+
+	nodes := []types.Node{
+		node(11, 1, 101, "Belgium", "Ghent", "up", 8, 32, 512, 2000),
+		node(12, 1, 102, "Belgium", "Ghent", "up", 16, 64, 1024, 4000),
+		node(13, 1, 103, "Belgium", "Brussels", "standby", 4, 16, 256, 0),
+		node(21, 2, 104, "Egypt", "Cairo", "up", 32, 128, 2048, 8000),
+		node(22, 2, 105, "Egypt", "Cairo", "down", 8, 32, 512, 2000),
+		node(31, 3, 106, "Germany", "Berlin", "up", 64, 256, 4096, 16000),
+	}
+
+	// Node 11 already runs workloads and is a public gateway
+	nodes[0].UsedResources = types.Capacity{CRU: 2, MRU: 8 * gigabyte, SRU: 100 * gigabyte}
+	nodes[0].PublicConfig = types.PublicConfig{Domain: "gw11.grid.test", Ipv4: "185.69.166.11/24", Gw4: "185.69.166.1"}
+	// Node 21 has a GPU
+	nodes[3].NumGPU = 1
+	nodes[3].UsedResources = types.Capacity{CRU: 4, MRU: 16 * gigabyte, SRU: 200 * gigabyte, HRU: 1000 * gigabyte}
+	// Node 31 is in a dedicated farm and rented by twin 7
+	nodes[5].Dedicated = true
+	nodes[5].InDedicatedFarm = true
+	nodes[5].Rentable = false
+	nodes[5].Rented = true
+	nodes[5].RentedByTwinID = 7
+	nodes[5].RentContractID = 1003
+	nodes[5].CertificationType = "Certified"
+	nodes[5].ExtraFee = 20000 // 20 USD a month, in mUSD
+	nodes[5].PublicConfig = types.PublicConfig{Ipv4: "91.107.1.31/24", Gw4: "91.107.1.1"}
+
+	return Dataset{
+		Farms: []types.Farm{
+			{
+				Name: "Freefarm", FarmID: 1, TwinID: 1, PricingPolicyID: 1, CertificationType: "NotCertified",
+				StellarAddress: "GAFREEFARMSTELLARADDRESS",
+				PublicIps: []types.PublicIP{
+					{ID: "ip-1", IP: "185.69.166.150/24", FarmID: "1", ContractID: 1001, Gateway: "185.69.166.1"},
+					{ID: "ip-2", IP: "185.69.166.151/24", FarmID: "1", Gateway: "185.69.166.1"},
+				},
+			},
+			{
+				Name: "NileFarm", FarmID: 2, TwinID: 2, PricingPolicyID: 1, CertificationType: "NotCertified",
+				StellarAddress: "GANILEFARMSTELLARADDRESS",
+				PublicIps: []types.PublicIP{
+					{ID: "ip-3", IP: "41.33.10.20/24", FarmID: "2", Gateway: "41.33.10.1"},
+				},
+			},
+			{
+				Name: "BerlinDedicated", FarmID: 3, TwinID: 3, PricingPolicyID: 1, CertificationType: "Gold",
+				StellarAddress: "GABERLINSTELLARADDRESS", Dedicated: true,
+				PublicIps: []types.PublicIP{},
+			},
+		},
+		Nodes: nodes,
+		Twins: []types.Twin{
+			{TwinID: 1, AccountID: "5FreefarmAccount", Relay: "relay.grid.test", PublicKey: "0xfarm1"},
+			{TwinID: 2, AccountID: "5NileFarmAccount", Relay: "relay.grid.test", PublicKey: "0xfarm2"},
+			{TwinID: 3, AccountID: "5BerlinAccount", Relay: "relay.grid.test", PublicKey: "0xfarm3"},
+			{TwinID: 7, AccountID: "5AliceAccount", Relay: "relay.grid.test", PublicKey: "0xalice"},
+			{TwinID: 8, AccountID: "5BobAccount", Relay: "relay.02.grid.test", PublicKey: "0xbob"},
+		},
+		Contracts: []types.Contract{
+			{
+				ContractID: 1001, TwinID: 7, State: "Created", CreatedAt: 1700000000, Type: "node",
+				Details: map[string]interface{}{"nodeId": 11, "deployment_data": `{"type":"vm","name":"web"}`, "deployment_hash": "hash1001", "number_of_public_ips": 1},
+			},
+			{
+				ContractID: 1002, TwinID: 7, State: "Created", CreatedAt: 1700000100, Type: "name",
+				Details: map[string]interface{}{"name": "alice"},
+			},
+			{
+				ContractID: 1003, TwinID: 7, State: "Created", CreatedAt: 1700000200, Type: "rent",
+				Details: map[string]interface{}{"nodeId": 31},
+			},
+			{
+				ContractID: 1004, TwinID: 8, State: "Deleted", CreatedAt: 1690000000, Type: "node",
+				Details: map[string]interface{}{"nodeId": 21, "deployment_data": `{"type":"vm","name":"old"}`, "deployment_hash": "hash1004", "number_of_public_ips": 0},
+			},
+			{
+				ContractID: 1005, TwinID: 8, State: "GracePeriod", CreatedAt: 1700000300, Type: "node",
+				Details: map[string]interface{}{"nodeId": 21, "deployment_data": `{"type":"vm","name":"gpu"}`, "deployment_hash": "hash1005", "number_of_public_ips": 0},
+			},
+		},
+		Bills: map[uint32][]types.ContractBilling{
+			1001: {
+				{AmountBilled: 120000, DiscountReceived: "Gold", Timestamp: 1700003600},
+				{AmountBilled: 120000, DiscountReceived: "Gold", Timestamp: 1700007200},
+				{AmountBilled: 95000, DiscountReceived: "Gold", Timestamp: 1700010800},
+			},
+			1003: {
+				{AmountBilled: 4000000, DiscountReceived: "Default", Timestamp: 1700003600},
+			},
+		},
+		Regions: map[string]string{
+			"Belgium": "Europe",
+			"Germany": "Europe",
+			"Egypt":   "Africa",
+		},
+	}
+}
@@ -0,0 +1,279 @@
+package gridproxytest
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// filter builds a predicate from the values of one query parameter
+type filter[T any] func(values []string) (func(T) bool, error)
+
+// filters are the query parameters a list endpoint implements
+type filters[T any] map[string]filter[T]
+
+// sortKeys are the sort_by values of a list endpoint that are not top-level
+// JSON fields, such as a node's free memory
+type sortKeys[T any] map[string]func(T) uint64
+
+// listQuery is the pagination and sorting of a list request (types.Limit)
+type listQuery struct {
+	size       int
+	page       int
+	retCount   bool
+	sortBy     string
+	descending bool
+}
+
+// parseListQuery reads the types.Limit parameters and removes them from query.
+// randomize is accepted and ignored so results stay deterministic.
+func parseListQuery(query url.Values) (listQuery, error) {
+	list := listQuery{size: defaultPageSize, page: 1}
+
+	for _, param := range []struct {
+		name   string
+		target *int
+	}{{"size", &list.size}, {"page", &list.page}} {
+		if value := query.Get(param.name); value != "" {
+			number, err := strconv.Atoi(value)
+			if err != nil || number < 1 {
+				return list, fmt.Errorf("%s must be a positive integer, got: %s", param.name, value)
+			}
+			*param.target = number
+		}
+	}
+
+	if value := query.Get("ret_count"); value != "" {
+		retCount, err := strconv.ParseBool(value)
+		if err != nil {
+			return list, fmt.Errorf("ret_count must be a boolean, got: %s", value)
+		}
+		list.retCount = retCount
+	}
+
+	list.sortBy = query.Get("sort_by")
+	switch order := strings.ToLower(query.Get("sort_order")); order {
+	case "", "asc":
+	case "desc":
+		list.descending = true
+	default:
+		return list, fmt.Errorf("sort_order must be asc or desc, got: %s", order)
+	}
+
+	for _, name := range []string{"size", "page", "ret_count", "randomize", "sort_by", "sort_order"} {
+		query.Del(name)
+	}
+	return list, nil
+}
+
+// serveList writes the items matching the request's filters, sorted and
+// paginated, with the total match count in the count header when ret_count is set
+func serveList[T any](w http.ResponseWriter, r *http.Request, items []T, available filters[T], keys sortKeys[T]) {
+	query := r.URL.Query()
+	list, err := parseListQuery(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	matched, err := applyFilters(query, items, available)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if list.sortBy != "" {
+		if err := sortItems(matched, list.sortBy, list.descending, keys); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if list.retCount {
+		w.Header().Set("count", strconv.Itoa(len(matched)))
+	}
+
+	start := min((list.page-1)*list.size, len(matched))
+	end := min(start+list.size, len(matched))
+	writeJSON(w, matched[start:end])
+}
+
+// filterItems returns the items matching the request's filters, writing a
+// 400 response and returning false when the query is invalid
+func filterItems[T any](w http.ResponseWriter, r *http.Request, items []T, available filters[T]) ([]T, bool) {
+	matched, err := applyFilters(r.URL.Query(), items, available)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return matched, true
+}
+
+// applyFilters returns a new slice with the items matching every filter in query
+func applyFilters[T any](query url.Values, items []T, available filters[T]) ([]T, error) {
+	var predicates []func(T) bool
+	for name, values := range query {
+		build, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("gridproxytest does not support the %s query parameter", name)
+		}
+		predicate, err := build(values)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", name, err)
+		}
+		predicates = append(predicates, predicate)
+	}
+
+	matched := make([]T, 0, len(items))
+	for _, item := range items {
+		if !slices.ContainsFunc(predicates, func(predicate func(T) bool) bool { return !predicate(item) }) {
+			matched = append(matched, item)
+		}
+	}
+	return matched, nil
+}
+
+// sortItems stably sorts items by the key in keys named sortBy, or else by
+// the top-level JSON field named by sortBy. Field names are matched ignoring
+// case and underscores, so node_id sorts by nodeId; nested fields such as
+// total_resources.cru cannot be sorted on.
+func sortItems[T any](items []T, sortBy string, descending bool, keys sortKeys[T]) error {
+	normalize := func(name string) string {
+		return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	}
+
+	values := make(map[int]interface{}, len(items))
+	for i, item := range items {
+		if key, ok := keys[sortBy]; ok {
+			values[i] = float64(key(item)) // Compared like JSON numbers
+			continue
+		}
+
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+
+		found := false
+		for name, value := range fields {
+			if normalize(name) == normalize(sortBy) {
+				values[i], found = value, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("gridproxytest cannot sort by %s", sortBy)
+		}
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		result := compareValues(values[a], values[b])
+		if descending {
+			return -result
+		}
+		return result
+	})
+
+	sorted := make([]T, len(items))
+	for i, index := range order {
+		sorted[i] = items[index]
+	}
+	copy(items, sorted)
+	return nil
+}
+
+// compareValues orders decoded JSON numbers, strings and booleans
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			return cmp.Compare(a, b)
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return cmp.Compare(a, b)
+		}
+	case bool:
+		if b, ok := b.(bool); ok && a != b {
+			if a {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// splitValues returns the values of a list parameter, given either repeated
+// (status=up&status=standby) or comma separated (status=up,standby)
+func splitValues(values []string) []string {
+	var split []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				split = append(split, part)
+			}
+		}
+	}
+	return split
+}
+
+// equalUint matches items whose field equals the parameter
+func equalUint[T any](field func(T) uint64) filter[T] {
+	return func(values []string) (func(T) bool, error) {
+		expected, err := strconv.ParseUint(values[0], 10, 64)
+		return func(item T) bool { return field(item) == expected }, err
+	}
+}
+
+// atLeast matches items whose field is at least the parameter
+func atLeast[T any](field func(T) uint64) filter[T] {
+	return func(values []string) (func(T) bool, error) {
+		minimum, err := strconv.ParseUint(values[0], 10, 64)
+		return func(item T) bool { return field(item) >= minimum }, err
+	}
+}
+
+// equalBool matches items whose field equals the parameter
+func equalBool[T any](field func(T) bool) filter[T] {
+	return func(values []string) (func(T) bool, error) {
+		expected, err := strconv.ParseBool(values[0])
+		return func(item T) bool { return field(item) == expected }, err
+	}
+}
+
+// equalString matches items whose field equals the parameter, ignoring case
+func equalString[T any](field func(T) string) filter[T] {
+	return func(values []string) (func(T) bool, error) {
+		return func(item T) bool { return strings.EqualFold(field(item), values[0]) }, nil
+	}
+}
+
+// containsString matches items whose field contains the parameter, ignoring case
+func containsString[T any](field func(T) string) filter[T] {
+	return func(values []string) (func(T) bool, error) {
+		part := strings.ToLower(values[0])
+		return func(item T) bool { return strings.Contains(strings.ToLower(field(item)), part) }, nil
+	}
+}
+
+// oneOf matches items whose field is one of the parameter's values
+func oneOf[T any](field func(T) string) filter[T] {
+	return func(values []string) (func(T) bool, error) {
+		accepted := splitValues(values)
+		return func(item T) bool { return slices.Contains(accepted, field(item)) }, nil
+	}
+}
@@ -0,0 +1,492 @@
+// Package gridproxytest serves the GridProxy REST API over an in-memory
+// dataset, so the executor, and services calling it, can be tested end to end
+// without network access.
+//
+// A Server listens on a loopback port; point an executor at it with
+// executer.WithEndpoints:
+//
+//	srv := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+//	defer srv.Close()
+//	executor, err := executer.NewTaskExecutor("test", executer.WithEndpoints(srv.URL))
+//
+// The endpoints used by client.Client are served: /ping, /farms, /nodes,
+// /nodes/{id}, /nodes/{id}/status, /contracts, /contracts/{id},
+// /contracts/{id}/bills, /twins, /stats and /public_ips. Lists are filtered
+// by the query parameters of the matching types.*Filter, paginated with
+// size and page, and sorted with sort_by and sort_order by top-level fields,
+// or for nodes by free_mru, free_sru and free_hru; randomize is ignored so
+// results stay deterministic. A query parameter the fake does not implement
+// is answered with 400, so a test cannot pass because a filter was silently
+// ignored.
+package gridproxytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+// defaultPageSize is GridProxy's page size when none is requested
+const defaultPageSize = 50
+
+// Handler serves the GridProxy REST API over a Dataset
+type Handler struct {
+	mux *http.ServeMux
+
+	mu         sync.RWMutex
+	dataset    Dataset
+	failures   int // Requests left to fail with failStatus
+	failStatus int
+	requests   map[string]int // Requests served per path
+}
+
+// NewHandler creates a Handler serving a copy of dataset
+func NewHandler(dataset Dataset) *Handler {
+	h := &Handler{
+		mux:      http.NewServeMux(),
+		dataset:  dataset.clone(),
+		requests: make(map[string]int),
+	}
+
+	h.mux.HandleFunc("GET /ping", h.ping)
+	h.mux.HandleFunc("GET /farms", h.farms)
+	h.mux.HandleFunc("GET /nodes", h.nodes)
+	h.mux.HandleFunc("GET /nodes/{id}", h.node)
+	h.mux.HandleFunc("GET /nodes/{id}/status", h.nodeStatus)
+	h.mux.HandleFunc("GET /contracts", h.contracts)
+	h.mux.HandleFunc("GET /contracts/{id}", h.contract)
+	h.mux.HandleFunc("GET /contracts/{id}/bills", h.contractBills)
+	h.mux.HandleFunc("GET /twins", h.twins)
+	h.mux.HandleFunc("GET /stats", h.stats)
+	h.mux.HandleFunc("GET /public_ips", h.publicIPs)
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests[r.URL.Path]++
+	failing := h.failures > 0
+	if failing {
+		h.failures--
+	}
+	status := h.failStatus
+	h.mu.Unlock()
+
+	if failing {
+		writeError(w, status, fmt.Sprintf("injected failure (%d)", status))
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// Update changes the served dataset, e.g. to take a node down between two
+// task runs
+func (h *Handler) Update(update func(dataset *Dataset)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	update(&h.dataset)
+}
+
+// FailNext answers the next n requests with status, e.g. 502 to exercise
+// retries and endpoint failover
+func (h *Handler) FailNext(n int, status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures = n
+	h.failStatus = status
+}
+
+// Requests returns how many requests were made to path, e.g. "/nodes", or
+// to any path when path is empty
+func (h *Handler) Requests(path string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if path != "" {
+		return h.requests[path]
+	}
+	total := 0
+	for _, count := range h.requests {
+		total += count
+	}
+	return total
+}
+
+// Server is a Handler listening on a loopback port
+type Server struct {
+	*Handler
+	URL string // Base URL, usable as a GridProxy endpoint
+
+	server *httptest.Server
+}
+
+// NewServer starts a Server serving a copy of dataset. Close it when done.
+func NewServer(dataset Dataset) *Server {
+	handler := NewHandler(dataset)
+	server := httptest.NewServer(handler)
+	return &Server{Handler: handler, URL: server.URL, server: server}
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes an error the way GridProxy does: {"error": message}
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+func (h *Handler) ping(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]string{"ping": "pong"})
+}
+
+func (h *Handler) farms(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	nodesOf := func(farm types.Farm) []types.Node {
+		var nodes []types.Node
+		for _, node := range h.dataset.Nodes {
+			if node.FarmID == farm.FarmID {
+				nodes = append(nodes, node)
+			}
+		}
+		return nodes
+	}
+	anyNode := func(match func(types.Node) bool) func(types.Farm) bool {
+		return func(farm types.Farm) bool {
+			return slices.ContainsFunc(nodesOf(farm), match)
+		}
+	}
+
+	serveList(w, r, h.dataset.Farms, filters[types.Farm]{
+		"farm_id":            equalUint(func(f types.Farm) uint64 { return uint64(f.FarmID) }),
+		"twin_id":            equalUint(func(f types.Farm) uint64 { return uint64(f.TwinID) }),
+		"pricing_policy_id":  equalUint(func(f types.Farm) uint64 { return uint64(f.PricingPolicyID) }),
+		"name":               equalString(func(f types.Farm) string { return f.Name }),
+		"name_contains":      containsString(func(f types.Farm) string { return f.Name }),
+		"certification_type": equalString(func(f types.Farm) string { return f.CertificationType }),
+		"stellar_address":    equalString(func(f types.Farm) string { return f.StellarAddress }),
+		"dedicated":          equalBool(func(f types.Farm) bool { return f.Dedicated }),
+		"free_ips":           atLeast(func(f types.Farm) uint64 { return uint64(len(freeIPs(f.PublicIps))) }),
+		"total_ips":          atLeast(func(f types.Farm) uint64 { return uint64(len(f.PublicIps)) }),
+		"country": func(values []string) (func(types.Farm) bool, error) {
+			return anyNode(func(n types.Node) bool { return strings.EqualFold(n.Country, values[0]) }), nil
+		},
+		"node_status": func(values []string) (func(types.Farm) bool, error) {
+			statuses := splitValues(values)
+			return anyNode(func(n types.Node) bool { return slices.Contains(statuses, n.Status) }), nil
+		},
+		"node_has_gpu": func(values []string) (func(types.Farm) bool, error) {
+			hasGPU, err := strconv.ParseBool(values[0])
+			return anyNode(func(n types.Node) bool { return (n.NumGPU > 0) == hasGPU }), err
+		},
+	}, nil)
+}
+
+func (h *Handler) nodes(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	farmNames := make(map[int]string, len(h.dataset.Farms))
+	farmFreeIPs := make(map[int]uint64, len(h.dataset.Farms))
+	for _, farm := range h.dataset.Farms {
+		farmNames[farm.FarmID] = farm.Name
+		farmFreeIPs[farm.FarmID] = uint64(len(freeIPs(farm.PublicIps)))
+	}
+	free := func(total, used types.Unit) uint64 {
+		if used > total {
+			return 0
+		}
+		return uint64(total - used)
+	}
+
+	serveList(w, r, h.dataset.Nodes, filters[types.Node]{
+		"status":             oneOf(func(n types.Node) string { return n.Status }),
+		"node_id":            equalUint(func(n types.Node) uint64 { return uint64(n.NodeID) }),
+		"twin_id":            equalUint(func(n types.Node) uint64 { return uint64(n.TwinID) }),
+		"farm_ids":           oneOf(func(n types.Node) string { return strconv.Itoa(n.FarmID) }),
+		"farm_name":          equalString(func(n types.Node) string { return farmNames[n.FarmID] }),
+		"farm_name_contains": containsString(func(n types.Node) string { return farmNames[n.FarmID] }),
+		"country":            equalString(func(n types.Node) string { return n.Country }),
+		"country_contains":   containsString(func(n types.Node) string { return n.Country }),
+		"city":               equalString(func(n types.Node) string { return n.City }),
+		"city_contains":      containsString(func(n types.Node) string { return n.City }),
+		"region":             equalString(func(n types.Node) string { return h.dataset.Regions[n.Country] }),
+		"free_ips":           atLeast(func(n types.Node) uint64 { return farmFreeIPs[n.FarmID] }),
+		"certification_type": equalString(func(n types.Node) string { return n.CertificationType }),
+		"free_mru":           atLeast(func(n types.Node) uint64 { return free(n.TotalResources.MRU, n.UsedResources.MRU) }),
+		"free_sru":           atLeast(func(n types.Node) uint64 { return free(n.TotalResources.SRU, n.UsedResources.SRU) }),
+		"free_hru":           atLeast(func(n types.Node) uint64 { return free(n.TotalResources.HRU, n.UsedResources.HRU) }),
+		"total_cru":          atLeast(func(n types.Node) uint64 { return n.TotalResources.CRU }),
+		"total_mru":          atLeast(func(n types.Node) uint64 { return uint64(n.TotalResources.MRU) }),
+		"total_sru":          atLeast(func(n types.Node) uint64 { return uint64(n.TotalResources.SRU) }),
+		"total_hru":          atLeast(func(n types.Node) uint64 { return uint64(n.TotalResources.HRU) }),
+		"ipv4":               equalBool(func(n types.Node) bool { return n.PublicConfig.Ipv4 != "" }),
+		"ipv6":               equalBool(func(n types.Node) bool { return n.PublicConfig.Ipv6 != "" }),
+		"domain":             equalBool(func(n types.Node) bool { return n.PublicConfig.Domain != "" }),
+		"dedicated":          equalBool(func(n types.Node) bool { return n.Dedicated }),
+		"in_dedicated_farm":  equalBool(func(n types.Node) bool { return n.InDedicatedFarm }),
+		"rentable":           equalBool(func(n types.Node) bool { return n.Rentable }),
+		"rented":             equalBool(func(n types.Node) bool { return n.Rented }),
+		"rented_by":          equalUint(func(n types.Node) uint64 { return uint64(n.RentedByTwinID) }),
+		"has_gpu":            equalBool(func(n types.Node) bool { return n.NumGPU > 0 }),
+		"healthy":            equalBool(func(n types.Node) bool { return n.Healthy }),
+		"available_for": func(values []string) (func(types.Node) bool, error) {
+			twinID, err := strconv.ParseUint(values[0], 10, 64)
+			return func(n types.Node) bool {
+				return !n.Rented || uint64(n.RentedByTwinID) == twinID
+			}, err
+		},
+	}, sortKeys[types.Node]{
+		"free_mru": func(n types.Node) uint64 { return free(n.TotalResources.MRU, n.UsedResources.MRU) },
+		"free_sru": func(n types.Node) uint64 { return free(n.TotalResources.SRU, n.UsedResources.SRU) },
+		"free_hru": func(n types.Node) uint64 { return free(n.TotalResources.HRU, n.UsedResources.HRU) },
+	})
+}
+
+// findNode returns the node with the ID in the request path
+func (h *Handler) findNode(w http.ResponseWriter, r *http.Request) (types.Node, bool) {
+	nodeID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid node id: %s", r.PathValue("id")))
+		return types.Node{}, false
+	}
+	for _, node := range h.dataset.Nodes {
+		if node.NodeID == nodeID {
+			return node, true
+		}
+	}
+	writeError(w, http.StatusNotFound, "node not found")
+	return types.Node{}, false
+}
+
+func (h *Handler) node(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	node, ok := h.findNode(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, types.NodeWithNestedCapacity{
+		ID:                node.ID,
+		NodeID:            node.NodeID,
+		FarmID:            node.FarmID,
+		TwinID:            node.TwinID,
+		Country:           node.Country,
+		GridVersion:       node.GridVersion,
+		City:              node.City,
+		Uptime:            node.Uptime,
+		Created:           node.Created,
+		FarmingPolicyID:   node.FarmingPolicyID,
+		UpdatedAt:         node.UpdatedAt,
+		Capacity:          types.CapacityResult{Total: node.TotalResources, Used: node.UsedResources},
+		Location:          node.Location,
+		PublicConfig:      node.PublicConfig,
+		Status:            node.Status,
+		CertificationType: node.CertificationType,
+		Dedicated:         node.Dedicated,
+		InDedicatedFarm:   node.InDedicatedFarm,
+		RentContractID:    node.RentContractID,
+		RentedByTwinID:    node.RentedByTwinID,
+		SerialNumber:      node.SerialNumber,
+		Power:             node.Power,
+		NumGPU:            node.NumGPU,
+		ExtraFee:          node.ExtraFee,
+		Healthy:           node.Healthy,
+		Rentable:          node.Rentable,
+		Rented:            node.Rented,
+		PriceUsd:          node.PriceUsd,
+	})
+}
+
+func (h *Handler) nodeStatus(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	node, ok := h.findNode(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, types.NodeStatus{Status: node.Status})
+}
+
+func (h *Handler) contracts(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	serveList(w, r, h.dataset.Contracts, filters[types.Contract]{
+		"contract_id":          equalUint(func(c types.Contract) uint64 { return uint64(c.ContractID) }),
+		"twin_id":              equalUint(func(c types.Contract) uint64 { return uint64(c.TwinID) }),
+		"node_id":              equalUint(func(c types.Contract) uint64 { return detailUint(c, "nodeId") }),
+		"type":                 equalString(func(c types.Contract) string { return c.Type }),
+		"state":                oneOf(func(c types.Contract) string { return c.State }),
+		"name":                 equalString(func(c types.Contract) string { return detailString(c, "name") }),
+		"number_of_public_ips": atLeast(func(c types.Contract) uint64 { return detailUint(c, "number_of_public_ips") }),
+		"deployment_data":      equalString(func(c types.Contract) string { return detailString(c, "deployment_data") }),
+		"deployment_hash":      equalString(func(c types.Contract) string { return detailString(c, "deployment_hash") }),
+	}, nil)
+}
+
+// findContract returns the contract with the ID in the request path
+func (h *Handler) findContract(w http.ResponseWriter, r *http.Request) (types.Contract, bool) {
+	contractID, err := strconv.ParseUint(r.PathValue("id"), 10, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid contract id: %s", r.PathValue("id")))
+		return types.Contract{}, false
+	}
+	for _, contract := range h.dataset.Contracts {
+		if uint64(contract.ContractID) == contractID {
+			return contract, true
+		}
+	}
+	writeError(w, http.StatusNotFound, "contract not found")
+	return types.Contract{}, false
+}
+
+func (h *Handler) contract(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if contract, ok := h.findContract(w, r); ok {
+		writeJSON(w, contract)
+	}
+}
+
+func (h *Handler) contractBills(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	contract, ok := h.findContract(w, r)
+	if !ok {
+		return
+	}
+	serveList(w, r, h.dataset.Bills[uint32(contract.ContractID)], filters[types.ContractBilling]{}, nil)
+}
+
+func (h *Handler) twins(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	serveList(w, r, h.dataset.Twins, filters[types.Twin]{
+		"twin_id":    equalUint(func(t types.Twin) uint64 { return uint64(t.TwinID) }),
+		"account_id": equalString(func(t types.Twin) string { return t.AccountID }),
+		"relay":      equalString(func(t types.Twin) string { return t.Relay }),
+		"public_key": equalString(func(t types.Twin) string { return t.PublicKey }),
+	}, nil)
+}
+
+func (h *Handler) stats(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	nodes, ok := filterItems(w, r, h.dataset.Nodes, filters[types.Node]{
+		"status": oneOf(func(n types.Node) string { return n.Status }),
+	})
+	if !ok {
+		return
+	}
+
+	stats := types.Stats{
+		Nodes:             int64(len(nodes)),
+		Farms:             int64(len(h.dataset.Farms)),
+		Twins:             int64(len(h.dataset.Twins)),
+		Contracts:         int64(len(h.dataset.Contracts)),
+		NodesDistribution: make(map[string]int64),
+	}
+	for _, farm := range h.dataset.Farms {
+		stats.PublicIPs += int64(len(farm.PublicIps))
+	}
+	for _, node := range nodes {
+		stats.TotalCRU += int64(node.TotalResources.CRU)
+		stats.TotalMRU += int64(node.TotalResources.MRU)
+		stats.TotalSRU += int64(node.TotalResources.SRU)
+		stats.TotalHRU += int64(node.TotalResources.HRU)
+		stats.GPUs += int64(node.NumGPU)
+		stats.NodesDistribution[node.Country]++
+		if node.PublicConfig.Ipv4 != "" || node.PublicConfig.Ipv6 != "" {
+			stats.AccessNodes++
+		}
+		if node.PublicConfig.Domain != "" {
+			stats.Gateways++
+		}
+		if node.Dedicated {
+			stats.DedicatedNodes++
+		}
+	}
+	stats.Countries = int64(len(stats.NodesDistribution))
+
+	writeJSON(w, stats)
+}
+
+func (h *Handler) publicIPs(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var ips []types.PublicIP
+	for _, farm := range h.dataset.Farms {
+		for _, ip := range farm.PublicIps {
+			if ip.FarmID == "" {
+				ip.FarmID = strconv.Itoa(farm.FarmID)
+			}
+			ips = append(ips, ip)
+		}
+	}
+
+	serveList(w, r, ips, filters[types.PublicIP]{
+		"free":     equalBool(func(ip types.PublicIP) bool { return ip.ContractID == 0 }),
+		"ip":       equalString(func(ip types.PublicIP) string { return ip.IP }),
+		"gateway":  equalString(func(ip types.PublicIP) string { return ip.Gateway }),
+		"farm_ids": oneOf(func(ip types.PublicIP) string { return ip.FarmID }),
+	}, nil)
+}
+
+// freeIPs returns the IPs not reserved by a contract
+func freeIPs(ips []types.PublicIP) []types.PublicIP {
+	var free []types.PublicIP
+	for _, ip := range ips {
+		if ip.ContractID == 0 {
+			free = append(free, ip)
+		}
+	}
+	return free
+}
+
+// detailString returns a string field of a contract's details
+func detailString(contract types.Contract, key string) string {
+	details, _ := contract.Details.(map[string]interface{})
+	value, _ := details[key].(string)
+	return value
+}
+
+// detailUint returns a numeric field of a contract's details
+func detailUint(contract types.Contract, key string) uint64 {
+	details, _ := contract.Details.(map[string]interface{})
+	switch value := details[key].(type) {
+	case float64:
+		return uint64(value)
+	case int:
+		return uint64(value)
+	case uint64:
+		return value
+	}
+	return 0
+}
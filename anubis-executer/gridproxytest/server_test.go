@@ -0,0 +1,244 @@
+package gridproxytest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/client"
+	"github.com/threefoldtech/tfgrid-sdk-go/grid-proxy/pkg/types"
+)
+
+func newTestClient(t *testing.T) (*Server, client.Client) {
+	t.Helper()
+
+	srv := NewServer(DefaultDataset())
+	t.Cleanup(srv.Close)
+	return srv, client.NewClient(srv.URL)
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+func TestNodes(t *testing.T) {
+	_, gridClient := newTestClient(t)
+
+	tests := []struct {
+		name          string
+		filter        types.NodeFilter
+		limit         types.Limit
+		expectedIDs   []int
+		expectedCount int
+	}{
+		{"all", types.NodeFilter{}, types.Limit{RetCount: true}, []int{11, 12, 13, 21, 22, 31}, 6},
+		{"status", types.NodeFilter{Status: []string{"up", "standby"}}, types.Limit{RetCount: true}, []int{11, 12, 13, 21, 31}, 5},
+		{"farms and country", types.NodeFilter{FarmIDs: []uint64{1, 2}, Country: ptr("egypt")}, types.Limit{RetCount: true}, []int{21, 22}, 2},
+		{"farm name", types.NodeFilter{FarmNameContains: ptr("nile")}, types.Limit{RetCount: true}, []int{21, 22}, 2},
+		{"free memory", types.NodeFilter{FreeMRU: ptr[uint64](100 * gigabyte)}, types.Limit{RetCount: true}, []int{21, 31}, 2},
+		{"gpu", types.NodeFilter{HasGPU: ptr(true)}, types.Limit{RetCount: true}, []int{21}, 1},
+		{"region and free IPs", types.NodeFilter{Region: ptr("Europe"), FreeIPs: ptr[uint64](1)}, types.Limit{RetCount: true}, []int{11, 12, 13}, 3},
+		{"gateways", types.NodeFilter{Domain: ptr(true), IPv4: ptr(true)}, types.Limit{RetCount: true}, []int{11}, 1},
+		{"rented", types.NodeFilter{Rented: ptr(true), RentedBy: ptr[uint64](7)}, types.Limit{RetCount: true}, []int{31}, 1},
+		{"second page", types.NodeFilter{}, types.Limit{Size: 4, Page: 2, RetCount: true}, []int{22, 31}, 6},
+		{"sorted", types.NodeFilter{Status: []string{"up"}}, types.Limit{SortBy: "farm_id", SortOrder: "desc"}, []int{31, 21, 11, 12}, 0},
+		{"sorted by free memory", types.NodeFilter{Status: []string{"up"}}, types.Limit{SortBy: "free_mru", SortOrder: "desc"}, []int{31, 21, 12, 11}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, count, err := gridClient.Nodes(context.Background(), tt.filter, tt.limit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var ids []int
+			for _, node := range nodes {
+				ids = append(ids, node.NodeID)
+			}
+			if !equalInts(ids, tt.expectedIDs) {
+				t.Errorf("expected nodes %v, got %v", tt.expectedIDs, ids)
+			}
+			if count != tt.expectedCount {
+				t.Errorf("expected count %d, got %d", tt.expectedCount, count)
+			}
+		})
+	}
+}
+
+func TestFarmsAndPublicIPs(t *testing.T) {
+	_, gridClient := newTestClient(t)
+	ctx := context.Background()
+
+	farms, _, err := gridClient.Farms(ctx, types.FarmFilter{FreeIPs: ptr[uint64](1)}, types.Limit{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(farms) != 2 || farms[0].Name != "Freefarm" || farms[1].Name != "NileFarm" {
+		t.Errorf("expected the farms with free IPs, got %+v", farms)
+	}
+
+	farms, _, err = gridClient.Farms(ctx, types.FarmFilter{Country: ptr("Germany"), NodeStatus: []string{"up"}}, types.Limit{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(farms) != 1 || farms[0].FarmID != 3 {
+		t.Errorf("expected farm 3 by its nodes, got %+v", farms)
+	}
+
+	ips, count, err := gridClient.PublicIps(ctx, types.PublicIpFilter{Free: ptr(true), FarmIDs: []uint64{1}}, types.Limit{RetCount: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 || len(ips) != 1 || ips[0].IP != "185.69.166.151/24" || ips[0].FarmID != "1" {
+		t.Errorf("expected the free IP of farm 1, got %d: %+v", count, ips)
+	}
+}
+
+func TestContractsAndTwins(t *testing.T) {
+	_, gridClient := newTestClient(t)
+	ctx := context.Background()
+
+	contracts, _, err := gridClient.Contracts(ctx, types.ContractFilter{NodeID: ptr[uint64](21), State: []string{"Created", "GracePeriod"}}, types.Limit{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(contracts) != 1 || contracts[0].ContractID != 1005 {
+		t.Errorf("expected contract 1005, got %+v", contracts)
+	}
+
+	bills, count, err := gridClient.ContractBills(ctx, 1001, types.Limit{Size: 2, Page: 1, RetCount: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 3 || len(bills) != 2 {
+		t.Errorf("expected 2 of 3 bills, got %d of %d", len(bills), count)
+	}
+
+	twins, _, err := gridClient.Twins(ctx, types.TwinFilter{AccountID: ptr("5BobAccount")}, types.Limit{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(twins) != 1 || twins[0].TwinID != 8 {
+		t.Errorf("expected twin 8, got %+v", twins)
+	}
+}
+
+func TestNodeAndContractLookups(t *testing.T) {
+	_, gridClient := newTestClient(t)
+	ctx := context.Background()
+
+	node, err := gridClient.Node(ctx, 11)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if node.Capacity.Total.CRU != 8 || node.Capacity.Used.CRU != 2 {
+		t.Errorf("expected nested capacity, got %+v", node.Capacity)
+	}
+
+	status, err := gridClient.NodeStatus(ctx, 13)
+	if err != nil || status.Status != "standby" {
+		t.Errorf("expected standby, got %+v, %v", status, err)
+	}
+
+	if _, err := gridClient.Node(ctx, 99); err == nil || err.Error() != "node not found" {
+		t.Errorf("expected node not found, got %v", err)
+	}
+	if _, err := gridClient.Contract(ctx, 99); err == nil || err.Error() != "contract not found" {
+		t.Errorf("expected contract not found, got %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	_, gridClient := newTestClient(t)
+
+	stats, err := gridClient.Stats(context.Background(), types.StatsFilter{Status: []string{"up"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stats.Nodes != 4 || stats.Farms != 3 || stats.Countries != 3 || stats.PublicIPs != 3 {
+		t.Errorf("unexpected counts: %+v", stats)
+	}
+	if stats.TotalCRU != 8+16+32+64 || stats.GPUs != 1 || stats.Gateways != 1 || stats.DedicatedNodes != 1 {
+		t.Errorf("unexpected totals: %+v", stats)
+	}
+	if stats.NodesDistribution["Belgium"] != 2 {
+		t.Errorf("expected 2 up nodes in Belgium, got %v", stats.NodesDistribution)
+	}
+}
+
+func TestUnsupportedQuery(t *testing.T) {
+	srv, _ := newTestClient(t)
+
+	for _, query := range []string{"/nodes?free_gpu=1", "/nodes?sort_by=colour", "/farms?page=0"} {
+		resp, err := http.Get(srv.URL + query)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest || body.Error == "" {
+			t.Errorf("%s: expected 400 with an error, got %d %q", query, resp.StatusCode, body.Error)
+		}
+	}
+}
+
+func TestUpdateAndFailNext(t *testing.T) {
+	srv, gridClient := newTestClient(t)
+	ctx := context.Background()
+
+	srv.Update(func(dataset *Dataset) {
+		dataset.Nodes[0].Status = "down"
+	})
+	status, err := gridClient.NodeStatus(ctx, 11)
+	if err != nil || status.Status != "down" {
+		t.Errorf("expected the updated status, got %+v, %v", status, err)
+	}
+
+	srv.FailNext(1, http.StatusBadGateway)
+	if err := gridClient.Ping(); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("expected the injected failure, got %v", err)
+	}
+	if err := gridClient.Ping(); err != nil {
+		t.Errorf("expected the second ping to succeed, got %v", err)
+	}
+
+	if got := srv.Requests("/ping"); got != 2 {
+		t.Errorf("expected 2 ping requests, got %d", got)
+	}
+	if got := srv.Requests(""); got != 3 {
+		t.Errorf("expected 3 requests in total, got %d", got)
+	}
+}
+
+func TestDatasetIsCopied(t *testing.T) {
+	dataset := DefaultDataset()
+	srv := NewServer(dataset)
+	defer srv.Close()
+
+	dataset.Nodes[0].Status = "down"
+
+	status, err := client.NewClient(srv.URL).NodeStatus(context.Background(), 11)
+	if err != nil || status.Status != "up" {
+		t.Errorf("expected the served dataset to be unaffected, got %+v, %v", status, err)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
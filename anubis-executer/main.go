@@ -1,38 +1,327 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"anubis-executer/executer"
+	"anubis-executer/gridproxytest"
+	"anubis-executer/server"
 )
 
 func main() {
-	if len(os.Args) > 1 && os.Args[1] == "demo" {
-		runDemo()
-		return
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "demo":
+			runDemo()
+			return
+		case "schema":
+			printSchema()
+			return
+		case "run":
+			os.Exit(runTask(os.Args[2:]))
+		case "serve":
+			os.Exit(serve(os.Args[2:]))
+		case "fake-gridproxy":
+			os.Exit(fakeGridProxy(os.Args[2:]))
+		}
 	}
 
 	// Default: run as a simple CLI tool
 	fmt.Println("Anubis Task Executor")
 	fmt.Println("Usage:")
 	fmt.Println("  go run main.go demo          - Run demo with test cases")
+	fmt.Println("  go run main.go schema        - Print task definitions and parameter schemas as JSON")
+	fmt.Println("  go run main.go run [flags] [task-json]")
+	fmt.Println("                               - Execute a task or plan JSON from an argument, --file or stdin")
+	fmt.Println("  go run main.go serve [flags] - Serve tasks over HTTP (/tasks, /execute, /healthz)")
+	fmt.Println("  go run main.go fake-gridproxy [flags]")
+	fmt.Println("                               - Serve an in-memory GridProxy for offline end-to-end tests")
 	fmt.Println("  go run main.go               - Show this help")
 	fmt.Println("")
 	fmt.Println("Supported tasks:")
-	executor := executer.NewTaskExecutor("main")
-	for _, task := range executor.GetSupportedTasks() {
-		fmt.Printf("  - %s\n", task)
+	for _, def := range executer.DefaultRegistry.Definitions() {
+		fmt.Printf("  - %-20s [%s] %s\n", def.Name, def.Category, def.Description)
 	}
 }
 
+// printSchema writes the task registry, including parameter schemas, as JSON.
+// The backend embeds this output so both sides validate with the same rules.
+func printSchema() {
+	data, err := executer.DefaultRegistry.Catalog()
+	if err != nil {
+		log.Fatalf("Failed to encode task schema: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+// stringList is a flag that can be given several times
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runTask executes a task or plan JSON, given as an argument, with --file or
+// on stdin, and prints the response. It returns the process exit code:
+// 0 when the task succeeded, 1 when it failed and 2 for usage errors.
+func runTask(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	network := flags.String("network", "main", "GridProxy network: dev, test, qa or main")
+	var endpoints stringList
+	flags.Var(&endpoints, "endpoint", "GridProxy endpoint to use instead of the network's defaults (repeatable)")
+	file := flags.String("file", "", "Read the task or plan JSON from this file, or from stdin with '-'")
+	timeout := flags.Duration("timeout", 0, "Bound the whole run, and every task without its own timeout (e.g. 2m)")
+	output := flags.String("output", executer.OutputPretty, "Output format: "+strings.Join(executer.OutputFormats, ", "))
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go run main.go run [flags] [task-json]")
+		fmt.Fprintln(flags.Output(), "Without task-json or --file, the task or plan is read from stdin.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	executor, err := newExecutor(*network, endpoints)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if !slices.Contains(executer.OutputFormats, *output) {
+		fmt.Fprintf(os.Stderr, "output must be one of [%s], got: %s\n", strings.Join(executer.OutputFormats, ", "), *output)
+		return 2
+	}
+	if *timeout < 0 {
+		fmt.Fprintf(os.Stderr, "timeout must be positive, got: %s\n", *timeout)
+		return 2
+	}
+
+	taskJSON, err := readTaskInput(*file, flags.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *timeout > 0 {
+		taskJSON, err = applyTimeout(taskJSON, *timeout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	responseJSON, err := executor.ExecuteTaskJSON(ctx, taskJSON)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error executing task: %v\n", err)
+		return 1
+	}
+
+	if err := executer.RenderResponse(os.Stdout, responseJSON, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error printing response: %v\n", err)
+		return 1
+	}
+
+	var response executer.TaskResponse
+	if err := json.Unmarshal(responseJSON, &response); err != nil || !response.Success {
+		return 1
+	}
+	return 0
+}
+
+// serve runs the executor HTTP server until interrupted. The shared secret
+// is read from ANUBIS_EXECUTOR_SECRET so it does not show up in process lists.
+// It returns the process exit code.
+func serve(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":8090", "Address to listen on")
+	network := flags.String("network", "main", "GridProxy network: dev, test, qa or main")
+	var endpoints stringList
+	flags.Var(&endpoints, "endpoint", "GridProxy endpoint to use instead of the network's defaults (repeatable)")
+	tlsCert := flags.String("tls-cert", "", "Server certificate file; enables TLS")
+	tlsKey := flags.String("tls-key", "", "Server private key file")
+	clientCA := flags.String("client-ca", "", "CA file that client certificates must chain to; enables mTLS")
+	maxConcurrent := flags.Int("max-concurrent", server.DefaultMaxConcurrent, "Tasks executed at once; further requests get 503")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go run main.go serve [flags]")
+		fmt.Fprintln(flags.Output(), "Set ANUBIS_EXECUTOR_SECRET, --client-ca, or both to authenticate clients.")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	executor, err := newExecutor(*network, endpoints)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	srv, err := server.New(executor, server.Config{
+		Addr:          *addr,
+		SharedSecret:  os.Getenv("ANUBIS_EXECUTOR_SECRET"),
+		TLSCertFile:   *tlsCert,
+		TLSKeyFile:    *tlsKey,
+		ClientCAFile:  *clientCA,
+		MaxConcurrent: *maxConcurrent,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid server configuration: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Executor serving %s network on %s", *network, *addr)
+	if err := srv.ListenAndServe(ctx); err != nil {
+		log.Printf("Executor server failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// fakeGridProxy serves the GridProxy API over an in-memory dataset until
+// interrupted, so `serve --endpoint` can run without network access.
+// It returns the process exit code.
+func fakeGridProxy(args []string) int {
+	flags := flag.NewFlagSet("fake-gridproxy", flag.ContinueOnError)
+	addr := flags.String("addr", "127.0.0.1:8091", "Address to listen on")
+	datasetFile := flags.String("dataset", "", "JSON dataset to serve instead of the built-in one")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: go run main.go fake-gridproxy [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	dataset := gridproxytest.DefaultDataset()
+	if *datasetFile != "" {
+		var err error
+		if dataset, err = gridproxytest.LoadDataset(*datasetFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	httpServer := &http.Server{Addr: *addr, Handler: gridproxytest.NewHandler(dataset)}
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	log.Printf("Fake GridProxy serving %d farms and %d nodes on %s", len(dataset.Farms), len(dataset.Nodes), *addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("Fake GridProxy failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// newExecutor creates the executor of the run and serve commands, using
+// endpoints instead of the network's defaults when any are given
+func newExecutor(network string, endpoints []string) (*executer.TaskExecutor, error) {
+	var opts []executer.Option
+	if len(endpoints) > 0 {
+		opts = append(opts, executer.WithEndpoints(endpoints...))
+	}
+	return executer.NewTaskExecutor(network, opts...)
+}
+
+// readTaskInput returns the task or plan JSON from file, the single
+// argument, or stdin when neither is given or either is "-"
+func readTaskInput(file string, args []string) ([]byte, error) {
+	var data []byte
+	var err error
+	switch {
+	case file != "" && len(args) > 0:
+		return nil, errors.New("give the task either as an argument or with --file, not both")
+	case len(args) > 1:
+		return nil, fmt.Errorf("expected a single task JSON argument, got %d", len(args))
+	case file == "-" || (file == "" && (len(args) == 0 || args[0] == "-")):
+		data, err = io.ReadAll(os.Stdin)
+	case file != "":
+		data, err = os.ReadFile(file)
+	default:
+		data = []byte(args[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task: %v", err)
+	}
+
+	if !json.Valid(data) {
+		return nil, errors.New("task input is not valid JSON")
+	}
+	return data, nil
+}
+
+// applyTimeout sets the timeout of a task, or of every step of a plan,
+// that does not set its own. Timeouts are capped at the executor's maximum.
+func applyTimeout(taskJSON []byte, timeout time.Duration) ([]byte, error) {
+	// Numbers are kept as written so large IDs survive the round trip
+	decoder := json.NewDecoder(bytes.NewReader(taskJSON))
+	decoder.UseNumber()
+	var input map[string]interface{}
+	if err := decoder.Decode(&input); err != nil {
+		return nil, fmt.Errorf("task input must be a JSON object: %v", err)
+	}
+
+	timeout = min(timeout, executer.MaxTaskTimeout)
+	setTimeout := func(task map[string]interface{}) {
+		if _, ok := task["timeout"]; !ok {
+			task["timeout"] = timeout.String()
+		}
+	}
+
+	if steps, ok := input["steps"].([]interface{}); ok {
+		for _, step := range steps {
+			if stepMap, ok := step.(map[string]interface{}); ok {
+				setTimeout(stepMap)
+			}
+		}
+	} else {
+		setTimeout(input)
+	}
+
+	return json.Marshal(input)
+}
+
 func runDemo() {
 	log.Println("Starting Anubis Task Executor Demo")
 
 	// Create a new task executor (using main network)
-	executor := executer.NewTaskExecutor("main")
+	executor, err := executer.NewTaskExecutor("main")
+	if err != nil {
+		log.Fatalf("Failed to create executor: %v", err)
+	}
 
 	// Test cases with various JSON task examples
 	testCases := []string{
@@ -61,7 +350,7 @@ func runDemo() {
 		fmt.Printf("Input: %s\n", testCase)
 
 		// Execute the task
-		responseJSON, err := executor.ExecuteTaskJSON([]byte(testCase))
+		responseJSON, err := executor.ExecuteTaskJSON(context.Background(), []byte(testCase))
 		if err != nil {
 			log.Printf("Error executing task: %v", err)
 			continue
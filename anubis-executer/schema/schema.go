@@ -0,0 +1,346 @@
+// Package schema describes task parameters in JSON-Schema style and validates
+// parameters against them. It has no dependencies outside the standard
+// library, so the Anubis API imports it to reject the same inputs with the
+// same messages as the executor.
+package schema
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParamType is the JSON type of a task parameter
+type ParamType string
+
+// Supported parameter types
+const (
+	TypeString  ParamType = "string"
+	TypeInteger ParamType = "integer"
+	TypeNumber  ParamType = "number"
+	TypeBoolean ParamType = "boolean"
+	TypeArray   ParamType = "array"
+)
+
+// ParamSpec describes a single task parameter in JSON-Schema style
+type ParamSpec struct {
+	Type        ParamType     `json:"type"`
+	Description string        `json:"description,omitempty"`
+	Minimum     *float64      `json:"minimum,omitempty"`
+	Maximum     *float64      `json:"maximum,omitempty"`
+	Enum        []interface{} `json:"enum,omitempty"`
+	Default     interface{}   `json:"default,omitempty"`
+	Items       *ParamSpec    `json:"items,omitempty"`
+}
+
+// ParamSchema describes the parameters accepted by a task
+type ParamSchema struct {
+	Type       string                `json:"type"`
+	Properties map[string]*ParamSpec `json:"properties"`
+	Required   []string              `json:"required,omitempty"`
+}
+
+// FieldError describes a validation failure for a single parameter
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface
+func (fe FieldError) Error() string {
+	return fe.Field + " " + fe.Message
+}
+
+// ValidationError is returned when task parameters do not match the task's schema
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+// Error implements the error interface
+func (ve *ValidationError) Error() string {
+	messages := make([]string, len(ve.Errors))
+	for i, fe := range ve.Errors {
+		messages[i] = fe.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Params creates a task parameter schema from its properties and required parameter names
+func Params(properties map[string]*ParamSpec, required ...string) *ParamSchema {
+	return &ParamSchema{
+		Type:       "object",
+		Properties: properties,
+		Required:   required,
+	}
+}
+
+// StringParam creates a string parameter spec
+func StringParam(description string) *ParamSpec {
+	return &ParamSpec{Type: TypeString, Description: description}
+}
+
+// IntegerParam creates an integer parameter spec
+func IntegerParam(description string) *ParamSpec {
+	return &ParamSpec{Type: TypeInteger, Description: description}
+}
+
+// NumberParam creates a number parameter spec
+func NumberParam(description string) *ParamSpec {
+	return &ParamSpec{Type: TypeNumber, Description: description}
+}
+
+// BooleanParam creates a boolean parameter spec
+func BooleanParam(description string) *ParamSpec {
+	return &ParamSpec{Type: TypeBoolean, Description: description}
+}
+
+// ArrayParam creates an array parameter spec whose elements match items
+func ArrayParam(description string, items *ParamSpec) *ParamSpec {
+	return &ParamSpec{Type: TypeArray, Description: description, Items: items}
+}
+
+// WithMin sets the inclusive minimum of a numeric parameter
+func (ps *ParamSpec) WithMin(min float64) *ParamSpec {
+	ps.Minimum = &min
+	return ps
+}
+
+// WithMax sets the inclusive maximum of a numeric parameter
+func (ps *ParamSpec) WithMax(max float64) *ParamSpec {
+	ps.Maximum = &max
+	return ps
+}
+
+// WithEnum restricts the parameter to a fixed set of values
+func (ps *ParamSpec) WithEnum(values ...interface{}) *ParamSpec {
+	ps.Enum = values
+	return ps
+}
+
+// WithDefault sets the value used when the parameter is omitted
+func (ps *ParamSpec) WithDefault(value interface{}) *ParamSpec {
+	ps.Default = value
+	return ps
+}
+
+// Validate checks params against the schema and returns a copy with values
+// coerced to their declared types (integers as int64, numbers as float64)
+// and defaults applied. Parameters not declared in the schema are passed
+// through unchanged. A nil schema accepts any parameters.
+func (s *ParamSchema) Validate(params map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		result[name] = value
+	}
+
+	if s == nil {
+		return result, nil
+	}
+
+	var fieldErrors []FieldError
+
+	for _, name := range s.Required {
+		if value, ok := params[name]; !ok || value == nil {
+			fieldErrors = append(fieldErrors, FieldError{Field: name, Message: "parameter is required"})
+		}
+	}
+
+	for name, spec := range s.Properties {
+		value, ok := params[name]
+		if !ok || value == nil {
+			if spec.Default != nil {
+				coerced, errs := spec.coerce(name, spec.Default)
+				fieldErrors = append(fieldErrors, errs...)
+				result[name] = coerced
+			}
+			continue
+		}
+
+		coerced, errs := spec.coerce(name, value)
+		if len(errs) > 0 {
+			fieldErrors = append(fieldErrors, errs...)
+			continue
+		}
+		result[name] = coerced
+	}
+
+	if len(fieldErrors) > 0 {
+		sort.SliceStable(fieldErrors, func(i, j int) bool {
+			return fieldErrors[i].Field < fieldErrors[j].Field
+		})
+		return nil, &ValidationError{Errors: fieldErrors}
+	}
+
+	return result, nil
+}
+
+// coerce converts value to the spec's type and checks its constraints
+func (ps *ParamSpec) coerce(field string, value interface{}) (interface{}, []FieldError) {
+	fail := func(format string, args ...interface{}) (interface{}, []FieldError) {
+		return nil, []FieldError{{Field: field, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	var coerced interface{}
+	switch ps.Type {
+	case TypeString:
+		str, ok := value.(string)
+		if !ok {
+			return fail("must be a string, got: %T", value)
+		}
+		coerced = str
+
+	case TypeInteger:
+		n, ok := ToInt64(value)
+		if !ok {
+			if _, isNumber := ToFloat64(value); isNumber {
+				return fail("must be an integer, got: %v", value)
+			}
+			return fail("must be an integer, got: %T", value)
+		}
+		if errs := ps.checkRange(field, float64(n)); errs != nil {
+			return nil, errs
+		}
+		coerced = n
+
+	case TypeNumber:
+		f, ok := ToFloat64(value)
+		if !ok {
+			return fail("must be a number, got: %T", value)
+		}
+		if errs := ps.checkRange(field, f); errs != nil {
+			return nil, errs
+		}
+		coerced = f
+
+	case TypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			coerced = v
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fail("must be a boolean, got: %q", v)
+			}
+			coerced = b
+		default:
+			return fail("must be a boolean, got: %T", value)
+		}
+
+	case TypeArray:
+		items := ToSlice(value)
+		values := make([]interface{}, 0, len(items))
+		var fieldErrors []FieldError
+		for i, item := range items {
+			if ps.Items == nil {
+				values = append(values, item)
+				continue
+			}
+			v, errs := ps.Items.coerce(fmt.Sprintf("%s[%d]", field, i), item)
+			fieldErrors = append(fieldErrors, errs...)
+			values = append(values, v)
+		}
+		if len(fieldErrors) > 0 {
+			return nil, fieldErrors
+		}
+		coerced = values
+
+	default:
+		coerced = value
+	}
+
+	if len(ps.Enum) > 0 && !ps.allows(coerced) {
+		return fail("must be one of %v, got: %v", ps.Enum, coerced)
+	}
+
+	return coerced, nil
+}
+
+// checkRange validates a numeric value against the spec's minimum and maximum
+func (ps *ParamSpec) checkRange(field string, value float64) []FieldError {
+	var message string
+	switch {
+	case ps.Minimum != nil && ps.Maximum != nil && (value < *ps.Minimum || value > *ps.Maximum):
+		message = fmt.Sprintf("must be between %v and %v, got: %v", *ps.Minimum, *ps.Maximum, value)
+	case ps.Minimum != nil && value < *ps.Minimum:
+		message = fmt.Sprintf("must be >= %v, got: %v", *ps.Minimum, value)
+	case ps.Maximum != nil && value > *ps.Maximum:
+		message = fmt.Sprintf("must be <= %v, got: %v", *ps.Maximum, value)
+	default:
+		return nil
+	}
+	return []FieldError{{Field: field, Message: message}}
+}
+
+// allows reports whether value is one of the spec's enum values
+func (ps *ParamSpec) allows(value interface{}) bool {
+	for _, allowed := range ps.Enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToInt64 converts whole numbers of any numeric type, or numeric strings, to int64
+func ToInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case float64:
+		if v != math.Trunc(v) || math.IsInf(v, 0) {
+			return 0, false
+		}
+		return int64(v), true
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// ToFloat64 converts any numeric type, or numeric strings, to float64
+func ToFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		if n, ok := ToInt64(value); ok {
+			return float64(n), true
+		}
+		return 0, false
+	}
+}
+
+// ToSlice converts array-like values to []interface{}; a scalar becomes a one-element slice
+func ToSlice(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return items
+	default:
+		return []interface{}{value}
+	}
+}
@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParamSchemaValidate(t *testing.T) {
+	schema := Params(map[string]*ParamSpec{
+		"page":    IntegerParam("page").WithMin(1).WithMax(1000).WithDefault(1),
+		"farm_id": IntegerParam("farm").WithMin(1),
+		"name":    StringParam("name"),
+		"ratio":   NumberParam("ratio").WithMax(1),
+		"up":      BooleanParam("up"),
+		"status":  ArrayParam("status", StringParam("status").WithEnum("up", "down")),
+	}, "farm_id")
+
+	tests := []struct {
+		name           string
+		params         map[string]interface{}
+		expected       map[string]interface{}
+		expectedErrors []FieldError
+	}{
+		{
+			name:     "coerces JSON numbers and applies defaults",
+			params:   map[string]interface{}{"farm_id": float64(5)},
+			expected: map[string]interface{}{"farm_id": int64(5), "page": int64(1)},
+		},
+		{
+			name:     "coerces strings",
+			params:   map[string]interface{}{"farm_id": "7", "ratio": "0.5", "up": "true"},
+			expected: map[string]interface{}{"farm_id": int64(7), "page": int64(1), "ratio": 0.5, "up": true},
+		},
+		{
+			name:     "wraps scalar into array",
+			params:   map[string]interface{}{"farm_id": 1, "status": "up"},
+			expected: map[string]interface{}{"farm_id": int64(1), "page": int64(1), "status": []interface{}{"up"}},
+		},
+		{
+			name:     "passes unknown parameters through",
+			params:   map[string]interface{}{"farm_id": 1, "extra": "value"},
+			expected: map[string]interface{}{"farm_id": int64(1), "page": int64(1), "extra": "value"},
+		},
+		{
+			name:           "missing required parameter",
+			params:         map[string]interface{}{},
+			expectedErrors: []FieldError{{Field: "farm_id", Message: "parameter is required"}},
+		},
+		{
+			name:   "type and range errors are reported per field",
+			params: map[string]interface{}{"farm_id": "abc", "page": float64(0), "name": float64(3), "up": "maybe"},
+			expectedErrors: []FieldError{
+				{Field: "farm_id", Message: "must be an integer, got: string"},
+				{Field: "name", Message: "must be a string, got: float64"},
+				{Field: "page", Message: "must be between 1 and 1000, got: 0"},
+				{Field: "up", Message: "must be a boolean, got: \"maybe\""},
+			},
+		},
+		{
+			name:           "fractional integer",
+			params:         map[string]interface{}{"farm_id": 1.5},
+			expectedErrors: []FieldError{{Field: "farm_id", Message: "must be an integer, got: 1.5"}},
+		},
+		{
+			name:           "maximum only",
+			params:         map[string]interface{}{"farm_id": 1, "ratio": 2},
+			expectedErrors: []FieldError{{Field: "ratio", Message: "must be <= 1, got: 2"}},
+		},
+		{
+			name:           "enum violation inside array",
+			params:         map[string]interface{}{"farm_id": 1, "status": []interface{}{"up", "sideways"}},
+			expectedErrors: []FieldError{{Field: "status[1]", Message: "must be one of [up down], got: sideways"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := schema.Validate(tt.params)
+
+			if tt.expectedErrors != nil {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected *ValidationError, got %v", err)
+				}
+				if len(validationErr.Errors) != len(tt.expectedErrors) {
+					t.Fatalf("expected %d errors, got %v", len(tt.expectedErrors), validationErr.Errors)
+				}
+				for i, expected := range tt.expectedErrors {
+					if validationErr.Errors[i] != expected {
+						t.Errorf("expected error %v at index %d, got %v", expected, i, validationErr.Errors[i])
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expectedJSON, _ := json.Marshal(tt.expected)
+			actualJSON, _ := json.Marshal(result)
+			if string(expectedJSON) != string(actualJSON) {
+				t.Errorf("expected %s, got %s", expectedJSON, actualJSON)
+			}
+			for key, value := range tt.expected {
+				if _, isSlice := value.([]interface{}); !isSlice && result[key] != value {
+					t.Errorf("expected %s to be %#v, got %#v", key, value, result[key])
+				}
+			}
+		})
+	}
+}
+
+func TestNilParamSchemaAcceptsAnything(t *testing.T) {
+	var schema *ParamSchema
+
+	result, err := schema.Validate(map[string]interface{}{"anything": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result["anything"] != 1 {
+		t.Errorf("expected params to be passed through, got %v", result)
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Errors: []FieldError{
+		{Field: "farm_id", Message: "parameter is required"},
+		{Field: "page", Message: "must be >= 1, got: 0"},
+	}}
+
+	expected := "farm_id parameter is required; page must be >= 1, got: 0"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
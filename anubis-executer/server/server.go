@@ -0,0 +1,301 @@
+// Package server exposes a TaskExecutor over HTTP so the executor can be
+// deployed and scaled independently of the Anubis API.
+//
+// Endpoints:
+//
+//	GET  /tasks    task registry with parameter schemas
+//	POST /execute  run a task or plan JSON, as accepted by ExecuteTaskJSON
+//	GET  /healthz  liveness, in-flight tasks and GridProxy endpoint health
+//
+// /tasks and /execute require the shared secret as a bearer token, a client
+// certificate signed by the configured CA, or both.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"anubis-executer/executer"
+)
+
+// RequestIDHeader carries the request ID between the API, the executor and their logs
+const RequestIDHeader = "X-Request-ID"
+
+// Defaults applied to zero Config fields
+const (
+	DefaultMaxConcurrent = 16
+	DefaultMaxBodyBytes  = 1 << 20
+)
+
+// Config configures the executor HTTP server
+type Config struct {
+	Addr          string // Listen address, e.g. ":8090"
+	SharedSecret  string // Bearer token clients must send; empty disables the check
+	TLSCertFile   string // Server certificate; TLS is enabled when set
+	TLSKeyFile    string
+	ClientCAFile  string // CA that client certificates must chain to (mTLS); requires TLS
+	MaxConcurrent int    // Tasks executed at once; further requests get 503
+	MaxBodyBytes  int64  // Largest accepted /execute body
+}
+
+// Server serves a TaskExecutor over HTTP
+type Server struct {
+	executor *executer.TaskExecutor
+	config   Config
+	slots    chan struct{} // Holds a token per task being executed
+}
+
+// New creates a Server for executor. It refuses to serve without
+// authentication: a shared secret, mTLS or both must be configured.
+func New(executor *executer.TaskExecutor, config Config) (*Server, error) {
+	if config.SharedSecret == "" && config.ClientCAFile == "" {
+		return nil, errors.New("a shared secret or a client CA for mTLS is required")
+	}
+	if config.ClientCAFile != "" && config.TLSCertFile == "" {
+		return nil, errors.New("mTLS requires a server certificate and key")
+	}
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		return nil, errors.New("TLS requires both a certificate and a key")
+	}
+	if config.MaxConcurrent <= 0 {
+		config.MaxConcurrent = DefaultMaxConcurrent
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	return &Server{
+		executor: executor,
+		config:   config,
+		slots:    make(chan struct{}, config.MaxConcurrent),
+	}, nil
+}
+
+// Handler returns the HTTP handler serving the executor's endpoints
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.Handle("GET /tasks", s.authenticate(http.HandlerFunc(s.handleTasks)))
+	mux.Handle("POST /execute", s.authenticate(http.HandlerFunc(s.handleExecute)))
+	return withRequestID(mux)
+}
+
+// ListenAndServe serves until ctx is done, then shuts down gracefully,
+// letting in-flight tasks finish
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:              s.config.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if s.config.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(s.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA: %v", err)
+		}
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificates found in %s", s.config.ClientCAFile)
+		}
+		httpServer.TLSConfig = &tls.Config{
+			ClientCAs:  clientCAs,
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		if s.config.TLSCertFile != "" {
+			errs <- httpServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+		} else {
+			errs <- httpServer.ListenAndServe()
+		}
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		log.Println("Shutting down executor server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), executer.MaxTaskTimeout)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// withRequestID takes the request ID from the X-Request-ID header, or
+// generates one, and echoes it on the response
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, requestID)))
+	})
+}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("req_%d", time.Now().UnixNano())
+	}
+	return "req_" + hex.EncodeToString(id)
+}
+
+// requestID returns the request ID of r
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// authenticate rejects requests without the shared secret. Client
+// certificates are verified by the TLS handshake before a request gets here.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.config.SharedSecret != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.config.SharedSecret)) != 1 {
+				log.Printf("[%s] Rejected %s %s: invalid or missing shared secret", requestID(r), r.Method, r.URL.Path)
+				writeError(w, http.StatusUnauthorized, "invalid or missing shared secret")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HealthResponse is returned by GET /healthz
+type HealthResponse struct {
+	Status        string                    `json:"status"` // ok, or degraded when no GridProxy endpoint is healthy
+	InFlight      int                       `json:"in_flight"`
+	MaxConcurrent int                       `json:"max_concurrent"`
+	Endpoints     []executer.EndpointHealth `json:"endpoints,omitempty"`
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	response := HealthResponse{
+		Status:        "ok",
+		InFlight:      len(s.slots),
+		MaxConcurrent: cap(s.slots),
+		Endpoints:     s.executor.GetEndpointHealth(),
+	}
+
+	if len(response.Endpoints) > 0 {
+		response.Status = "degraded"
+		for _, endpoint := range response.Endpoints {
+			if endpoint.Healthy {
+				response.Status = "ok"
+				break
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// TasksResponse is returned by GET /tasks
+type TasksResponse struct {
+	Tasks []executer.TaskDefinition `json:"tasks"`
+}
+
+func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, TasksResponse{Tasks: s.executor.GetTaskDefinitions()})
+}
+
+// handleExecute runs a task or plan and returns the executor's TaskResponse.
+// Task failures are reported in the response with status 200; non-200
+// statuses mean the task was not run.
+func (s *Server) handleExecute(w http.ResponseWriter, r *http.Request) {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	default:
+		log.Printf("[%s] Rejected task: %d tasks already running", requestID(r), cap(s.slots))
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusServiceUnavailable, "executor is busy, retry later")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.config.MaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err))
+		return
+	}
+	if !json.Valid(body) {
+		writeError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+
+	start := time.Now()
+	responseJSON, err := s.executor.ExecuteTaskJSON(r.Context(), body)
+	if err != nil {
+		log.Printf("[%s] Failed to encode task response: %v", requestID(r), err)
+		writeError(w, http.StatusInternalServerError, "failed to encode task response")
+		return
+	}
+	log.Printf("[%s] Executed task in %s", requestID(r), time.Since(start))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseJSON)
+}
+
+// writeJSON writes value as a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// Error codes of requests the server rejects before running a task
+const (
+	codeUnauthorized executer.ErrorCode = "unauthorized"
+	codeBusy         executer.ErrorCode = "busy"
+)
+
+// writeError writes an error in the executor's TaskResponse format, with
+// the error code matching status
+func writeError(w http.ResponseWriter, status int, message string) {
+	response := executer.TaskResponse{Success: false, Error: message}
+	switch status {
+	case http.StatusUnauthorized:
+		response.Code = codeUnauthorized
+	case http.StatusServiceUnavailable:
+		response.Code = codeBusy
+		response.Retryable = true
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		response.Code = executer.CodeInvalidRequest
+	default:
+		response.Code = executer.CodeInternal
+	}
+	writeJSON(w, status, response)
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"anubis-executer/executer"
+	"anubis-executer/gridproxytest"
+)
+
+const testSecret = "s3cret"
+
+func newTestExecutor(t *testing.T, endpoints ...string) *executer.TaskExecutor {
+	t.Helper()
+
+	executor, err := executer.NewTaskExecutor("test", executer.WithEndpoints(endpoints...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return executor
+}
+
+func newTestServer(t *testing.T, config Config) *Server {
+	t.Helper()
+
+	// Nothing listens on this endpoint; tests only run tasks that fail validation
+	executor := newTestExecutor(t, "http://127.0.0.1:1/")
+	if config.SharedSecret == "" {
+		config.SharedSecret = testSecret
+	}
+
+	srv, err := New(executor, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return srv
+}
+
+func doRequest(srv *Server, method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestNewRequiresAuthentication(t *testing.T) {
+	executor := newTestExecutor(t, "http://127.0.0.1:1/")
+
+	tests := []struct {
+		name          string
+		config        Config
+		expectedError string
+	}{
+		{"no authentication", Config{}, "shared secret or a client CA"},
+		{"mTLS without TLS", Config{ClientCAFile: "ca.pem"}, "requires a server certificate"},
+		{"certificate without key", Config{SharedSecret: testSecret, TLSCertFile: "cert.pem"}, "both a certificate and a key"},
+		{"shared secret", Config{SharedSecret: testSecret}, ""},
+		{"mTLS", Config{ClientCAFile: "ca.pem", TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(executor, tt.config)
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Errorf("expected error containing %q, got %v", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	srv := newTestServer(t, Config{})
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		authorization  string
+		expectedStatus int
+	}{
+		{"tasks without secret", http.MethodGet, "/tasks", "", http.StatusUnauthorized},
+		{"tasks with wrong secret", http.MethodGet, "/tasks", "Bearer wrong", http.StatusUnauthorized},
+		{"tasks with secret", http.MethodGet, "/tasks", "Bearer " + testSecret, http.StatusOK},
+		{"execute without secret", http.MethodPost, "/execute", "", http.StatusUnauthorized},
+		{"health is public", http.MethodGet, "/healthz", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := doRequest(srv, tt.method, tt.path, `{"task_name": "get_farm", "params": {}}`,
+				map[string]string{"Authorization": tt.authorization})
+
+			if recorder.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestTasks(t *testing.T) {
+	srv := newTestServer(t, Config{})
+
+	recorder := doRequest(srv, http.MethodGet, "/tasks", "", map[string]string{"Authorization": "Bearer " + testSecret})
+
+	var response struct {
+		Tasks []struct {
+			Name       string                 `json:"name"`
+			Parameters map[string]interface{} `json:"parameters"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(response.Tasks) != len(executer.DefaultRegistry.Names()) {
+		t.Fatalf("expected %d tasks, got %d", len(executer.DefaultRegistry.Names()), len(response.Tasks))
+	}
+	if response.Tasks[0].Name != "list_farms" || response.Tasks[0].Parameters == nil {
+		t.Errorf("expected list_farms with its parameter schema first, got %+v", response.Tasks[0])
+	}
+}
+
+func TestExecute(t *testing.T) {
+	srv := newTestServer(t, Config{MaxBodyBytes: 64})
+	auth := map[string]string{"Authorization": "Bearer " + testSecret}
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedError  string
+		expectedCode   executer.ErrorCode
+	}{
+		{"task failures are responses", `{"task_name": "get_farm", "params": {}}`, http.StatusOK, "farm_id parameter is required", executer.CodeInvalidParams},
+		{"unknown task", `{"task_name": "unknown_task"}`, http.StatusOK, "unknown task: unknown_task", executer.CodeUnknownTask},
+		{"invalid JSON", `{"task_name":`, http.StatusBadRequest, "not valid JSON", executer.CodeInvalidRequest},
+		{"body too large", `{"task_name": "get_farm", "params": {"farm_id": 1, "padding": "` + strings.Repeat("x", 64) + `"}}`, http.StatusRequestEntityTooLarge, "exceeds 64 bytes", executer.CodeInvalidRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := doRequest(srv, http.MethodPost, "/execute", tt.body, auth)
+			if recorder.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+
+			var response executer.TaskResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Success || !strings.Contains(response.Error, tt.expectedError) || response.Code != tt.expectedCode {
+				t.Errorf("expected %s failure containing %q, got %+v", tt.expectedCode, tt.expectedError, response)
+			}
+		})
+	}
+}
+
+func TestExecuteConcurrencyLimit(t *testing.T) {
+	srv := newTestServer(t, Config{MaxConcurrent: 1})
+
+	// Occupy the only slot as if a task were running
+	srv.slots <- struct{}{}
+
+	recorder := doRequest(srv, http.MethodPost, "/execute", `{"task_name": "get_farm", "params": {}}`,
+		map[string]string{"Authorization": "Bearer " + testSecret})
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status 503, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Errorf("expected a Retry-After header")
+	}
+
+	health := doRequest(srv, http.MethodGet, "/healthz", "", nil)
+	var response HealthResponse
+	if err := json.Unmarshal(health.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode health: %v", err)
+	}
+	if response.InFlight != 1 || response.MaxConcurrent != 1 {
+		t.Errorf("expected 1 of 1 tasks in flight, got %d of %d", response.InFlight, response.MaxConcurrent)
+	}
+
+	<-srv.slots
+	recorder = doRequest(srv, http.MethodPost, "/execute", `{"task_name": "get_farm", "params": {}}`,
+		map[string]string{"Authorization": "Bearer " + testSecret})
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected status 200 once the slot is free, got %d", recorder.Code)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	srv := newTestServer(t, Config{})
+
+	recorder := doRequest(srv, http.MethodGet, "/healthz", "", map[string]string{RequestIDHeader: "req_from_api"})
+	if got := recorder.Header().Get(RequestIDHeader); got != "req_from_api" {
+		t.Errorf("expected the caller's request ID to be echoed, got %q", got)
+	}
+
+	recorder = doRequest(srv, http.MethodGet, "/healthz", "", nil)
+	if got := recorder.Header().Get(RequestIDHeader); !strings.HasPrefix(got, "req_") {
+		t.Errorf("expected a generated request ID, got %q", got)
+	}
+}
+
+func TestExecuteAgainstFakeGridProxy(t *testing.T) {
+	gridProxy := gridproxytest.NewServer(gridproxytest.DefaultDataset())
+	defer gridProxy.Close()
+
+	// The first endpoint is unreachable, so tasks also go through failover
+	executor := newTestExecutor(t, "http://127.0.0.1:1/", gridProxy.URL)
+	srv, err := New(executor, Config{SharedSecret: testSecret})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	httpServer := httptest.NewServer(srv.Handler())
+	defer httpServer.Close()
+
+	execute := func(task string) executer.TaskResponse {
+		t.Helper()
+
+		req, _ := http.NewRequest(http.MethodPost, httpServer.URL+"/execute", strings.NewReader(task))
+		req.Header.Set("Authorization", "Bearer "+testSecret)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var response executer.TaskResponse
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	response := execute(`{"task_name": "list_nodes", "params": {"farm_ids": [1], "status": ["up"]}}`)
+	if !response.Success {
+		t.Fatalf("expected success, got %s", response.Error)
+	}
+	data, _ := json.Marshal(response.Data)
+	var nodes executer.ListResult[executer.Node]
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		t.Fatalf("failed to decode nodes: %v", err)
+	}
+	if nodes.TotalCount != 2 || len(nodes.Items) != 2 || nodes.Items[0].NodeID != 11 {
+		t.Errorf("expected the 2 up nodes of farm 1, got %+v", nodes)
+	}
+
+	response = execute(`{"task_name": "get_node", "params": {"node_id": 99}}`)
+	if response.Success || response.Code != executer.CodeNotFound {
+		t.Errorf("expected a not found failure, got %+v", response)
+	}
+
+	if gridProxy.Requests("/nodes") == 0 {
+		t.Errorf("expected the tasks to reach the fake GridProxy")
+	}
+}
@@ -0,0 +1,369 @@
+// Package shape sorts, truncates, projects and renders the items of list
+// results. It has no dependencies outside the standard library and the
+// schema package, so the Anubis API shapes grid snapshot results the same
+// way as the executor shapes live ones.
+package shape
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"anubis-executer/schema"
+)
+
+// Formats accepted by the format parameter of list tasks
+const (
+	FormatFull     = "full"     // The list envelope with its items as JSON
+	FormatCompact  = "compact"  // The items as a CSV table under "text"
+	FormatMarkdown = "markdown" // The items as a Markdown bullet list under "text"
+)
+
+// Options controls how the items of a list result are shaped for the caller
+type Options struct {
+	fields     []string // Field paths to keep, e.g. "name" or "total_resources.cru"
+	sortBy     string
+	descending bool
+	limit      int // Zero keeps every item
+	format     string
+}
+
+// OptionsFromParams reads fields, sort_by, order, limit and format.
+// The parameters have been validated against the list task's schema.
+func OptionsFromParams(params map[string]interface{}) Options {
+	opts := Options{format: FormatFull}
+
+	if fields, ok := params["fields"].([]interface{}); ok {
+		for _, field := range fields {
+			if name, ok := field.(string); ok && name != "" {
+				opts.fields = append(opts.fields, name)
+			}
+		}
+	}
+	opts.sortBy, _ = params["sort_by"].(string)
+	opts.descending = params["order"] == "desc"
+	if limit, ok := params["limit"].(int64); ok {
+		opts.limit = int(limit)
+	}
+	if format, ok := params["format"].(string); ok && format != "" {
+		opts.format = format
+	}
+	return opts
+}
+
+// isZero reports whether opts leave results unchanged
+func (opts Options) isZero() bool {
+	return len(opts.fields) == 0 && opts.sortBy == "" && opts.limit == 0 && opts.format == FormatFull
+}
+
+// Apply sorts, truncates and projects the items of a list result and
+// renders them in the requested format. Sorting applies to the fetched items
+// only; use all or max_items to sort across pages. Unknown fields are
+// reported as a *schema.ValidationError.
+func Apply(result interface{}, opts Options) (interface{}, error) {
+	if opts.isZero() {
+		return result, nil
+	}
+
+	// Work on the JSON form so every list task is shaped the same way.
+	// Numbers are kept as written so IDs and amounts are not reformatted.
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var envelope map[string]interface{}
+	if err := decoder.Decode(&envelope); err != nil {
+		return result, nil
+	}
+	items, ok := envelope["items"].([]interface{})
+	if !ok {
+		return result, nil
+	}
+
+	if opts.sortBy != "" {
+		if err := sortByField(items, opts.sortBy, opts.descending); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.limit > 0 && len(items) > opts.limit {
+		items = items[:opts.limit]
+		envelope["has_more"] = true
+	}
+
+	columns := opts.fields
+	if len(columns) > 0 {
+		if items, err = projectFields(items, columns); err != nil {
+			return nil, err
+		}
+	} else {
+		columns = scalarColumns(items)
+	}
+
+	switch opts.format {
+	case FormatCompact:
+		delete(envelope, "items")
+		envelope["text"] = compactTable(items, columns)
+	case FormatMarkdown:
+		delete(envelope, "items")
+		envelope["text"] = markdownList(items, columns)
+	default:
+		envelope["items"] = items
+	}
+	return envelope, nil
+}
+
+// lookupField returns the value at a dot-separated path in item and the
+// path's keys as they appear in item. Keys are matched exactly, or else
+// ignoring case and underscores, so farm_id finds farmId.
+func lookupField(item interface{}, path string) (interface{}, []string, bool) {
+	var keys []string
+	value := item
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, nil, false
+		}
+		key, ok := matchKey(object, name)
+		if !ok {
+			return nil, nil, false
+		}
+		keys = append(keys, key)
+		value = object[key]
+	}
+	return value, keys, true
+}
+
+// matchKey finds the key of object named name
+func matchKey(object map[string]interface{}, name string) (string, bool) {
+	if _, ok := object[name]; ok {
+		return name, true
+	}
+	normalize := func(key string) string {
+		return strings.ToLower(strings.ReplaceAll(key, "_", ""))
+	}
+	for key := range object {
+		if normalize(key) == normalize(name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// checkField reports a field that no item has, listing the fields they do have
+func checkField(param string, items []interface{}, path string) error {
+	if len(items) == 0 || slices.ContainsFunc(items, func(item interface{}) bool {
+		_, _, ok := lookupField(item, path)
+		return ok
+	}) {
+		return nil
+	}
+
+	var available []string
+	if first, ok := items[0].(map[string]interface{}); ok {
+		available = SortedKeys(first)
+	}
+	return &schema.ValidationError{Errors: []schema.FieldError{{
+		Field:   param,
+		Message: fmt.Sprintf("names unknown field %s, items have: %s", path, strings.Join(available, ", ")),
+	}}}
+}
+
+// sortByField stably sorts items by the value at path; items without it go last
+func sortByField(items []interface{}, path string, descending bool) error {
+	if err := checkField("sort_by", items, path); err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(items, func(a, b interface{}) int {
+		valueA, _, okA := lookupField(a, path)
+		valueB, _, okB := lookupField(b, path)
+		switch {
+		case !okA || valueA == nil:
+			if !okB || valueB == nil {
+				return 0
+			}
+			return 1
+		case !okB || valueB == nil:
+			return -1
+		}
+
+		result := compareJSONValues(valueA, valueB)
+		if descending {
+			return -result
+		}
+		return result
+	})
+	return nil
+}
+
+// compareJSONValues orders decoded JSON numbers, strings and booleans
+func compareJSONValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case json.Number:
+		if b, ok := b.(json.Number); ok {
+			x, _ := a.Float64()
+			y, _ := b.Float64()
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+		}
+	case bool:
+		if b, ok := b.(bool); ok && a != b {
+			if a {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// projectFields returns copies of items holding only the given field paths,
+// nested as in the original items. Fields must exist on at least one item.
+func projectFields(items []interface{}, fields []string) ([]interface{}, error) {
+	for _, field := range fields {
+		if err := checkField("fields", items, field); err != nil {
+			return nil, err
+		}
+	}
+
+	projected := make([]interface{}, len(items))
+	for i, item := range items {
+		result := make(map[string]interface{})
+		for _, field := range fields {
+			value, keys, ok := lookupField(item, field)
+			if !ok {
+				continue
+			}
+
+			target := result
+			for _, key := range keys[:len(keys)-1] {
+				next, ok := target[key].(map[string]interface{})
+				if !ok {
+					next = make(map[string]interface{})
+					target[key] = next
+				}
+				target = next
+			}
+			target[keys[len(keys)-1]] = value
+		}
+		projected[i] = result
+	}
+	return projected, nil
+}
+
+// scalarColumns returns the scalar top-level fields of items in order
+func scalarColumns(items []interface{}) []string {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range SortedKeys(row) {
+			if !seen[key] && !IsNested(row[key]) {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+	return columns
+}
+
+// shapeCell formats a value for a rendered table or list; nested values are
+// written as compact JSON
+func shapeCell(value interface{}) string {
+	if IsNested(value) {
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+	return TableCell(value)
+}
+
+// compactTable renders items as CSV with a header row of columns
+func compactTable(items []interface{}, columns []string) string {
+	if len(items) == 0 {
+		return "(no items)"
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(columns)
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			if value, _, ok := lookupField(item, column); ok {
+				row[i] = shapeCell(value)
+			}
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// markdownList renders items as a Markdown bullet per item
+func markdownList(items []interface{}, columns []string) string {
+	if len(items) == 0 {
+		return "_No items._"
+	}
+
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		var parts []string
+		for _, column := range columns {
+			if value, _, ok := lookupField(item, column); ok {
+				parts = append(parts, fmt.Sprintf("**%s**: %s", column, shapeCell(value)))
+			}
+		}
+		lines = append(lines, "- "+strings.Join(parts, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IsNested reports whether value is an object or array, which tables leave out
+func IsNested(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// TableCell formats a scalar for a table
+func TableCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// SortedKeys returns the keys of m in order
+func SortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package shape
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"anubis-executer/schema"
+)
+
+// farm is a list item with the JSON field names of a GridProxy farm
+type farm struct {
+	FarmID int    `json:"farmId"`
+	Name   string `json:"name"`
+}
+
+// page is a list envelope like the executor's and the grid snapshot's
+type page struct {
+	Items   []farm `json:"items"`
+	HasMore bool   `json:"has_more"`
+}
+
+func TestApply(t *testing.T) {
+	result := page{Items: []farm{{1, "Freefarm"}, {2, "NileFarm"}, {3, "berlin"}}}
+
+	tests := []struct {
+		name     string
+		params   map[string]interface{}
+		expected string
+	}{
+		{
+			"sorted by name ignoring case",
+			map[string]interface{}{"sort_by": "name", "order": "desc", "fields": []interface{}{"farm_id"}},
+			`{"has_more":false,"items":[{"farmId":2},{"farmId":1},{"farmId":3}]}`,
+		},
+		{
+			"limit",
+			map[string]interface{}{"limit": int64(1)},
+			`{"has_more":true,"items":[{"farmId":1,"name":"Freefarm"}]}`,
+		},
+		{
+			"compact",
+			map[string]interface{}{"format": FormatCompact},
+			`{"has_more":false,"text":"farmId,name\n1,Freefarm\n2,NileFarm\n3,berlin"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shaped, err := Apply(result, OptionsFromParams(tt.params))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			data, err := json.Marshal(shaped)
+			if err != nil {
+				t.Fatalf("failed to encode result: %v", err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, data)
+			}
+		})
+	}
+}
+
+func TestApplyLeavesResultUnchanged(t *testing.T) {
+	result := page{Items: []farm{{1, "Freefarm"}}}
+
+	shaped, err := Apply(result, OptionsFromParams(map[string]interface{}{"format": FormatFull}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := shaped.(page); !ok {
+		t.Errorf("expected the result unchanged, got %T", shaped)
+	}
+}
+
+func TestApplyUnknownField(t *testing.T) {
+	_, err := Apply(page{Items: []farm{{1, "Freefarm"}}}, OptionsFromParams(map[string]interface{}{"sort_by": "colour"}))
+
+	var validationErr *schema.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	expected := "sort_by names unknown field colour, items have: farmId, name"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}